@@ -1,21 +1,39 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
-	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/jwtauth"
 
 	"github.com/otthonleao/go-products.git/configs"
 	_ "github.com/otthonleao/go-products.git/docs"
 	"github.com/otthonleao/go-products.git/internal/entity"
 	"github.com/otthonleao/go-products.git/internal/infra/database"
+	"github.com/otthonleao/go-products.git/internal/infra/logger"
+	"github.com/otthonleao/go-products.git/internal/infra/metrics"
+	"github.com/otthonleao/go-products.git/internal/infra/ratelimit"
+	"github.com/otthonleao/go-products.git/internal/infra/storage"
+	"github.com/otthonleao/go-products.git/internal/infra/tracing"
+	"github.com/otthonleao/go-products.git/internal/infra/webserver"
 	"github.com/otthonleao/go-products.git/internal/infra/webserver/handlers"
+	"github.com/otthonleao/go-products.git/internal/infra/webserver/middlewares"
+	"github.com/otthonleao/go-products.git/internal/infra/worker"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
 )
 
 // @title			Go Products API
@@ -36,49 +54,406 @@ import (
 // @in							header
 // @name						Authorization
 func main() {
+	// Logger provisório até a configuração ser carregada
+	log := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
 	// Carregar configurações
 	configs, err := configs.LoadConfig(".")
 	if err != nil {
-		log.Fatalf("Erro ao carregar configurações: %v", err)
+		fatal(log, "Erro ao carregar configurações", err)
+	}
+
+	log, err = logger.New(os.Stdout, configs.LogLevel, configs.LogFormat)
+	if err != nil {
+		fatal(slog.Default(), "Erro ao configurar o log", err)
+	}
+	slog.SetDefault(log)
+
+	// Tracing distribuído (OpenTelemetry)
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		ServiceName:  "go-products",
+		Exporter:     configs.TracingExporter,
+		OTLPEndpoint: configs.TracingOTLPEndpoint,
+		OTLPInsecure: configs.TracingOTLPInsecure,
+		File:         configs.TracingFile,
+		SampleRatio:  configs.TracingSampleRatio,
+	})
+	if err != nil {
+		fatal(log, "Erro ao configurar o tracing", err)
 	}
 
 	// Inicializar banco de dados SQLite
-	db, err := gorm.Open(sqlite.Open("test.db"), &gorm.Config{})
+	db, err := gorm.Open(sqlite.Open("test.db"), &gorm.Config{
+		Logger: gormLogger.New(slog.NewLogLogger(log.Handler(), slog.LevelWarn), gormLogger.Config{
+			SlowThreshold:             200 * time.Millisecond,
+			LogLevel:                  gormLogger.Warn,
+			IgnoreRecordNotFoundError: true,
+			ParameterizedQueries:      true, // nunca grava valores (senhas, tokens) no log
+		}),
+	})
 	if err != nil {
-		panic(err)
+		fatal(log, "Erro ao abrir o banco de dados", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		fatal(log, "Erro ao migrar o banco de dados", err)
 	}
-	db.AutoMigrate(&entity.Product{}, &entity.User{})
 
-	productDB := database.NewProduct(db)
-	productHandler := handlers.NewProductHandler(productDB)
+	if err := metrics.InstrumentDB(db, "sqlite"); err != nil {
+		fatal(log, "Erro ao instrumentar o banco de dados", err)
+	}
+	if err := tracing.InstrumentDB(db, "sqlite"); err != nil {
+		fatal(log, "Erro ao instrumentar o banco de dados", err)
+	}
+
+	var productDB database.ProductInterface = database.NewProduct(db)
+	orderDB := database.NewOrder(db)
+	if configs.ProductCacheEnabled {
+		productCache := database.NewProductCache(productDB, configs.ProductCacheTTL, configs.ProductCacheMaxEntries)
+		productDB = productCache
+		// Os pedidos mudam o estoque direto no banco e precisam invalidar o cache
+		orderDB.ProductCache = productCache
+	}
+	// Storage dos arquivos enviados (imagens dos produtos)
+	var blobs storage.Storage
+	switch configs.StorageDriver {
+	case "local":
+		publicURL := configs.StoragePublicURL
+		if publicURL == "" {
+			publicURL = "/media"
+		}
+		blobs = storage.NewLocal(configs.StorageLocalDir, publicURL)
+	case "s3":
+		blobs = storage.NewS3(storage.S3Options{
+			Endpoint:        configs.S3Endpoint,
+			Region:          configs.S3Region,
+			Bucket:          configs.S3Bucket,
+			AccessKeyID:     configs.S3AccessKeyID,
+			SecretAccessKey: configs.S3SecretAccessKey,
+			PublicURL:       configs.StoragePublicURL,
+		})
+	default:
+		fatal(log, "Erro ao configurar o storage", fmt.Errorf("unknown STORAGE_DRIVER %q", configs.StorageDriver))
+	}
+	categoryDB := database.NewCategory(db)
+	variantDB := database.NewProductVariant(db)
+	imageDB := database.NewProductImage(db)
+	productHandler := handlers.NewProductHandler(productDB, categoryDB, variantDB, imageDB, blobs, configs.ImageMaxBytes)
+	categoryHandler := handlers.NewCategoryHandler(categoryDB, productDB)
 
 	userDB := database.NewUser(db)
-	userHandler := handlers.NewUserHandler(userDB)
+	organizationDB := database.NewOrganization(db)
+	catalogHandler := handlers.NewCatalogHandler(productDB, variantDB, imageDB, organizationDB, blobs, configs.CatalogMaxAge, configs.CatalogStaleWhileRevalidate)
+	cartDB := database.NewCart(db)
+	cartHandler := handlers.NewCartHandler(cartDB, productDB, variantDB, organizationDB, configs.CartTTL)
+	promotionDB := database.NewPromotion(db)
+	promotionHandler := handlers.NewPromotionHandler(promotionDB, productDB, variantDB, categoryDB, organizationDB)
+	orderHandler := handlers.NewOrderHandler(orderDB, cartDB, productDB, variantDB, organizationDB, promotionDB)
+	userHandler := handlers.NewUserHandler(userDB, organizationDB)
+	organizationHandler := handlers.NewOrganizationHandler(organizationDB, userDB)
+
+	idempotencyDB := database.NewIdempotencyKey(db)
+	idempotency := middlewares.Idempotency(idempotencyDB, configs.IdempotencyKeyTTL, log)
+
+	state := webserver.NewState()
+	healthHandler := handlers.NewHealthHandler(state,
+		handlers.HealthCheck{Name: "database", Check: func(ctx context.Context) error {
+			return database.Ping(ctx, db)
+		}},
+		handlers.HealthCheck{Name: "migrations", Check: func(ctx context.Context) error {
+			pending, err := database.PendingMigrations(ctx, db)
+			if err != nil {
+				return err
+			}
+			if len(pending) > 0 {
+				return fmt.Errorf("pending migrations: %s", strings.Join(pending, ", "))
+			}
+			return nil
+		}},
+	)
+
+	// Workers de segundo plano são encerrados junto com o servidor
+	workers := worker.NewGroup()
+	workers.Every(configs.IdempotencyCleanupInterval, func(ctx context.Context) {
+		if _, err := idempotencyDB.WithContext(ctx).DeleteExpired(time.Now()); err != nil {
+			log.Error("Erro ao remover chaves de idempotência vencidas", slog.Any("error", err))
+		}
+	})
+	workers.Every(configs.CartCleanupInterval, func(ctx context.Context) {
+		deleted, err := cartDB.WithContext(ctx).DeleteExpired(time.Now())
+		if err != nil {
+			log.Error("Erro ao remover carrinhos abandonados", slog.Any("error", err))
+			return
+		}
+		if deleted > 0 {
+			log.Info("Carrinhos abandonados removidos", slog.Int64("count", deleted))
+		}
+	})
 
 	// Inicializar roteador
 	route := chi.NewRouter()
-	route.Use(middleware.Logger)
-	route.Use(middleware.Recoverer)
+	route.Use(middlewares.RequestID)
+	route.Use(tracing.Middleware)
+	route.Use(metrics.Middleware)
+	route.Use(middlewares.RequestLogger(log))
+	route.Use(middlewares.Recoverer(log))
+
+	// O upload de imagens tem limite próprio; a folga cobre os cabeçalhos do multipart
+	route.Use(middlewares.MaxBodySize(configs.WebServerMaxBodyBytes, middlewares.BodySizeOverride{
+		Method:   http.MethodPost,
+		Pattern:  "/products/*/images",
+		MaxBytes: configs.ImageMaxBytes + 64<<10,
+	}))
+
+	if configs.CompressionEnabled {
+		route.Use(middlewares.Compress(middlewares.CompressOptions{
+			MinSize:      configs.CompressionMinSize,
+			ContentTypes: configs.CompressionContentTypes,
+		}))
+	}
+
+	// CORS fica antes do rate limit e do JWT para responder os preflights
+	if len(configs.CORSAllowedOrigins) > 0 {
+		route.Use(middlewares.CORS(middlewares.CORSOptions{
+			AllowedOrigins:   configs.CORSAllowedOrigins,
+			AllowedMethods:   configs.CORSAllowedMethods,
+			AllowedHeaders:   configs.CORSAllowedHeaders,
+			ExposedHeaders:   configs.CORSExposedHeaders,
+			AllowCredentials: configs.CORSAllowCredentials,
+			MaxAge:           configs.CORSMaxAge,
+		}))
+	}
+
+	if configs.RateLimitEnabled {
+		policies, err := ratelimit.ParsePolicies(configs.RateLimitPolicies)
+		if err != nil {
+			fatal(log, "Erro ao ler as políticas de rate limit", err)
+		}
+
+		store := ratelimit.NewMemoryStore()
+		workers.Every(configs.RateLimitCleanupInterval, func(ctx context.Context) {
+			store.Cleanup(ratelimit.LongestPeriod(policies))
+		})
+		route.Use(ratelimit.NewLimiter(store, policies, configs.TokenAuth, log).WithAPIKeys(configs.RateLimitAPIKeys).Middleware)
+	}
 	route.Use(middleware.WithValue("jwt", configs.TokenAuth))
 	route.Use((middleware.WithValue("jwtExpiresIn", configs.JWTExpiresIn)))
 
+	// Sondas para o orquestrador
+	route.Get("/healthz", healthHandler.Liveness)
+	route.Get("/readyz", healthHandler.Readiness)
+	route.Handle("/metrics", metrics.Handler())
+
 	// Register the handler
 	route.Route("/products", func(chiRoute chi.Router) {
-		chiRoute.Use(jwtauth.Verifier(configs.TokenAuth))
-		chiRoute.Use(jwtauth.Authenticator)
-		chiRoute.Post("/", productHandler.Create)
-		chiRoute.Get("/{id}", productHandler.GetProduct)
-		chiRoute.Get("/", productHandler.GetProducts)
-		chiRoute.Put("/{id}", productHandler.UpdateProduct)
-		chiRoute.Delete("/{id}", productHandler.DeleteProduct)
+		chiRoute.Use(tracing.Traced("jwt.verify", jwtauth.Verifier(configs.TokenAuth)))
+		chiRoute.Use(middlewares.Authenticate)
+		chiRoute.Use(middlewares.RequireTenant)
+		chiRoute.With(idempotency).Post("/", tracing.HandlerFunc("ProductHandler.Create", productHandler.Create))
+		chiRoute.With(middlewares.DecompressRequest(configs.BulkMaxDecompressedBytes), idempotency).Post("/bulk", tracing.HandlerFunc("ProductHandler.BulkCreate", productHandler.BulkCreate))
+		chiRoute.Get("/by-sku/{sku}", tracing.HandlerFunc("ProductHandler.GetProductBySKU", productHandler.GetProductBySKU))
+		chiRoute.Get("/by-barcode/{code}", tracing.HandlerFunc("ProductHandler.GetProductByBarcode", productHandler.GetProductByBarcode))
+		chiRoute.Get("/{id}", tracing.HandlerFunc("ProductHandler.GetProduct", productHandler.GetProduct))
+		chiRoute.Get("/", tracing.HandlerFunc("ProductHandler.GetProducts", productHandler.GetProducts))
+		chiRoute.Put("/{id}", tracing.HandlerFunc("ProductHandler.UpdateProduct", productHandler.UpdateProduct))
+		chiRoute.Delete("/{id}", tracing.HandlerFunc("ProductHandler.DeleteProduct", productHandler.DeleteProduct))
+		chiRoute.Put("/{id}/tags", tracing.HandlerFunc("ProductHandler.SetTags", productHandler.SetTags))
+		chiRoute.With(idempotency).Post("/{id}/transitions", tracing.HandlerFunc("ProductHandler.CreateTransition", productHandler.CreateTransition))
+		chiRoute.Get("/{id}/transitions", tracing.HandlerFunc("ProductHandler.GetTransitions", productHandler.GetTransitions))
+		chiRoute.With(idempotency).Post("/{id}/variants", tracing.HandlerFunc("ProductHandler.CreateVariant", productHandler.CreateVariant))
+		chiRoute.Get("/{id}/variants", tracing.HandlerFunc("ProductHandler.GetVariants", productHandler.GetVariants))
+		chiRoute.Get("/{id}/variants/{variantID}", tracing.HandlerFunc("ProductHandler.GetVariant", productHandler.GetVariant))
+		chiRoute.Put("/{id}/variants/{variantID}", tracing.HandlerFunc("ProductHandler.UpdateVariant", productHandler.UpdateVariant))
+		chiRoute.Delete("/{id}/variants/{variantID}", tracing.HandlerFunc("ProductHandler.DeleteVariant", productHandler.DeleteVariant))
+		chiRoute.With(idempotency).Post("/{id}/images", tracing.HandlerFunc("ProductHandler.UploadImage", productHandler.UploadImage))
+		chiRoute.Get("/{id}/images", tracing.HandlerFunc("ProductHandler.GetImages", productHandler.GetImages))
+		chiRoute.Put("/{id}/images/order", tracing.HandlerFunc("ProductHandler.ReorderImages", productHandler.ReorderImages))
+		chiRoute.Put("/{id}/images/{imageID}/primary", tracing.HandlerFunc("ProductHandler.SetPrimaryImage", productHandler.SetPrimaryImage))
+		chiRoute.Delete("/{id}/images/{imageID}", tracing.HandlerFunc("ProductHandler.DeleteImage", productHandler.DeleteImage))
+	})
+
+	route.Route("/categories", func(chiRoute chi.Router) {
+		chiRoute.Use(tracing.Traced("jwt.verify", jwtauth.Verifier(configs.TokenAuth)))
+		chiRoute.Use(middlewares.Authenticate)
+		chiRoute.Use(middlewares.RequireTenant)
+		chiRoute.With(idempotency).Post("/", tracing.HandlerFunc("CategoryHandler.Create", categoryHandler.Create))
+		chiRoute.Get("/", tracing.HandlerFunc("CategoryHandler.GetCategories", categoryHandler.GetCategories))
+		chiRoute.Get("/{id}", tracing.HandlerFunc("CategoryHandler.GetCategory", categoryHandler.GetCategory))
+		chiRoute.Put("/{id}", tracing.HandlerFunc("CategoryHandler.UpdateCategory", categoryHandler.UpdateCategory))
+		chiRoute.Delete("/{id}", tracing.HandlerFunc("CategoryHandler.DeleteCategory", categoryHandler.DeleteCategory))
+	})
+
+	// Catálogo público, só leitura e sem autenticação
+	route.Route("/catalog/{organizationID}/products", func(chiRoute chi.Router) {
+		chiRoute.Get("/", tracing.HandlerFunc("CatalogHandler.GetProducts", catalogHandler.GetProducts))
+		chiRoute.Get("/{id}", tracing.HandlerFunc("CatalogHandler.GetProduct", catalogHandler.GetProduct))
+	})
+
+	// Carrinhos aceitam usuários autenticados e anônimos (via X-Cart-Token)
+	route.Route("/carts", func(chiRoute chi.Router) {
+		chiRoute.Use(tracing.Traced("jwt.verify", jwtauth.Verifier(configs.TokenAuth)))
+		chiRoute.Use(middlewares.OptionalIdentify)
+		chiRoute.Post("/", tracing.HandlerFunc("CartHandler.Create", cartHandler.Create))
+		chiRoute.Get("/{id}", tracing.HandlerFunc("CartHandler.GetCart", cartHandler.GetCart))
+		chiRoute.Delete("/{id}", tracing.HandlerFunc("CartHandler.DeleteCart", cartHandler.DeleteCart))
+		chiRoute.Post("/{id}/items", tracing.HandlerFunc("CartHandler.AddItem", cartHandler.AddItem))
+		chiRoute.Put("/{id}/items/{itemID}", tracing.HandlerFunc("CartHandler.UpdateItem", cartHandler.UpdateItem))
+		chiRoute.Delete("/{id}/items/{itemID}", tracing.HandlerFunc("CartHandler.DeleteItem", cartHandler.DeleteItem))
+	})
+
+	// Pedidos da organização, só para owners e admins
+	route.Route("/orders", func(chiRoute chi.Router) {
+		chiRoute.Use(tracing.Traced("jwt.verify", jwtauth.Verifier(configs.TokenAuth)))
+		chiRoute.Use(middlewares.Authenticate)
+		chiRoute.Use(middlewares.RequireTenant)
+		chiRoute.Use(middlewares.RequireRole(entity.RoleOwner, entity.RoleAdmin))
+		chiRoute.Get("/", tracing.HandlerFunc("OrderHandler.GetOrders", orderHandler.GetOrders))
+		chiRoute.Get("/{id}", tracing.HandlerFunc("OrderHandler.GetOrder", orderHandler.GetOrder))
+		chiRoute.With(idempotency).Post("/{id}/transitions", tracing.HandlerFunc("OrderHandler.CreateTransition", orderHandler.CreateTransition))
+	})
+
+	// Promoções e cupons da organização, só para owners e admins
+	route.Route("/promotions", func(chiRoute chi.Router) {
+		chiRoute.Use(tracing.Traced("jwt.verify", jwtauth.Verifier(configs.TokenAuth)))
+		chiRoute.Use(middlewares.Authenticate)
+		chiRoute.Use(middlewares.RequireTenant)
+		chiRoute.Use(middlewares.RequireRole(entity.RoleOwner, entity.RoleAdmin))
+		chiRoute.With(idempotency).Post("/", tracing.HandlerFunc("PromotionHandler.Create", promotionHandler.Create))
+		chiRoute.Get("/", tracing.HandlerFunc("PromotionHandler.GetPromotions", promotionHandler.GetPromotions))
+		chiRoute.Get("/{id}", tracing.HandlerFunc("PromotionHandler.GetPromotion", promotionHandler.GetPromotion))
+		chiRoute.Put("/{id}", tracing.HandlerFunc("PromotionHandler.UpdatePromotion", promotionHandler.UpdatePromotion))
+		chiRoute.Delete("/{id}", tracing.HandlerFunc("PromotionHandler.DeletePromotion", promotionHandler.DeletePromotion))
 	})
 
-	route.Post("/users", userHandler.Create)
-	route.Post("/users/login", userHandler.GetJWT)
+	// Cotação pública, com os preços do catálogo
+	route.Post("/pricing/quote", tracing.HandlerFunc("PromotionHandler.CreateQuote", promotionHandler.CreateQuote))
+
+	route.Route("/organizations", func(chiRoute chi.Router) {
+		chiRoute.Use(tracing.Traced("jwt.verify", jwtauth.Verifier(configs.TokenAuth)))
+		chiRoute.Use(middlewares.Identify)
+		chiRoute.With(idempotency).Post("/", tracing.HandlerFunc("OrganizationHandler.Create", organizationHandler.Create))
+		chiRoute.With(idempotency).Post("/{id}/members", tracing.HandlerFunc("OrganizationHandler.AddMember", organizationHandler.AddMember))
+	})
+
+	// Pedidos do usuário autenticado, em qualquer organização
+	route.Route("/users/me/orders", func(chiRoute chi.Router) {
+		chiRoute.Use(tracing.Traced("jwt.verify", jwtauth.Verifier(configs.TokenAuth)))
+		chiRoute.Use(middlewares.Identify)
+		chiRoute.With(idempotency).Post("/", tracing.HandlerFunc("OrderHandler.PlaceOrder", orderHandler.PlaceOrder))
+		chiRoute.Get("/", tracing.HandlerFunc("OrderHandler.GetMyOrders", orderHandler.GetMyOrders))
+		chiRoute.Get("/{id}", tracing.HandlerFunc("OrderHandler.GetMyOrder", orderHandler.GetMyOrder))
+		chiRoute.Post("/{id}/cancel", tracing.HandlerFunc("OrderHandler.CancelMyOrder", orderHandler.CancelMyOrder))
+	})
+
+	route.With(idempotency).Post("/users", tracing.HandlerFunc("UserHandler.Create", userHandler.Create))
+	route.Post("/users/login", tracing.HandlerFunc("UserHandler.GetJWT", userHandler.GetJWT))
+	route.With(tracing.Traced("jwt.verify", jwtauth.Verifier(configs.TokenAuth)), middlewares.Identify).
+		Get("/users/{id}", tracing.HandlerFunc("UserHandler.GetUser", userHandler.GetUser))
+
+	// Arquivos do storage local, públicos como os de um bucket
+	if local, ok := blobs.(*storage.Local); ok {
+		route.Handle("/media/*", http.StripPrefix("/media", local.Handler()))
+	}
 
 	// Subindo a documentação do webservice
-	route.Get("/swagger/*", httpSwagger.Handler(httpSwagger.URL("http://localhost:8000/swagger/doc.json")))
+	route.Get("/swagger/*", httpSwagger.Handler(httpSwagger.URL("/swagger/doc.json")))
+
+	serverOptions := webserver.ServerOptions{
+		ReadTimeout:       configs.WebServerReadTimeout,
+		ReadHeaderTimeout: configs.WebServerReadHeaderTimeout,
+		WriteTimeout:      configs.WebServerWriteTimeout,
+		IdleTimeout:       configs.WebServerIdleTimeout,
+		MaxHeaderBytes:    configs.WebServerMaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(log.Handler(), slog.LevelWarn),
+	}
+
+	// TLS nativo, com recarga automática do certificado e mTLS opcional
+	if configs.TLSEnabled() {
+		reloader, err := webserver.NewCertificateReloader(configs.TLSCertFile, configs.TLSKeyFile)
+		if err != nil {
+			fatal(log, "Erro ao carregar o certificado TLS", err)
+		}
+
+		serverOptions.TLSConfig, err = webserver.NewTLSConfig(reloader, webserver.TLSOptions{
+			MinVersion:        configs.TLSMinVersion,
+			HTTP2:             configs.HTTP2Enabled,
+			ClientCAFile:      configs.TLSClientCAFile,
+			RequireClientCert: configs.TLSRequireClientCert,
+		})
+		if err != nil {
+			fatal(log, "Erro ao configurar o TLS", err)
+		}
+
+		workers.Every(configs.TLSReloadInterval, func(ctx context.Context) {
+			reloaded, err := reloader.ReloadIfChanged()
+			if err != nil {
+				log.Error("Erro ao recarregar o certificado TLS", slog.Any("error", err))
+			} else if reloaded {
+				log.Info("Certificado TLS recarregado")
+			}
+		})
+	}
+
+	server := webserver.NewServer(configs.WebServerAddress(), route, serverOptions)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		fatal(log, "Erro ao iniciar o servidor", err)
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Info("Servidor ouvindo", slog.String("address", listener.Addr().String()))
+		if server.TLSConfig != nil {
+			// Certificado e chave vêm do GetCertificate do TLSConfig
+			serverErr <- server.ServeTLS(listener, "", "")
+			return
+		}
+		serverErr <- server.Serve(listener)
+	}()
+	state.SetReady()
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			fatal(log, "Erro ao iniciar o servidor", err)
+		}
+	case <-ctx.Done():
+		log.Info("Sinal de encerramento recebido, aguardando requisições em andamento")
+	}
+
+	// A sonda de prontidão passa a responder 503 para o balanceador parar de enviar tráfego
+	state.SetDraining()
+	time.Sleep(configs.WebServerDrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), configs.WebServerShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Error("Erro ao encerrar o servidor", slog.Any("error", err))
+	}
+
+	if err := workers.Stop(shutdownCtx); err != nil {
+		log.Error("Erro ao encerrar os workers", slog.Any("error", err))
+	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Error("Erro ao encerrar o tracing", slog.Any("error", err))
+	}
+
+	if sqlDB, err := db.DB(); err == nil {
+		if err := sqlDB.Close(); err != nil {
+			log.Error("Erro ao fechar o banco de dados", slog.Any("error", err))
+		}
+	}
+
+	log.Info("Servidor encerrado")
+}
 
-	// http.HandleFunc("/products", productHandler.Create)
-	http.ListenAndServe(":8000", route)
+// fatal registra o erro e encerra o processo.
+func fatal(log *slog.Logger, message string, err error) {
+	log.Error(message, slog.Any("error", err))
+	os.Exit(1)
 }
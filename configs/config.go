@@ -1,21 +1,79 @@
 package configs
 
 import (
+	"net"
+	"time"
+
 	"github.com/go-chi/jwtauth"
 	"github.com/spf13/viper"
 )
 
 type conf struct {
-	DBDriver      string `mapstructure:"DB_DRIVER"`
-	DBHost        string `mapstructure:"DB_HOST"`
-	DBPort        string `mapstructure:"DB_PORT"`
-	DBUser        string `mapstructure:"DB_USER"`
-	DBPassword    string `mapstructure:"DB_PASSWORD"`
-	DBName        string `mapstructure:"DB_NAME"`
-	WebServerPort string `mapstructure:"WEB_SERVER_PORT"`
-	JWTSecret     string `mapstructure:"JWT_SECRET"`
-	JWTExpiresIn  int 	 `mapstructure:"JWT_EXPIRES_IN"`
-	TokenAuth     *jwtauth.JWTAuth
+	DBDriver                   string        `mapstructure:"DB_DRIVER"`
+	DBHost                     string        `mapstructure:"DB_HOST"`
+	DBPort                     string        `mapstructure:"DB_PORT"`
+	DBUser                     string        `mapstructure:"DB_USER"`
+	DBPassword                 string        `mapstructure:"DB_PASSWORD"`
+	DBName                     string        `mapstructure:"DB_NAME"`
+	WebServerHost              string        `mapstructure:"WEB_SERVER_HOST"`
+	WebServerPort              string        `mapstructure:"WEB_SERVER_PORT"`
+	WebServerReadTimeout       time.Duration `mapstructure:"WEB_SERVER_READ_TIMEOUT"`
+	WebServerReadHeaderTimeout time.Duration `mapstructure:"WEB_SERVER_READ_HEADER_TIMEOUT"`
+	WebServerWriteTimeout      time.Duration `mapstructure:"WEB_SERVER_WRITE_TIMEOUT"`
+	WebServerIdleTimeout       time.Duration `mapstructure:"WEB_SERVER_IDLE_TIMEOUT"`
+	WebServerMaxHeaderBytes    int           `mapstructure:"WEB_SERVER_MAX_HEADER_BYTES"`
+	WebServerMaxBodyBytes      int64         `mapstructure:"WEB_SERVER_MAX_BODY_BYTES"`
+	WebServerShutdownTimeout   time.Duration `mapstructure:"WEB_SERVER_SHUTDOWN_TIMEOUT"`
+	WebServerDrainDelay        time.Duration `mapstructure:"WEB_SERVER_DRAIN_DELAY"`
+	TLSCertFile                string        `mapstructure:"TLS_CERT_FILE"`
+	TLSKeyFile                 string        `mapstructure:"TLS_KEY_FILE"`
+	TLSMinVersion              string        `mapstructure:"TLS_MIN_VERSION"`
+	TLSReloadInterval          time.Duration `mapstructure:"TLS_RELOAD_INTERVAL"`
+	TLSClientCAFile            string        `mapstructure:"TLS_CLIENT_CA_FILE"`
+	TLSRequireClientCert       bool          `mapstructure:"TLS_REQUIRE_CLIENT_CERT"`
+	HTTP2Enabled               bool          `mapstructure:"HTTP2_ENABLED"`
+	TracingExporter            string        `mapstructure:"TRACING_EXPORTER"`
+	TracingOTLPEndpoint        string        `mapstructure:"TRACING_OTLP_ENDPOINT"`
+	TracingOTLPInsecure        bool          `mapstructure:"TRACING_OTLP_INSECURE"`
+	TracingFile                string        `mapstructure:"TRACING_FILE"`
+	TracingSampleRatio         float64       `mapstructure:"TRACING_SAMPLE_RATIO"`
+	LogLevel                   string        `mapstructure:"LOG_LEVEL"`
+	LogFormat                  string        `mapstructure:"LOG_FORMAT"`
+	RateLimitEnabled           bool          `mapstructure:"RATE_LIMIT_ENABLED"`
+	RateLimitPolicies          string        `mapstructure:"RATE_LIMIT_POLICIES"`
+	RateLimitCleanupInterval   time.Duration `mapstructure:"RATE_LIMIT_CLEANUP_INTERVAL"`
+	RateLimitAPIKeys           []string      `mapstructure:"RATE_LIMIT_API_KEYS"`
+	CORSAllowedOrigins         []string      `mapstructure:"CORS_ALLOWED_ORIGINS"`
+	CORSAllowedMethods         []string      `mapstructure:"CORS_ALLOWED_METHODS"`
+	CORSAllowedHeaders         []string      `mapstructure:"CORS_ALLOWED_HEADERS"`
+	CORSExposedHeaders         []string      `mapstructure:"CORS_EXPOSED_HEADERS"`
+	CORSAllowCredentials       bool          `mapstructure:"CORS_ALLOW_CREDENTIALS"`
+	CORSMaxAge                 time.Duration `mapstructure:"CORS_MAX_AGE"`
+	CompressionEnabled         bool          `mapstructure:"COMPRESSION_ENABLED"`
+	CompressionMinSize         int           `mapstructure:"COMPRESSION_MIN_SIZE"`
+	CompressionContentTypes    []string      `mapstructure:"COMPRESSION_CONTENT_TYPES"`
+	BulkMaxDecompressedBytes   int64         `mapstructure:"BULK_MAX_DECOMPRESSED_BYTES"`
+	StorageDriver              string        `mapstructure:"STORAGE_DRIVER"`
+	StorageLocalDir            string        `mapstructure:"STORAGE_LOCAL_DIR"`
+	StoragePublicURL           string        `mapstructure:"STORAGE_PUBLIC_URL"`
+	S3Endpoint                 string        `mapstructure:"S3_ENDPOINT"`
+	S3Region                   string        `mapstructure:"S3_REGION"`
+	S3Bucket                   string        `mapstructure:"S3_BUCKET"`
+	S3AccessKeyID              string        `mapstructure:"S3_ACCESS_KEY_ID"`
+	S3SecretAccessKey          string        `mapstructure:"S3_SECRET_ACCESS_KEY"`
+	ImageMaxBytes              int64         `mapstructure:"IMAGE_MAX_BYTES"`
+	ProductCacheEnabled        bool          `mapstructure:"PRODUCT_CACHE_ENABLED"`
+	ProductCacheTTL            time.Duration `mapstructure:"PRODUCT_CACHE_TTL"`
+	ProductCacheMaxEntries     int           `mapstructure:"PRODUCT_CACHE_MAX_ENTRIES"`
+	CatalogMaxAge              time.Duration `mapstructure:"CATALOG_MAX_AGE"`
+	CatalogStaleWhileRevalidate time.Duration `mapstructure:"CATALOG_STALE_WHILE_REVALIDATE"`
+	CartTTL                    time.Duration `mapstructure:"CART_TTL"`
+	CartCleanupInterval        time.Duration `mapstructure:"CART_CLEANUP_INTERVAL"`
+	IdempotencyKeyTTL          time.Duration `mapstructure:"IDEMPOTENCY_KEY_TTL"`
+	IdempotencyCleanupInterval time.Duration `mapstructure:"IDEMPOTENCY_CLEANUP_INTERVAL"`
+	JWTSecret                  string        `mapstructure:"JWT_SECRET"`
+	JWTExpiresIn               int           `mapstructure:"JWT_EXPIRES_IN"`
+	TokenAuth                  *jwtauth.JWTAuth
 }
 
 func LoadConfig(path string) (*conf, error) {
@@ -27,17 +85,88 @@ func LoadConfig(path string) (*conf, error) {
 	viper.AddConfigPath(path);
 	viper.SetConfigFile(".env");
 	viper.AutomaticEnv();
+	setDefaults();
 
 	err := viper.ReadInConfig();
 	if err != nil {
-		panic(err);
+		return nil, err;
 	}
 
 	err = viper.Unmarshal(&cfg);
 	if err != nil {
-		panic((err));
+		return nil, err;
 	}
 
 	cfg.TokenAuth = jwtauth.New("HS256", []byte(cfg.JWTSecret), nil);
 	return cfg, nil;
 }
+
+// setDefaults define valores usados quando a variável não está no .env nem no ambiente.
+func setDefaults() {
+	viper.SetDefault("WEB_SERVER_HOST", "")
+	viper.SetDefault("WEB_SERVER_PORT", "8000")
+	viper.SetDefault("WEB_SERVER_READ_TIMEOUT", 15*time.Second)
+	viper.SetDefault("WEB_SERVER_READ_HEADER_TIMEOUT", 5*time.Second)
+	viper.SetDefault("WEB_SERVER_WRITE_TIMEOUT", 30*time.Second)
+	viper.SetDefault("WEB_SERVER_IDLE_TIMEOUT", 120*time.Second)
+	viper.SetDefault("WEB_SERVER_MAX_HEADER_BYTES", 1<<20)
+	viper.SetDefault("WEB_SERVER_MAX_BODY_BYTES", 1<<20)
+	viper.SetDefault("WEB_SERVER_SHUTDOWN_TIMEOUT", 20*time.Second)
+	viper.SetDefault("WEB_SERVER_DRAIN_DELAY", 0)
+	viper.SetDefault("TLS_CERT_FILE", "")
+	viper.SetDefault("TLS_KEY_FILE", "")
+	viper.SetDefault("TLS_MIN_VERSION", "1.2")
+	viper.SetDefault("TLS_RELOAD_INTERVAL", 30*time.Second)
+	viper.SetDefault("TLS_CLIENT_CA_FILE", "")
+	viper.SetDefault("TLS_REQUIRE_CLIENT_CERT", false)
+	viper.SetDefault("HTTP2_ENABLED", true)
+	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("LOG_FORMAT", "json")
+	viper.SetDefault("RATE_LIMIT_ENABLED", true)
+	viper.SetDefault("RATE_LIMIT_POLICIES", "POST /users/login=ip:10/1m;POST /users=ip:10/1m;/products=user:120/1m;POST /pricing/quote=ip:60/1m;/carts=ip:120/1m;/catalog=ip:300/1m")
+	viper.SetDefault("RATE_LIMIT_CLEANUP_INTERVAL", time.Minute)
+	viper.SetDefault("RATE_LIMIT_API_KEYS", []string{})
+	viper.SetDefault("CORS_ALLOWED_ORIGINS", []string{})
+	viper.SetDefault("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"})
+	viper.SetDefault("CORS_ALLOWED_HEADERS", []string{"Authorization", "Content-Type", "X-Request-ID", "Idempotency-Key", "Content-Encoding", "X-Cart-Token"})
+	viper.SetDefault("CORS_EXPOSED_HEADERS", []string{"X-Request-ID", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", "Idempotent-Replayed"})
+	viper.SetDefault("CORS_ALLOW_CREDENTIALS", false)
+	viper.SetDefault("CORS_MAX_AGE", 10*time.Minute)
+	viper.SetDefault("COMPRESSION_ENABLED", true)
+	viper.SetDefault("COMPRESSION_MIN_SIZE", 1024)
+	viper.SetDefault("COMPRESSION_CONTENT_TYPES", []string{"application/json", "text/plain", "text/html", "text/css", "application/javascript"})
+	viper.SetDefault("BULK_MAX_DECOMPRESSED_BYTES", 10<<20)
+	viper.SetDefault("STORAGE_DRIVER", "local")
+	viper.SetDefault("STORAGE_LOCAL_DIR", "./uploads")
+	viper.SetDefault("STORAGE_PUBLIC_URL", "")
+	viper.SetDefault("S3_ENDPOINT", "")
+	viper.SetDefault("S3_REGION", "us-east-1")
+	viper.SetDefault("S3_BUCKET", "")
+	viper.SetDefault("S3_ACCESS_KEY_ID", "")
+	viper.SetDefault("S3_SECRET_ACCESS_KEY", "")
+	viper.SetDefault("IMAGE_MAX_BYTES", 10<<20)
+	viper.SetDefault("PRODUCT_CACHE_ENABLED", false)
+	viper.SetDefault("PRODUCT_CACHE_TTL", 30*time.Second)
+	viper.SetDefault("PRODUCT_CACHE_MAX_ENTRIES", 10000)
+	viper.SetDefault("CATALOG_MAX_AGE", time.Minute)
+	viper.SetDefault("CATALOG_STALE_WHILE_REVALIDATE", 5*time.Minute)
+	viper.SetDefault("CART_TTL", 72*time.Hour)
+	viper.SetDefault("CART_CLEANUP_INTERVAL", time.Hour)
+	viper.SetDefault("IDEMPOTENCY_KEY_TTL", 24*time.Hour)
+	viper.SetDefault("IDEMPOTENCY_CLEANUP_INTERVAL", time.Hour)
+	viper.SetDefault("TRACING_EXPORTER", "none")
+	viper.SetDefault("TRACING_OTLP_ENDPOINT", "localhost:4318")
+	viper.SetDefault("TRACING_OTLP_INSECURE", true)
+	viper.SetDefault("TRACING_FILE", "")
+	viper.SetDefault("TRACING_SAMPLE_RATIO", 1.0)
+}
+
+// WebServerAddress retorna o endereço no formato host:porta usado pelo servidor HTTP.
+func (c *conf) WebServerAddress() string {
+	return net.JoinHostPort(c.WebServerHost, c.WebServerPort)
+}
+
+// TLSEnabled indica se o servidor deve terminar TLS por conta própria.
+func (c *conf) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
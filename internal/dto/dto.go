@@ -1,21 +1,197 @@
 package dto
 
+import (
+	"time"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+)
+
 type CreateProductInput struct {
-	Name  string  `json:"name"`
-	Price float64 `json:"price"`
+	Name    string  `json:"name" validate:"required,max=100"`
+	Price   float64 `json:"price" validate:"required,gt=0,max=1000000,maxdecimals=2"`
+	SKU     string  `json:"sku,omitempty" validate:"max=64,identifier"`
+	Barcode string  `json:"barcode,omitempty" validate:"gtin"`
+	// OptionAxes são os eixos das variantes, como ["size", "color"]
+	OptionAxes []string `json:"option_axes,omitempty" validate:"max=3"`
+	CategoryID string   `json:"category_id,omitempty" validate:"uuid"`
+	// Attributes são validados contra o esquema da categoria
+	Attributes map[string]interface{} `json:"attributes,omitempty" validate:"max=50"`
+	Tags       []string               `json:"tags,omitempty" validate:"max=20"`
+	// Stock vazio deixa o estoque do produto sem controle
+	Stock *int `json:"stock,omitempty" validate:"min=0"`
+}
+
+type SetTagsInput struct {
+	// Tags substitui todas as tags do produto; uma lista vazia remove as tags
+	Tags []string `json:"tags" validate:"max=20"`
+}
+
+type TransitionInput struct {
+	To      string `json:"to" validate:"required,oneof=draft in_review published archived"`
+	Comment string `json:"comment,omitempty" validate:"max=500"`
+}
+
+// ProductListOutput é a resposta da listagem quando as contagens são pedidas.
+type ProductListOutput struct {
+	Products []entity.Product     `json:"products"`
+	Facets   entity.ProductFacets `json:"facets"`
+}
+
+type AttributeDefinitionInput struct {
+	Name     string   `json:"name" validate:"required,max=64,identifier"`
+	Type     string   `json:"type" validate:"required,oneof=string number bool enum"`
+	Required bool     `json:"required"`
+	Unit     string   `json:"unit,omitempty" validate:"max=16"`
+	Options  []string `json:"options,omitempty" validate:"max=100"`
+}
+
+type CreateCategoryInput struct {
+	Name       string                     `json:"name" validate:"required,max=100"`
+	Attributes []AttributeDefinitionInput `json:"attributes,omitempty" validate:"max=50"`
+}
+
+type CreateVariantInput struct {
+	// Options deve ter um valor para cada eixo de opção do produto
+	Options map[string]string `json:"options" validate:"required"`
+	SKU     string            `json:"sku,omitempty" validate:"max=64,identifier"`
+	// Price substitui o preço do produto; sem ele vale o preço do produto
+	Price *float64 `json:"price,omitempty" validate:"gt=0,max=1000000,maxdecimals=2"`
+	Stock int      `json:"stock" validate:"min=0"`
+}
+
+type ReorderImagesInput struct {
+	// ImageIDs lista todas as imagens do produto na nova ordem
+	ImageIDs []string `json:"image_ids" validate:"required"`
 }
 
 type CreateUserInput struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Name  string `json:"name" validate:"required,min=2,max=100"`
+	Email string `json:"email" validate:"required,email,max=254"`
+	// O bcrypt não aceita senhas com mais de 72 bytes
+	Password string `json:"password" validate:"required,min=6,max=72"`
 }
 
 type GetJWTInput struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email          string `json:"email" validate:"required,email"`
+	Password       string `json:"password" validate:"required"`
+	OrganizationID string `json:"organization_id,omitempty" validate:"uuid"`
 }
 
 type GetJWTOutput struct {
 	AccessToken string `json:"access_token"`
-}
\ No newline at end of file
+}
+
+type CreateOrganizationInput struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type AddMemberInput struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"oneof=owner admin member"`
+}
+
+// CatalogProduct é a representação pública de um produto publicado, sem os
+// dados internos da organização, o estado do fluxo ou o estoque exato.
+type CatalogProduct struct {
+	ID          string                 `json:"id"`
+	Name        string                 `json:"name"`
+	Price       float64                `json:"price"`
+	SKU         string                 `json:"sku,omitempty"`
+	Barcode     string                 `json:"barcode,omitempty"`
+	CategoryID  string                 `json:"category_id,omitempty"`
+	Attributes  map[string]interface{} `json:"attributes,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	InStock     bool                   `json:"in_stock"`
+	OptionAxes  []string               `json:"option_axes,omitempty"`
+	Variants    []CatalogVariant       `json:"variants,omitempty"`
+	Images      []CatalogImage         `json:"images,omitempty"`
+	PublishedAt *time.Time             `json:"published_at,omitempty"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+type CatalogVariant struct {
+	ID      string            `json:"id"`
+	Options map[string]string `json:"options"`
+	SKU     string            `json:"sku,omitempty"`
+	// Price já considera o preço do produto quando a variante não tem um próprio
+	Price   float64 `json:"price"`
+	InStock bool    `json:"in_stock"`
+}
+
+type CatalogImage struct {
+	URL        string            `json:"url"`
+	Width      int               `json:"width"`
+	Height     int               `json:"height"`
+	Primary    bool              `json:"primary"`
+	Thumbnails map[string]string `json:"thumbnails,omitempty"`
+}
+
+// CatalogListOutput é a resposta do catálogo quando as contagens são pedidas.
+type CatalogListOutput struct {
+	Products []CatalogProduct     `json:"products"`
+	Facets   entity.ProductFacets `json:"facets"`
+}
+
+type CreateCartInput struct {
+	OrganizationID string `json:"organization_id" validate:"required,uuid"`
+}
+
+type AddCartItemInput struct {
+	ProductID string `json:"product_id" validate:"required,uuid"`
+	// VariantID é obrigatório para produtos com variantes
+	VariantID string `json:"variant_id,omitempty" validate:"uuid"`
+	Quantity  int    `json:"quantity" validate:"required,min=1,max=999"`
+}
+
+type UpdateCartItemInput struct {
+	Quantity int `json:"quantity" validate:"required,min=1,max=999"`
+}
+
+// CreateOrderInput cria o pedido a partir de um carrinho (cart_id) ou de uma
+// lista de itens de uma organização (organization_id e items).
+type CreateOrderInput struct {
+	CartID         string           `json:"cart_id,omitempty" validate:"uuid"`
+	OrganizationID string           `json:"organization_id,omitempty" validate:"uuid"`
+	Items          []OrderItemInput `json:"items,omitempty" validate:"max=100"`
+	CouponCode     string           `json:"coupon_code,omitempty" validate:"max=32"`
+}
+
+type OrderItemInput struct {
+	ProductID string `json:"product_id" validate:"required,uuid"`
+	VariantID string `json:"variant_id,omitempty" validate:"uuid"`
+	Quantity  int    `json:"quantity" validate:"required,min=1,max=999"`
+}
+
+type OrderTransitionInput struct {
+	To string `json:"to" validate:"required,oneof=paid fulfilled cancelled refunded"`
+}
+
+// PromotionInput cria ou substitui uma promoção. Conforme kind, o desconto vem
+// de percentage, amount ou buy_quantity e free_quantity; com code a promoção
+// só vale como cupom.
+type PromotionInput struct {
+	Name       string  `json:"name" validate:"required,max=100"`
+	Kind       string  `json:"kind" validate:"required,oneof=percentage fixed buy_x_get_y"`
+	Percentage float64 `json:"percentage,omitempty" validate:"gt=0,max=100,maxdecimals=2"`
+	// Amount é o desconto por unidade, em centavos
+	Amount       int64    `json:"amount,omitempty" validate:"min=1"`
+	BuyQuantity  int      `json:"buy_quantity,omitempty" validate:"min=1,max=999"`
+	FreeQuantity int      `json:"free_quantity,omitempty" validate:"min=1,max=999"`
+	ProductIDs   []string `json:"product_ids,omitempty" validate:"max=100"`
+	CategoryIDs  []string `json:"category_ids,omitempty" validate:"max=100"`
+	Code         string   `json:"code,omitempty" validate:"max=32,identifier"`
+	// UsageLimit vazio permite usos ilimitados do cupom
+	UsageLimit *int       `json:"usage_limit,omitempty" validate:"min=1"`
+	StartsAt   *time.Time `json:"starts_at,omitempty"`
+	EndsAt     *time.Time `json:"ends_at,omitempty"`
+	// Active vazio deixa a promoção ativa
+	Active *bool `json:"active,omitempty"`
+}
+
+// QuoteInput cota os itens de uma organização com as promoções vigentes e,
+// opcionalmente, um cupom.
+type QuoteInput struct {
+	OrganizationID string           `json:"organization_id" validate:"required,uuid"`
+	Items          []OrderItemInput `json:"items" validate:"required,min=1,max=100"`
+	CouponCode     string           `json:"coupon_code,omitempty" validate:"max=32"`
+}
@@ -0,0 +1,198 @@
+package entity
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/otthonleao/go-products.git/pkg/money"
+)
+
+// Limites de um carrinho.
+const (
+	MaxCartItems        = 100
+	MaxCartItemQuantity = 999
+)
+
+var (
+	ErrInvalidQuantity  = errors.New("invalid quantity")
+	ErrTooManyCartItems = errors.New("too many items in the cart")
+	ErrCartExpired      = errors.New("cart expired")
+)
+
+// Cart é um carrinho de compras de uma organização. Carrinhos de usuários
+// autenticados pertencem ao usuário; os anônimos são acessados com o token
+// entregue na criação, do qual só o hash é guardado.
+type Cart struct {
+	ID             entity.ID  `json:"id"`
+	OrganizationID entity.ID  `json:"organization_id" gorm:"index"`
+	UserID         *entity.ID `json:"user_id,omitempty" gorm:"index"`
+	TokenHash      string     `json:"-"`
+	// Token só é preenchido na resposta da criação de carrinhos anônimos
+	Token string     `json:"token,omitempty" gorm:"-"`
+	Items []CartItem `json:"items" gorm:"-"`
+	// Subtotal e ItemCount são calculados por Totals a partir dos itens
+	Subtotal  money.Cents `json:"subtotal" gorm:"-"`
+	ItemCount int         `json:"item_count" gorm:"-"`
+	ExpiresAt time.Time   `json:"expires_at" gorm:"index"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// CartItem é uma linha do carrinho. Nome e preço são copiados do produto (ou
+// da variante) quando a linha é criada e não mudam com o catálogo.
+type CartItem struct {
+	ID        entity.ID   `json:"id"`
+	CartID    entity.ID   `json:"-" gorm:"index"`
+	ProductID entity.ID   `json:"product_id"`
+	VariantID *entity.ID  `json:"variant_id,omitempty"`
+	Name      string      `json:"name"`
+	UnitPrice money.Cents `json:"unit_price"`
+	Quantity  int         `json:"quantity"`
+	Total     money.Cents `json:"total" gorm:"-"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// NewCart cria um carrinho que expira depois de ttl sem alterações. Sem
+// usuário o carrinho é anônimo e o token de acesso é gerado em Token.
+func NewCart(organizationID entity.ID, userID *entity.ID, ttl time.Duration) (*Cart, error) {
+	now := time.Now()
+	cart := &Cart{
+		ID:             entity.NewID(),
+		OrganizationID: organizationID,
+		UserID:         userID,
+		Items:          []CartItem{},
+		ExpiresAt:      now.Add(ttl),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	if userID == nil {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		cart.Token = base64.RawURLEncoding.EncodeToString(secret)
+		cart.TokenHash = hashCartToken(cart.Token)
+	}
+
+	return cart, nil
+}
+
+func hashCartToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckToken confere o token de um carrinho anônimo em tempo constante.
+func (c *Cart) CheckToken(token string) bool {
+	if c.TokenHash == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashCartToken(token)), []byte(c.TokenHash)) == 1
+}
+
+// Expired indica se o carrinho passou da validade em now.
+func (c *Cart) Expired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
+
+// Touch registra uma alteração e renova a validade do carrinho.
+func (c *Cart) Touch(ttl time.Duration) {
+	now := time.Now()
+	c.UpdatedAt = now
+	c.ExpiresAt = now.Add(ttl)
+}
+
+// Item retorna a linha do produto e variante informados.
+func (c *Cart) Item(productID entity.ID, variantID *entity.ID) (*CartItem, bool) {
+	for i := range c.Items {
+		item := &c.Items[i]
+		if item.ProductID != productID {
+			continue
+		}
+		if (item.VariantID == nil && variantID == nil) || (item.VariantID != nil && variantID != nil && *item.VariantID == *variantID) {
+			return item, true
+		}
+	}
+	return nil, false
+}
+
+// AddItem soma quantity à linha do produto e variante ou, se ela não existe,
+// cria uma nova linha no carrinho. A linha existente mantém o preço capturado
+// quando foi criada. Retorna a linha alterada.
+func (c *Cart) AddItem(product *Product, variant *ProductVariant, quantity int) (*CartItem, error) {
+	var variantID *entity.ID
+	if variant != nil {
+		variantID = &variant.ID
+	}
+	if item, found := c.Item(product.ID, variantID); found {
+		if err := item.SetQuantity(item.Quantity + quantity); err != nil {
+			return nil, err
+		}
+		return item, nil
+	}
+
+	item, err := NewCartItem(c, product, variant, quantity)
+	if err != nil {
+		return nil, err
+	}
+	c.Items = append(c.Items, *item)
+	return &c.Items[len(c.Items)-1], nil
+}
+
+// Totals recalcula o total de cada linha, o subtotal e a quantidade de
+// unidades do carrinho, em centavos.
+func (c *Cart) Totals() {
+	c.Subtotal = 0
+	c.ItemCount = 0
+	for i := range c.Items {
+		c.Items[i].Total = c.Items[i].UnitPrice.Times(c.Items[i].Quantity)
+		c.Subtotal += c.Items[i].Total
+		c.ItemCount += c.Items[i].Quantity
+	}
+}
+
+// NewCartItem cria a linha copiando nome e preço do produto ou da variante.
+func NewCartItem(cart *Cart, product *Product, variant *ProductVariant, quantity int) (*CartItem, error) {
+	if len(cart.Items) >= MaxCartItems {
+		return nil, ErrTooManyCartItems
+	}
+
+	now := time.Now()
+	item := &CartItem{
+		ID:        entity.NewID(),
+		CartID:    cart.ID,
+		ProductID: product.ID,
+		Name:      product.Name,
+		UnitPrice: money.FromFloat(product.Price),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if variant != nil {
+		item.VariantID = &variant.ID
+		item.UnitPrice = money.FromFloat(variant.EffectivePrice(product))
+	}
+
+	if err := item.SetQuantity(quantity); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// SetQuantity altera a quantidade da linha, entre 1 e MaxCartItemQuantity.
+func (i *CartItem) SetQuantity(quantity int) error {
+	if quantity < 1 || quantity > MaxCartItemQuantity {
+		return ErrInvalidQuantity
+	}
+	i.Quantity = quantity
+	i.UpdatedAt = time.Now()
+	i.Total = i.UnitPrice.Times(quantity)
+	return nil
+}
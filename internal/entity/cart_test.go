@@ -0,0 +1,102 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/otthonleao/go-products.git/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCart_Anonymous(t *testing.T) {
+	cart, err := NewCart(entity.NewID(), nil, time.Hour)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, cart.Token)
+	assert.NotEqual(t, cart.Token, cart.TokenHash)
+	assert.True(t, cart.CheckToken(cart.Token))
+	assert.False(t, cart.CheckToken("other"))
+	assert.False(t, cart.CheckToken(""))
+	assert.False(t, cart.Expired(time.Now()))
+	assert.True(t, cart.Expired(time.Now().Add(2*time.Hour)))
+}
+
+func TestNewCart_User(t *testing.T) {
+	userID := entity.NewID()
+	cart, err := NewCart(entity.NewID(), &userID, time.Hour)
+	assert.Nil(t, err)
+	assert.Empty(t, cart.Token)
+	assert.False(t, cart.CheckToken(""))
+}
+
+func TestCart_Totals(t *testing.T) {
+	cart, _ := NewCart(entity.NewID(), nil, time.Hour)
+	product, _ := NewProduct("Product 1", 0.1)
+	item, err := NewCartItem(cart, product, nil, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, money.Cents(10), item.UnitPrice)
+	cart.Items = append(cart.Items, *item)
+
+	other, _ := NewProduct("Product 2", 15)
+	other.OptionAxes = []string{"size"}
+	price := 19.99
+	variant, _ := NewProductVariant(other, map[string]string{"size": "M"}, "", &price, 1)
+	item, err = NewCartItem(cart, other, variant, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, money.Cents(1999), item.UnitPrice)
+	cart.Items = append(cart.Items, *item)
+
+	// Em float64, 0.1 * 3 + 19.99 * 2 não é exato
+	cart.Totals()
+	assert.Equal(t, money.Cents(4028), cart.Subtotal)
+	assert.Equal(t, "40.28", cart.Subtotal.String())
+	assert.Equal(t, 5, cart.ItemCount)
+
+	found, ok := cart.Item(other.ID, &variant.ID)
+	assert.True(t, ok)
+	assert.Equal(t, 2, found.Quantity)
+	_, ok = cart.Item(other.ID, nil)
+	assert.False(t, ok)
+}
+
+func TestCart_AddItem(t *testing.T) {
+	cart, _ := NewCart(entity.NewID(), nil, time.Hour)
+	product, _ := NewProduct("Product 1", 10)
+	item, err := cart.AddItem(product, nil, 2)
+	assert.Nil(t, err)
+	assert.Len(t, cart.Items, 1)
+
+	// O mesmo produto soma na linha existente, com o preço capturado antes
+	product.Price = 20
+	item, err = cart.AddItem(product, nil, 3)
+	assert.Nil(t, err)
+	assert.Len(t, cart.Items, 1)
+	assert.Equal(t, 5, cart.Items[0].Quantity)
+	assert.Equal(t, money.Cents(1000), item.UnitPrice)
+
+	_, err = cart.AddItem(product, nil, MaxCartItemQuantity)
+	assert.Equal(t, ErrInvalidQuantity, err)
+	assert.Equal(t, 5, cart.Items[0].Quantity)
+
+	for len(cart.Items) < MaxCartItems {
+		other, _ := NewProduct("Other", 1)
+		cart.AddItem(other, nil, 1)
+	}
+	other, _ := NewProduct("Other", 1)
+	_, err = cart.AddItem(other, nil, 1)
+	assert.Equal(t, ErrTooManyCartItems, err)
+	_, err = cart.AddItem(product, nil, 1)
+	assert.Nil(t, err)
+}
+
+func TestCartItem_SetQuantity(t *testing.T) {
+	cart, _ := NewCart(entity.NewID(), nil, time.Hour)
+	product, _ := NewProduct("Product 1", 10)
+	_, err := NewCartItem(cart, product, nil, 0)
+	assert.Equal(t, ErrInvalidQuantity, err)
+
+	item, _ := NewCartItem(cart, product, nil, 1)
+	assert.Equal(t, ErrInvalidQuantity, item.SetQuantity(MaxCartItemQuantity+1))
+	assert.Nil(t, item.SetQuantity(4))
+	assert.Equal(t, money.Cents(4000), item.Total)
+}
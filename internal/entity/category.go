@@ -0,0 +1,176 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/otthonleao/go-products.git/pkg/validation"
+)
+
+// Tipos de atributo aceitos nas definições das categorias.
+const (
+	AttributeString = "string"
+	AttributeNumber = "number"
+	AttributeBool   = "bool"
+	AttributeEnum   = "enum"
+)
+
+const MaxCategoryAttributes = 50
+
+var (
+	ErrInvalidAttributeName = errors.New("attribute names must be unique identifiers")
+	ErrInvalidAttributeType = errors.New("attribute type must be string, number, bool or enum")
+	ErrEnumWithoutOptions   = errors.New("enum attributes must list their options")
+	ErrTooManyAttributes    = errors.New("too many attributes")
+)
+
+// AttributeDefinition descreve um atributo que os produtos da categoria podem
+// (ou, com Required, devem) ter.
+type AttributeDefinition struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+	// Unit é só informativa, como "V" ou "kg"
+	Unit string `json:"unit,omitempty"`
+	// Options são os valores aceitos por atributos do tipo enum
+	Options []string `json:"options,omitempty"`
+}
+
+// Category agrupa produtos do mesmo tipo e define o esquema dos atributos deles.
+type Category struct {
+	ID             entity.ID             `json:"id"`
+	OrganizationID entity.ID             `json:"organization_id" gorm:"uniqueIndex:idx_categories_org_name"`
+	Name           string                `json:"name" gorm:"uniqueIndex:idx_categories_org_name"`
+	Attributes     []AttributeDefinition `json:"attributes" gorm:"serializer:json"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+func NewCategory(name string, attributes []AttributeDefinition) (*Category, error) {
+	now := time.Now()
+	category := &Category{
+		ID:         entity.NewID(),
+		Name:       name,
+		Attributes: attributes,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := category.Validate(); err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (c *Category) Validate() error {
+	if c.Name == "" {
+		return ErrNameIsRequired
+	}
+
+	if len(c.Attributes) > MaxCategoryAttributes {
+		return ErrTooManyAttributes
+	}
+
+	seen := make(map[string]bool, len(c.Attributes))
+	for _, attribute := range c.Attributes {
+		if !validation.IsIdentifier(attribute.Name) || seen[attribute.Name] {
+			return ErrInvalidAttributeName
+		}
+		seen[attribute.Name] = true
+
+		switch attribute.Type {
+		case AttributeString, AttributeNumber, AttributeBool:
+		case AttributeEnum:
+			if len(attribute.Options) == 0 {
+				return ErrEnumWithoutOptions
+			}
+		default:
+			return ErrInvalidAttributeType
+		}
+	}
+
+	return nil
+}
+
+// Attribute retorna a definição do atributo pelo nome.
+func (c *Category) Attribute(name string) (AttributeDefinition, bool) {
+	for _, attribute := range c.Attributes {
+		if attribute.Name == name {
+			return attribute, true
+		}
+	}
+	return AttributeDefinition{}, false
+}
+
+// ValidateAttributes confere os valores de um produto contra o esquema da
+// categoria: atributos desconhecidos, obrigatórios ausentes e valores do tipo
+// errado. As chaves dos erros são "attributes.<nome>".
+func (c *Category) ValidateAttributes(values map[string]interface{}) validation.Errors {
+	errors := validation.Errors{}
+
+	for name, value := range values {
+		attribute, ok := c.Attribute(name)
+		if !ok {
+			errors["attributes."+name] = append(errors["attributes."+name], fmt.Sprintf("is not defined in category %q", c.Name))
+			continue
+		}
+		if message := attribute.check(value); message != "" {
+			errors["attributes."+name] = append(errors["attributes."+name], message)
+		}
+	}
+
+	for _, attribute := range c.Attributes {
+		if _, ok := values[attribute.Name]; attribute.Required && !ok {
+			errors["attributes."+attribute.Name] = append(errors["attributes."+attribute.Name], "is required")
+		}
+	}
+
+	if len(errors) == 0 {
+		return nil
+	}
+	return errors
+}
+
+func (a AttributeDefinition) check(value interface{}) string {
+	switch a.Type {
+	case AttributeString:
+		if _, ok := value.(string); !ok {
+			return "must be a string"
+		}
+	case AttributeNumber:
+		if _, ok := value.(float64); !ok {
+			return "must be a number"
+		}
+	case AttributeBool:
+		if _, ok := value.(bool); !ok {
+			return "must be a boolean"
+		}
+	case AttributeEnum:
+		text, ok := value.(string)
+		if !ok || !slices.Contains(a.Options, text) {
+			return fmt.Sprintf("must be one of %v", a.Options)
+		}
+	}
+	return ""
+}
+
+// AttributeText converte o valor de um atributo para a forma textual usada nos
+// filtros: strings ficam como estão, booleanos viram "true"/"false" e números
+// usam a menor representação decimal.
+func AttributeText(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case bool:
+		return strconv.FormatBool(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/otthonleao/go-products.git/pkg/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func newElectronics(t *testing.T) *Category {
+	category, err := NewCategory("Electronics", []AttributeDefinition{
+		{Name: "voltage", Type: AttributeNumber, Required: true, Unit: "V"},
+		{Name: "brand", Type: AttributeString},
+		{Name: "wireless", Type: AttributeBool},
+		{Name: "plug", Type: AttributeEnum, Options: []string{"A", "C"}},
+	})
+	assert.Nil(t, err)
+	return category
+}
+
+func TestNewCategory(t *testing.T) {
+	category := newElectronics(t)
+	assert.NotEmpty(t, category.ID)
+
+	attribute, ok := category.Attribute("voltage")
+	assert.True(t, ok)
+	assert.Equal(t, "V", attribute.Unit)
+}
+
+func TestNewCategory_WhenInvalid(t *testing.T) {
+	_, err := NewCategory("", nil)
+	assert.Equal(t, ErrNameIsRequired, err)
+
+	_, err = NewCategory("Clothing", []AttributeDefinition{{Name: "size", Type: AttributeString}, {Name: "size", Type: AttributeString}})
+	assert.Equal(t, ErrInvalidAttributeName, err)
+
+	_, err = NewCategory("Clothing", []AttributeDefinition{{Name: "size", Type: "date"}})
+	assert.Equal(t, ErrInvalidAttributeType, err)
+
+	_, err = NewCategory("Clothing", []AttributeDefinition{{Name: "size", Type: AttributeEnum}})
+	assert.Equal(t, ErrEnumWithoutOptions, err)
+}
+
+func TestCategory_ValidateAttributes(t *testing.T) {
+	category := newElectronics(t)
+
+	assert.Nil(t, category.ValidateAttributes(map[string]interface{}{"voltage": 220.0, "brand": "Acme", "wireless": true, "plug": "C"}))
+
+	errors := category.ValidateAttributes(map[string]interface{}{"brand": 10.0, "wireless": "yes", "plug": "B", "color": "red"})
+	assert.Equal(t, validation.Errors{
+		"attributes.voltage":  {"is required"},
+		"attributes.brand":    {"must be a string"},
+		"attributes.wireless": {"must be a boolean"},
+		"attributes.plug":     {"must be one of [A C]"},
+		"attributes.color":    {`is not defined in category "Electronics"`},
+	}, errors)
+}
+
+func TestAttributeText(t *testing.T) {
+	assert.Equal(t, "red", AttributeText("red"))
+	assert.Equal(t, "true", AttributeText(true))
+	assert.Equal(t, "2", AttributeText(2.0))
+	assert.Equal(t, "1.5", AttributeText(1.5))
+}
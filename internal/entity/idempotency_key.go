@@ -0,0 +1,77 @@
+package entity
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/otthonleao/go-products.git/pkg/entity"
+)
+
+const MaxIdempotencyKeyLength = 255
+
+// IdempotencyKeyLease é quanto tempo uma reserva sem resposta bloqueia a
+// chave. Passado esse prazo a requisição original é dada como abandonada (o
+// cliente caiu ou o servidor reiniciou) e uma retentativa pode assumir a chave.
+// É maior que o WEB_SERVER_WRITE_TIMEOUT padrão.
+const IdempotencyKeyLease = time.Minute
+
+var (
+	ErrIdempotencyKeyIsRequired = errors.New("idempotency key is required")
+	ErrIdempotencyKeyTooLong    = errors.New("idempotency key is too long")
+)
+
+// IdempotencyKey guarda a resposta de uma requisição de criação para que
+// retentativas com a mesma chave recebam o mesmo resultado.
+type IdempotencyKey struct {
+	Scope       string `gorm:"primaryKey"`
+	Key         string `gorm:"primaryKey"`
+	Fingerprint string
+	Completed   bool
+	StatusCode  int
+	Header      http.Header `gorm:"serializer:json"`
+	Body        []byte
+	// ReservedBy identifica a reserva, para que só ela grave ou apague a chave
+	ReservedBy  string
+	LockedUntil time.Time
+	CreatedAt   time.Time
+	ExpiresAt   time.Time `gorm:"index"`
+}
+
+func NewIdempotencyKey(scope, key, fingerprint string, ttl time.Duration) (*IdempotencyKey, error) {
+	if key == "" {
+		return nil, ErrIdempotencyKeyIsRequired
+	}
+	if len(key) > MaxIdempotencyKeyLength {
+		return nil, ErrIdempotencyKeyTooLong
+	}
+
+	now := time.Now()
+	return &IdempotencyKey{
+		Scope:       scope,
+		Key:         key,
+		Fingerprint: fingerprint,
+		ReservedBy:  entity.NewID().String(),
+		LockedUntil: now.Add(IdempotencyKeyLease),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}, nil
+}
+
+func (k *IdempotencyKey) IsExpired(now time.Time) bool {
+	return !now.Before(k.ExpiresAt)
+}
+
+// IsStale indica se a reserva ficou sem resposta além do prazo e pode ser
+// assumida por uma retentativa.
+func (k *IdempotencyKey) IsStale(now time.Time) bool {
+	return !k.Completed && !now.Before(k.LockedUntil)
+}
+
+// Complete registra a resposta que será reenviada nas retentativas.
+func (k *IdempotencyKey) Complete(statusCode int, header http.Header, body []byte) {
+	k.Completed = true
+	k.StatusCode = statusCode
+	k.Header = header
+	k.Body = body
+}
@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewIdempotencyKey(t *testing.T) {
+	key, err := NewIdempotencyKey("user-1", "key-1", "abc", time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, "user-1", key.Scope)
+	assert.False(t, key.Completed)
+	assert.False(t, key.IsExpired(time.Now()))
+	assert.True(t, key.IsExpired(time.Now().Add(2*time.Hour)))
+
+	assert.NotEmpty(t, key.ReservedBy)
+	assert.False(t, key.IsStale(time.Now()))
+	assert.True(t, key.IsStale(time.Now().Add(IdempotencyKeyLease)))
+
+	key.Complete(http.StatusCreated, http.Header{"Location": {"/products/1"}}, []byte("{}"))
+	assert.True(t, key.Completed)
+	assert.Equal(t, http.StatusCreated, key.StatusCode)
+	assert.False(t, key.IsStale(time.Now().Add(IdempotencyKeyLease)))
+}
+
+func TestIdempotencyKey_Validate(t *testing.T) {
+	_, err := NewIdempotencyKey("user-1", "", "abc", time.Hour)
+	assert.Equal(t, ErrIdempotencyKeyIsRequired, err)
+
+	_, err = NewIdempotencyKey("user-1", strings.Repeat("k", MaxIdempotencyKeyLength+1), "abc", time.Hour)
+	assert.Equal(t, ErrIdempotencyKeyTooLong, err)
+}
@@ -0,0 +1,241 @@
+package entity
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/otthonleao/go-products.git/pkg/money"
+)
+
+// Estados de um pedido. Cancelados e reembolsados são finais.
+const (
+	OrderPending   = "pending"
+	OrderPaid      = "paid"
+	OrderFulfilled = "fulfilled"
+	OrderCancelled = "cancelled"
+	OrderRefunded  = "refunded"
+)
+
+// RoleBuyer é o papel de quem fez o pedido nas transições, ao lado dos papéis
+// da organização que vende.
+const RoleBuyer = "buyer"
+
+// MaxOrderItems limita as linhas de um pedido, como MaxCartItems no carrinho.
+const MaxOrderItems = MaxCartItems
+
+var (
+	ErrEmptyOrder                = errors.New("order has no items")
+	ErrTooManyOrderItems         = errors.New("too many items in the order")
+	ErrInvalidOrderStatus        = errors.New("invalid order status")
+	ErrOrderTransitionNotAllowed = errors.New("transition not allowed from the current order status")
+	ErrOrderTransitionForbidden  = errors.New("role cannot perform this order transition")
+	ErrInsufficientStock         = errors.New("insufficient stock")
+)
+
+// orderTransitions lista, para cada estado, os destinos possíveis e os papéis
+// que podem fazer a mudança. O comprador só cancela pedidos ainda não pagos.
+var orderTransitions = map[string]map[string][]string{
+	OrderPending: {
+		OrderPaid:      {RoleOwner, RoleAdmin},
+		OrderCancelled: {RoleOwner, RoleAdmin, RoleBuyer},
+	},
+	OrderPaid: {
+		OrderFulfilled: {RoleOwner, RoleAdmin},
+		OrderCancelled: {RoleOwner, RoleAdmin},
+		OrderRefunded:  {RoleOwner, RoleAdmin},
+	},
+	OrderFulfilled: {
+		OrderRefunded: {RoleOwner, RoleAdmin},
+	},
+	OrderCancelled: {},
+	OrderRefunded:  {},
+}
+
+// Order é um pedido feito por um usuário a uma organização. As linhas guardam
+// nome, preço e desconto do momento da compra; o estoque e o uso do cupom são
+// reservados na criação e devolvidos no cancelamento.
+type Order struct {
+	ID             entity.ID   `json:"id"`
+	OrganizationID entity.ID   `json:"organization_id" gorm:"index"`
+	UserID         entity.ID   `json:"user_id" gorm:"index"`
+	Status         string      `json:"status" gorm:"index"`
+	Items          []OrderItem `json:"items" gorm:"-"`
+	Subtotal       money.Cents `json:"subtotal"`
+	Discount       money.Cents `json:"discount"`
+	Total          money.Cents `json:"total"`
+	ItemCount      int         `json:"item_count"`
+	CouponID       *entity.ID  `json:"coupon_id,omitempty" gorm:"index"`
+	CouponCode     *string     `json:"coupon_code,omitempty"`
+	// Promotions resume o desconto de cada promoção aplicada
+	Promotions  []AppliedPromotion `json:"promotions" gorm:"serializer:json"`
+	PaidAt      *time.Time         `json:"paid_at,omitempty"`
+	FulfilledAt *time.Time         `json:"fulfilled_at,omitempty"`
+	CancelledAt *time.Time         `json:"cancelled_at,omitempty"`
+	RefundedAt  *time.Time         `json:"refunded_at,omitempty"`
+	CreatedAt   time.Time          `json:"created_at" gorm:"index"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// OrderItem é uma linha do pedido.
+type OrderItem struct {
+	ID        entity.ID   `json:"id"`
+	OrderID   entity.ID   `json:"-" gorm:"index"`
+	ProductID entity.ID   `json:"product_id" gorm:"index"`
+	VariantID *entity.ID  `json:"variant_id,omitempty"`
+	Name      string      `json:"name"`
+	UnitPrice money.Cents `json:"unit_price"`
+	Quantity  int         `json:"quantity"`
+	Discount  money.Cents `json:"discount"`
+	// Total é o valor da linha já descontado
+	Total money.Cents `json:"total"`
+	// Position mantém a ordem das linhas
+	Position int `json:"-"`
+}
+
+// NewOrderItem cria a linha com o nome e o preço atuais do produto ou da variante.
+func NewOrderItem(product *Product, variant *ProductVariant, quantity int) (*OrderItem, error) {
+	if quantity < 1 || quantity > MaxCartItemQuantity {
+		return nil, ErrInvalidQuantity
+	}
+
+	item := &OrderItem{
+		ID:        entity.NewID(),
+		ProductID: product.ID,
+		Name:      product.Name,
+		UnitPrice: money.FromFloat(product.Price),
+		Quantity:  quantity,
+	}
+	if variant != nil {
+		item.VariantID = &variant.ID
+		item.UnitPrice = money.FromFloat(variant.EffectivePrice(product))
+	}
+	item.Total = item.UnitPrice.Times(quantity)
+	return item, nil
+}
+
+// NewOrderItemFromCart cria a linha com o nome e o preço capturados no carrinho.
+func NewOrderItemFromCart(cartItem CartItem) OrderItem {
+	return OrderItem{
+		ID:        entity.NewID(),
+		ProductID: cartItem.ProductID,
+		VariantID: cartItem.VariantID,
+		Name:      cartItem.Name,
+		UnitPrice: cartItem.UnitPrice,
+		Quantity:  cartItem.Quantity,
+		Total:     cartItem.UnitPrice.Times(cartItem.Quantity),
+	}
+}
+
+// NewOrder cria um pedido pendente com as linhas informadas e o total em centavos.
+func NewOrder(organizationID, userID entity.ID, items []OrderItem) (*Order, error) {
+	if len(items) == 0 {
+		return nil, ErrEmptyOrder
+	}
+	if len(items) > MaxOrderItems {
+		return nil, ErrTooManyOrderItems
+	}
+
+	now := time.Now()
+	order := &Order{
+		ID:             entity.NewID(),
+		OrganizationID: organizationID,
+		UserID:         userID,
+		Status:         OrderPending,
+		Items:          items,
+		Promotions:     []AppliedPromotion{},
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	for i := range order.Items {
+		order.Items[i].OrderID = order.ID
+		order.Items[i].Position = i
+		order.Subtotal += order.Items[i].UnitPrice.Times(order.Items[i].Quantity)
+		order.Total += order.Items[i].Total
+		order.ItemCount += order.Items[i].Quantity
+	}
+	return order, nil
+}
+
+// PricingLine converte a linha para a precificação das promoções.
+func (i OrderItem) PricingLine(categoryID *entity.ID) PricingLine {
+	return PricingLine{
+		ProductID:  i.ProductID,
+		VariantID:  i.VariantID,
+		CategoryID: categoryID,
+		Name:       i.Name,
+		UnitPrice:  i.UnitPrice,
+		Quantity:   i.Quantity,
+	}
+}
+
+// ApplyQuote grava no pedido os descontos da cotação, cujas linhas seguem a
+// ordem das linhas do pedido. O cupom fica registrado para ser consumido na
+// criação e devolvido no cancelamento.
+func (o *Order) ApplyQuote(quote *Quote, coupon *Promotion) {
+	for i, line := range quote.Lines {
+		o.Items[i].Discount = line.Discount
+		o.Items[i].Total = line.Total
+	}
+	o.Subtotal = quote.Subtotal
+	o.Discount = quote.Discount
+	o.Total = quote.Total
+	o.Promotions = quote.Promotions
+	o.CouponID, o.CouponCode = nil, nil
+	if coupon != nil {
+		o.CouponID = &coupon.ID
+		o.CouponCode = coupon.Code
+	}
+}
+
+func IsValidOrderStatus(status string) bool {
+	_, ok := orderTransitions[status]
+	return ok
+}
+
+// CanTransitionOrder indica se o papel pode levar o pedido do estado from para to.
+func CanTransitionOrder(from, to, role string) error {
+	if !IsValidOrderStatus(to) {
+		return ErrInvalidOrderStatus
+	}
+	roles, ok := orderTransitions[from][to]
+	if !ok {
+		return ErrOrderTransitionNotAllowed
+	}
+	if !slices.Contains(roles, role) {
+		return ErrOrderTransitionForbidden
+	}
+	return nil
+}
+
+// Transition muda o estado do pedido, conferindo a máquina de estados e o
+// papel de quem pede, e retorna o estado anterior.
+func (o *Order) Transition(to, role string) (string, error) {
+	if err := CanTransitionOrder(o.Status, to, role); err != nil {
+		return "", err
+	}
+
+	from := o.Status
+	now := time.Now()
+	switch to {
+	case OrderPaid:
+		o.PaidAt = &now
+	case OrderFulfilled:
+		o.FulfilledAt = &now
+	case OrderCancelled:
+		o.CancelledAt = &now
+	case OrderRefunded:
+		o.RefundedAt = &now
+	}
+	o.Status = to
+	o.UpdatedAt = now
+	return from, nil
+}
+
+// ReleasesStock indica se a mudança para o estado atual devolve o estoque
+// reservado. Só o cancelamento devolve: pedidos reembolsados podem já ter
+// sido entregues.
+func (o *Order) ReleasesStock() bool {
+	return o.Status == OrderCancelled
+}
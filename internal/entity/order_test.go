@@ -0,0 +1,78 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/otthonleao/go-products.git/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOrder(t *testing.T) {
+	_, err := NewOrder(entity.NewID(), entity.NewID(), nil)
+	assert.Equal(t, ErrEmptyOrder, err)
+
+	product, _ := NewProduct("Product 1", 0.1)
+	item, err := NewOrderItem(product, nil, 3)
+	assert.Nil(t, err)
+	_, err = NewOrderItem(product, nil, 0)
+	assert.Equal(t, ErrInvalidQuantity, err)
+
+	cart, _ := NewCart(entity.NewID(), nil, time.Hour)
+	cartItem, _ := NewCartItem(cart, product, nil, 2)
+	product.Price = 99
+
+	order, err := NewOrder(cart.OrganizationID, entity.NewID(), []OrderItem{*item, NewOrderItemFromCart(*cartItem)})
+	assert.Nil(t, err)
+	assert.Equal(t, OrderPending, order.Status)
+	assert.Equal(t, money.Cents(50), order.Total)
+	assert.Equal(t, 5, order.ItemCount)
+	assert.Equal(t, order.ID, order.Items[1].OrderID)
+	assert.Equal(t, 1, order.Items[1].Position)
+}
+
+func TestOrder_Transition(t *testing.T) {
+	product, _ := NewProduct("Product 1", 10)
+	item, _ := NewOrderItem(product, nil, 1)
+	order, _ := NewOrder(entity.NewID(), entity.NewID(), []OrderItem{*item})
+
+	_, err := order.Transition(OrderPaid, RoleBuyer)
+	assert.Equal(t, ErrOrderTransitionForbidden, err)
+	_, err = order.Transition(OrderPaid, RoleMember)
+	assert.Equal(t, ErrOrderTransitionForbidden, err)
+	_, err = order.Transition(OrderFulfilled, RoleAdmin)
+	assert.Equal(t, ErrOrderTransitionNotAllowed, err)
+	_, err = order.Transition("shipped", RoleAdmin)
+	assert.Equal(t, ErrInvalidOrderStatus, err)
+
+	from, err := order.Transition(OrderPaid, RoleAdmin)
+	assert.Nil(t, err)
+	assert.Equal(t, OrderPending, from)
+	assert.NotNil(t, order.PaidAt)
+	assert.False(t, order.ReleasesStock())
+
+	// Depois de pago, só a organização cancela
+	_, err = order.Transition(OrderCancelled, RoleBuyer)
+	assert.Equal(t, ErrOrderTransitionForbidden, err)
+
+	_, err = order.Transition(OrderFulfilled, RoleOwner)
+	assert.Nil(t, err)
+	_, err = order.Transition(OrderCancelled, RoleOwner)
+	assert.Equal(t, ErrOrderTransitionNotAllowed, err)
+	_, err = order.Transition(OrderRefunded, RoleOwner)
+	assert.Nil(t, err)
+	assert.NotNil(t, order.RefundedAt)
+	assert.False(t, order.ReleasesStock())
+}
+
+func TestOrder_BuyerCancels(t *testing.T) {
+	product, _ := NewProduct("Product 1", 10)
+	item, _ := NewOrderItem(product, nil, 1)
+	order, _ := NewOrder(entity.NewID(), entity.NewID(), []OrderItem{*item})
+
+	_, err := order.Transition(OrderCancelled, RoleBuyer)
+	assert.Nil(t, err)
+	assert.NotNil(t, order.CancelledAt)
+	assert.True(t, order.ReleasesStock())
+}
@@ -0,0 +1,123 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/otthonleao/go-products.git/pkg/entity"
+)
+
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+var (
+	ErrOrganizationIsRequired = errors.New("organization is required")
+	ErrUserIsRequired         = errors.New("user is required")
+	ErrInvalidRole            = errors.New("invalid role")
+)
+
+type Organization struct {
+	ID        entity.ID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Membership vincula um usuário a uma organização (tenant) com um papel.
+type Membership struct {
+	ID             entity.ID `json:"id"`
+	UserID         entity.ID `json:"user_id" gorm:"uniqueIndex:idx_memberships_user_org"`
+	OrganizationID entity.ID `json:"organization_id" gorm:"uniqueIndex:idx_memberships_user_org"`
+	Role           string    `json:"role"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func NewOrganization(name string) (*Organization, error) {
+	organization := &Organization{
+		ID:        entity.NewID(),
+		Name:      name,
+		CreatedAt: time.Now(),
+	}
+
+	if err := organization.Validate(); err != nil {
+		return nil, err
+	}
+
+	return organization, nil
+}
+
+func (o *Organization) Validate() error {
+	if o.ID.String() == "" {
+		return ErrIdIsRequired
+	}
+
+	if _, err := entity.ParseID(o.ID.String()); err != nil {
+		return ErrInvalidId
+	}
+
+	if o.Name == "" {
+		return ErrNameIsRequired
+	}
+
+	return nil
+}
+
+func NewMembership(userID, organizationID entity.ID, role string) (*Membership, error) {
+	membership := &Membership{
+		ID:             entity.NewID(),
+		UserID:         userID,
+		OrganizationID: organizationID,
+		Role:           role,
+		CreatedAt:      time.Now(),
+	}
+
+	if err := membership.Validate(); err != nil {
+		return nil, err
+	}
+
+	return membership, nil
+}
+
+func (m *Membership) Validate() error {
+	if m.UserID == (entity.ID{}) {
+		return ErrUserIsRequired
+	}
+
+	if m.OrganizationID == (entity.ID{}) {
+		return ErrOrganizationIsRequired
+	}
+
+	if !IsValidRole(m.Role) {
+		return ErrInvalidRole
+	}
+
+	return nil
+}
+
+// CanManageMembers indica se o papel permite adicionar membros à organização.
+func (m *Membership) CanManageMembers() bool {
+	return m.Role == RoleOwner || m.Role == RoleAdmin
+}
+
+// roleRanks ordena os papéis do menor para o maior privilégio.
+var roleRanks = map[string]int{
+	RoleMember: 1,
+	RoleAdmin:  2,
+	RoleOwner:  3,
+}
+
+// CanGrantRole indica se o papel permite conceder role a outro membro: ninguém
+// concede um papel acima do próprio.
+func (m *Membership) CanGrantRole(role string) bool {
+	return m.CanManageMembers() && IsValidRole(role) && roleRanks[role] <= roleRanks[m.Role]
+}
+
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleOwner, RoleAdmin, RoleMember:
+		return true
+	}
+	return false
+}
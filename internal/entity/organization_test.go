@@ -0,0 +1,59 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOrganization(t *testing.T) {
+	organization, err := NewOrganization("Acme")
+	assert.Nil(t, err)
+	assert.NotNil(t, organization)
+	assert.NotEmpty(t, organization.ID)
+	assert.Equal(t, "Acme", organization.Name)
+}
+
+func TestOrganization_WhenNameIsRequired(t *testing.T) {
+	organization, err := NewOrganization("")
+	assert.Nil(t, organization)
+	assert.Equal(t, ErrNameIsRequired, err)
+}
+
+func TestNewMembership(t *testing.T) {
+	membership, err := NewMembership(entity.NewID(), entity.NewID(), RoleOwner)
+	assert.Nil(t, err)
+	assert.NotNil(t, membership)
+	assert.True(t, membership.CanManageMembers())
+
+	membership, err = NewMembership(entity.NewID(), entity.NewID(), RoleMember)
+	assert.Nil(t, err)
+	assert.False(t, membership.CanManageMembers())
+}
+
+func TestMembership_CanGrantRole(t *testing.T) {
+	owner, _ := NewMembership(entity.NewID(), entity.NewID(), RoleOwner)
+	assert.True(t, owner.CanGrantRole(RoleOwner))
+	assert.True(t, owner.CanGrantRole(RoleMember))
+
+	admin, _ := NewMembership(entity.NewID(), entity.NewID(), RoleAdmin)
+	assert.False(t, admin.CanGrantRole(RoleOwner))
+	assert.True(t, admin.CanGrantRole(RoleAdmin))
+	assert.True(t, admin.CanGrantRole(RoleMember))
+	assert.False(t, admin.CanGrantRole("superuser"))
+
+	member, _ := NewMembership(entity.NewID(), entity.NewID(), RoleMember)
+	assert.False(t, member.CanGrantRole(RoleMember))
+}
+
+func TestMembership_Validate(t *testing.T) {
+	_, err := NewMembership(entity.ID{}, entity.NewID(), RoleMember)
+	assert.Equal(t, ErrUserIsRequired, err)
+
+	_, err = NewMembership(entity.NewID(), entity.ID{}, RoleMember)
+	assert.Equal(t, ErrOrganizationIsRequired, err)
+
+	_, err = NewMembership(entity.NewID(), entity.NewID(), "superuser")
+	assert.Equal(t, ErrInvalidRole, err)
+}
@@ -0,0 +1,153 @@
+package entity
+
+import (
+	"github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/otthonleao/go-products.git/pkg/money"
+)
+
+// PricingLine é uma linha a precificar: o produto, sua categoria e o preço
+// unitário já definido pelo catálogo ou pelo carrinho.
+type PricingLine struct {
+	ProductID  entity.ID
+	VariantID  *entity.ID
+	CategoryID *entity.ID
+	Name       string
+	UnitPrice  money.Cents
+	Quantity   int
+}
+
+// AppliedPromotion é o desconto de uma promoção numa linha ou, no resumo da
+// cotação, somado em todas as linhas.
+type AppliedPromotion struct {
+	PromotionID entity.ID   `json:"promotion_id"`
+	Name        string      `json:"name"`
+	Kind        string      `json:"kind"`
+	Code        string      `json:"code,omitempty"`
+	Discount    money.Cents `json:"discount"`
+}
+
+type QuoteLine struct {
+	ProductID  entity.ID          `json:"product_id"`
+	VariantID  *entity.ID         `json:"variant_id,omitempty"`
+	Name       string             `json:"name"`
+	UnitPrice  money.Cents        `json:"unit_price"`
+	Quantity   int                `json:"quantity"`
+	Subtotal   money.Cents        `json:"subtotal"`
+	Discount   money.Cents        `json:"discount"`
+	Total      money.Cents        `json:"total"`
+	Promotions []AppliedPromotion `json:"promotions"`
+}
+
+// Quote é o resultado da precificação, com o desconto de cada regra aplicada.
+type Quote struct {
+	Lines      []QuoteLine        `json:"lines"`
+	Subtotal   money.Cents        `json:"subtotal"`
+	Discount   money.Cents        `json:"discount"`
+	Total      money.Cents        `json:"total"`
+	Promotions []AppliedPromotion `json:"promotions"`
+}
+
+// HasPromotion indica se a promoção deu algum desconto na cotação.
+func (q *Quote) HasPromotion(id entity.ID) bool {
+	for _, applied := range q.Promotions {
+		if applied.PromotionID == id && applied.Discount > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func appliedPromotion(promotion *Promotion, discount money.Cents) AppliedPromotion {
+	applied := AppliedPromotion{
+		PromotionID: promotion.ID,
+		Name:        promotion.Name,
+		Kind:        promotion.Kind,
+		Discount:    discount,
+	}
+	if promotion.Code != nil {
+		applied.Code = *promotion.Code
+	}
+	return applied
+}
+
+// PriceLines aplica as promoções às linhas. Em cada linha vale só a promoção
+// automática de maior desconto (empates ficam com a primeira da lista); o
+// cupom, se houver, é aplicado depois sobre o que sobrou da linha. Em "leve X
+// ganhe Y" as unidades grátis saem de todas as linhas do produto (ver
+// FreeUnits). Nenhuma linha fica negativa. Quem chama deve passar só
+// promoções válidas no momento.
+func PriceLines(lines []PricingLine, automatic []Promotion, coupon *Promotion) *Quote {
+	quote := &Quote{
+		Lines:      make([]QuoteLine, 0, len(lines)),
+		Promotions: []AppliedPromotion{},
+	}
+	totals := map[entity.ID]int{}
+	add := func(promotion *Promotion, discount money.Cents) AppliedPromotion {
+		applied := appliedPromotion(promotion, discount)
+		if i, ok := totals[promotion.ID]; ok {
+			quote.Promotions[i].Discount += discount
+		} else {
+			totals[promotion.ID] = len(quote.Promotions)
+			quote.Promotions = append(quote.Promotions, applied)
+		}
+		return applied
+	}
+
+	// "leve X ganhe Y" conta as unidades do produto em todas as linhas
+	freeUnits := map[entity.ID][]int{}
+	lineDiscount := func(promotion *Promotion, i int, base money.Cents) money.Cents {
+		line := lines[i]
+		if promotion.Kind != PromotionBuyXGetY {
+			return promotion.Discount(line.UnitPrice, line.Quantity, base)
+		}
+		free, ok := freeUnits[promotion.ID]
+		if !ok {
+			free = promotion.FreeUnits(lines)
+			freeUnits[promotion.ID] = free
+		}
+		return min(line.UnitPrice.Times(free[i]), base)
+	}
+
+	for i, line := range lines {
+		quoted := QuoteLine{
+			ProductID:  line.ProductID,
+			VariantID:  line.VariantID,
+			Name:       line.Name,
+			UnitPrice:  line.UnitPrice,
+			Quantity:   line.Quantity,
+			Subtotal:   line.UnitPrice.Times(line.Quantity),
+			Promotions: []AppliedPromotion{},
+		}
+
+		var best *Promotion
+		var bestDiscount money.Cents
+		for j := range automatic {
+			promotion := &automatic[j]
+			if !promotion.Applies(line.ProductID, line.CategoryID) {
+				continue
+			}
+			if discount := lineDiscount(promotion, i, quoted.Subtotal); discount > bestDiscount {
+				best, bestDiscount = promotion, discount
+			}
+		}
+		if best != nil {
+			quoted.Discount += bestDiscount
+			quoted.Promotions = append(quoted.Promotions, add(best, bestDiscount))
+		}
+
+		if coupon != nil && coupon.Applies(line.ProductID, line.CategoryID) {
+			discount := lineDiscount(coupon, i, quoted.Subtotal-quoted.Discount)
+			if discount > 0 {
+				quoted.Discount += discount
+				quoted.Promotions = append(quoted.Promotions, add(coupon, discount))
+			}
+		}
+
+		quoted.Total = quoted.Subtotal - quoted.Discount
+		quote.Subtotal += quoted.Subtotal
+		quote.Discount += quoted.Discount
+		quote.Total += quoted.Total
+		quote.Lines = append(quote.Lines, quoted)
+	}
+	return quote
+}
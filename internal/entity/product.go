@@ -2,32 +2,75 @@ package entity
 
 import (
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/otthonleao/go-products.git/pkg/validation"
 )
 
 var (
-	ErrIdIsRequired    = errors.New("id is required")
-	ErrInvalidId       = errors.New("invalid id")
-	ErrNameIsRequired  = errors.New("name is required")
-	ErrPriceIsRequired = errors.New("price is required")
-	ErrInvalidPrice    = errors.New("invalid price")
+	ErrIdIsRequired      = errors.New("id is required")
+	ErrInvalidId         = errors.New("invalid id")
+	ErrNameIsRequired    = errors.New("name is required")
+	ErrPriceIsRequired   = errors.New("price is required")
+	ErrInvalidPrice      = errors.New("invalid price")
+	ErrInvalidSKU        = errors.New("invalid sku")
+	ErrInvalidBarcode    = errors.New("invalid barcode")
+	ErrInvalidOptionAxes = errors.New("option axes must be unique, non-empty names")
+	ErrInvalidTags       = errors.New("invalid tags")
+)
+
+const (
+	MaxSKULength  = 64
+	MaxOptionAxes = 3
+	MaxTags       = 20
+	MaxTagLength  = 50
 )
 
 type Product struct {
-	ID        entity.ID `json:"id"`
-	Name      string    `json:"name"`
-	Price     float64   `json:"price"`
-	CreatedAt time.Time `json:"created_at"`
+	ID             entity.ID `json:"id"`
+	OrganizationID entity.ID `json:"organization_id" gorm:"index;uniqueIndex:idx_products_org_sku;uniqueIndex:idx_products_org_barcode"`
+	// SKU e Barcode são opcionais e únicos dentro da organização
+	SKU     *string `json:"sku,omitempty" gorm:"uniqueIndex:idx_products_org_sku"`
+	Barcode *string `json:"barcode,omitempty" gorm:"uniqueIndex:idx_products_org_barcode"`
+	Name    string  `json:"name"`
+	Price   float64 `json:"price"`
+	// Stock nulo indica estoque não controlado. Produtos com variantes usam o
+	// estoque de cada variante
+	Stock *int `json:"stock,omitempty"`
+	// Status só muda por Transition. Um produto gravado sem estado fica em
+	// rascunho; os criados antes do fluxo de publicação são publicados uma única
+	// vez na migração
+	Status          string     `json:"status" gorm:"index;default:draft"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty"`
+	PublishedAt     *time.Time `json:"published_at,omitempty"`
+	// CategoryID define o esquema de Attributes
+	CategoryID *entity.ID             `json:"category_id,omitempty" gorm:"index"`
+	Attributes map[string]interface{} `json:"attributes,omitempty" gorm:"serializer:json"`
+	// Tags são livres, normalizadas em minúsculas e ordenadas
+	Tags []string `json:"tags,omitempty" gorm:"serializer:json"`
+	// OptionAxes são os eixos das variantes, como ["size", "color"]
+	OptionAxes []string `json:"option_axes,omitempty" gorm:"serializer:json"`
+	// Variants só é preenchido quando a requisição pede as variantes
+	Variants []ProductVariant `json:"variants,omitempty" gorm:"-"`
+	// Images vem ordenado por posição, com as URLs já resolvidas
+	Images    []ProductImage `json:"images,omitempty" gorm:"-"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
 }
 
 func NewProduct(name string, price float64) (*Product, error) {
+	now := time.Now()
 	product := &Product{
 		ID:        entity.NewID(),
 		Name:      name,
 		Price:     price,
-		CreatedAt: time.Now(),
+		Status:    StatusDraft,
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
 
 	if err := product.Validate(); err != nil {
@@ -37,6 +80,29 @@ func NewProduct(name string, price float64) (*Product, error) {
 	return product, nil
 }
 
+// SetIdentifiers define o SKU e o código de barras; valores vazios removem o campo.
+func (p *Product) SetIdentifiers(sku, barcode string) error {
+	p.SKU = optional(sku)
+	p.Barcode = optional(barcode)
+	return p.Validate()
+}
+
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// LastModified retorna a última alteração do produto. Registros anteriores à
+// coluna updated_at usam a data de criação.
+func (p *Product) LastModified() time.Time {
+	if p.UpdatedAt.IsZero() {
+		return p.CreatedAt
+	}
+	return p.UpdatedAt
+}
+
 func (p *Product) Validate() error {
 	if p.ID.String() == "" {
 		return ErrIdIsRequired
@@ -58,5 +124,87 @@ func (p *Product) Validate() error {
 		return ErrInvalidPrice
 	}
 
+	if p.Stock != nil && *p.Stock < 0 {
+		return ErrInvalidStock
+	}
+
+	if p.Status != "" && !IsValidStatus(p.Status) {
+		return ErrInvalidStatus
+	}
+
+	if p.SKU != nil && (len(*p.SKU) > MaxSKULength || !validation.IsIdentifier(*p.SKU)) {
+		return ErrInvalidSKU
+	}
+
+	if p.Barcode != nil && !validation.IsGTIN(*p.Barcode) {
+		return ErrInvalidBarcode
+	}
+
+	if !validOptionAxes(p.OptionAxes) {
+		return ErrInvalidOptionAxes
+	}
+
+	if normalized, err := NormalizeTags(p.Tags); err != nil || !slices.Equal(normalized, p.Tags) {
+		return ErrInvalidTags
+	}
+
+	return nil
+}
+
+func validOptionAxes(axes []string) bool {
+	if len(axes) > MaxOptionAxes {
+		return false
+	}
+	seen := make(map[string]bool, len(axes))
+	for _, axis := range axes {
+		if axis == "" || seen[axis] || !validation.IsIdentifier(axis) {
+			return false
+		}
+		seen[axis] = true
+	}
+	return true
+}
+
+// SetTags substitui as tags do produto pela forma normalizada delas.
+func (p *Product) SetTags(tags []string) error {
+	normalized, err := NormalizeTags(tags)
+	if err != nil {
+		return err
+	}
+	p.Tags = normalized
 	return nil
 }
+
+// NormalizeTags remove espaços nas pontas, passa as tags para minúsculas,
+// descarta repetições e ordena o resultado. Tags vazias ou longas demais são
+// recusadas.
+func NormalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || len(tag) > MaxTagLength {
+			return nil, fmt.Errorf("%w: tags must have between 1 and %d characters", ErrInvalidTags, MaxTagLength)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > MaxTags {
+		return nil, fmt.Errorf("%w: at most %d tags per product", ErrInvalidTags, MaxTags)
+	}
+
+	slices.Sort(normalized)
+	return normalized, nil
+}
+
+// InStock indica se há ao menos quantity unidades do produto. Sem controle de
+// estoque sempre há.
+func (p *Product) InStock(quantity int) bool {
+	return p.Stock == nil || *p.Stock >= quantity
+}
@@ -0,0 +1,22 @@
+package entity
+
+// FacetCount é a quantidade de produtos com um valor, como uma tag ou categoria.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// PriceBucket conta os produtos com preço em [Min, Max). A última faixa não
+// tem Max.
+type PriceBucket struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max,omitempty"`
+	Count int64    `json:"count"`
+}
+
+// ProductFacets resume uma listagem filtrada inteira, não só a página retornada.
+type ProductFacets struct {
+	Tags       []FacetCount  `json:"tags,omitempty"`
+	Categories []FacetCount  `json:"categories,omitempty"`
+	Prices     []PriceBucket `json:"prices,omitempty"`
+}
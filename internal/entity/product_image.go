@@ -0,0 +1,73 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/otthonleao/go-products.git/pkg/entity"
+)
+
+var ErrInvalidImage = errors.New("invalid image")
+
+// ProductImage descreve uma imagem do produto. Os arquivos ficam no storage; aqui
+// ficam só as chaves, e as URLs são preenchidas por ResolveURLs na hora de responder.
+type ProductImage struct {
+	ID             entity.ID `json:"id"`
+	ProductID      entity.ID `json:"product_id" gorm:"index"`
+	OrganizationID entity.ID `json:"organization_id"`
+	ContentType    string    `json:"content_type"`
+	Size           int64     `json:"size"`
+	Width          int       `json:"width"`
+	Height         int       `json:"height"`
+	// Position ordena as imagens do produto, começando em 0
+	Position int  `json:"position"`
+	Primary  bool `json:"primary" gorm:"column:is_primary"`
+	// Key e ThumbnailKeys são as chaves dos arquivos no storage
+	Key           string            `json:"-"`
+	ThumbnailKeys map[string]string `json:"-" gorm:"serializer:json"`
+	URL           string            `json:"url" gorm:"-"`
+	Thumbnails    map[string]string `json:"thumbnails,omitempty" gorm:"-"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+func NewProductImage(product *Product, contentType string, size int64, width, height int) (*ProductImage, error) {
+	if contentType == "" || size <= 0 || width <= 0 || height <= 0 {
+		return nil, ErrInvalidImage
+	}
+
+	return &ProductImage{
+		ID:             entity.NewID(),
+		ProductID:      product.ID,
+		OrganizationID: product.OrganizationID,
+		ContentType:    contentType,
+		Size:           size,
+		Width:          width,
+		Height:         height,
+		ThumbnailKeys:  map[string]string{},
+		CreatedAt:      time.Now(),
+	}, nil
+}
+
+// BlobKey monta a chave de um arquivo da imagem, como
+// "products/{productID}/images/{imageID}/original.jpg".
+func (i *ProductImage) BlobKey(name, extension string) string {
+	return "products/" + i.ProductID.String() + "/images/" + i.ID.String() + "/" + name + extension
+}
+
+// BlobKeys retorna as chaves do original e de todas as miniaturas.
+func (i *ProductImage) BlobKeys() []string {
+	keys := []string{i.Key}
+	for _, key := range i.ThumbnailKeys {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// ResolveURLs preenche URL e Thumbnails a partir das chaves.
+func (i *ProductImage) ResolveURLs(url func(key string) string) {
+	i.URL = url(i.Key)
+	i.Thumbnails = make(map[string]string, len(i.ThumbnailKeys))
+	for name, key := range i.ThumbnailKeys {
+		i.Thumbnails[name] = url(key)
+	}
+}
@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProductImage(t *testing.T) {
+	product, _ := NewProduct("Product 1", 10)
+
+	image, err := NewProductImage(product, "image/png", 1024, 800, 600)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, image.ID)
+	assert.Equal(t, product.ID, image.ProductID)
+
+	image.Key = image.BlobKey("original", ".png")
+	image.ThumbnailKeys["small"] = image.BlobKey("small", ".png")
+	assert.Equal(t, "products/"+product.ID.String()+"/images/"+image.ID.String()+"/original.png", image.Key)
+	assert.ElementsMatch(t, []string{image.Key, image.ThumbnailKeys["small"]}, image.BlobKeys())
+
+	image.ResolveURLs(func(key string) string { return "/media/" + key })
+	assert.Equal(t, "/media/"+image.Key, image.URL)
+	assert.Equal(t, "/media/"+image.ThumbnailKeys["small"], image.Thumbnails["small"])
+}
+
+func TestNewProductImage_WhenInvalid(t *testing.T) {
+	product, _ := NewProduct("Product 1", 10)
+
+	image, err := NewProductImage(product, "image/png", 0, 800, 600)
+	assert.Nil(t, image)
+	assert.Equal(t, ErrInvalidImage, err)
+
+	_, err = NewProductImage(product, "", 1024, 800, 600)
+	assert.Equal(t, ErrInvalidImage, err)
+}
@@ -0,0 +1,105 @@
+package entity
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/otthonleao/go-products.git/pkg/entity"
+)
+
+// Estados do ciclo de vida de um produto. Só produtos publicados aparecem para
+// quem não é membro da organização.
+const (
+	StatusDraft     = "draft"
+	StatusInReview  = "in_review"
+	StatusPublished = "published"
+	StatusArchived  = "archived"
+)
+
+var (
+	ErrInvalidStatus        = errors.New("invalid status")
+	ErrTransitionNotAllowed = errors.New("transition not allowed from the current status")
+	ErrTransitionForbidden  = errors.New("role cannot perform this transition")
+)
+
+// productTransitions lista, para cada estado, os destinos possíveis e os papéis
+// que podem fazer a mudança. Membros só enviam e retiram produtos da revisão.
+var productTransitions = map[string]map[string][]string{
+	StatusDraft: {
+		StatusInReview: {RoleOwner, RoleAdmin, RoleMember},
+		StatusArchived: {RoleOwner, RoleAdmin},
+	},
+	StatusInReview: {
+		StatusDraft:     {RoleOwner, RoleAdmin, RoleMember},
+		StatusPublished: {RoleOwner, RoleAdmin},
+	},
+	StatusPublished: {
+		StatusDraft:    {RoleOwner, RoleAdmin},
+		StatusArchived: {RoleOwner, RoleAdmin},
+	},
+	StatusArchived: {
+		StatusDraft: {RoleOwner, RoleAdmin},
+	},
+}
+
+// ProductTransition registra uma mudança de estado do produto e quem a fez.
+type ProductTransition struct {
+	ID             entity.ID `json:"id"`
+	ProductID      entity.ID `json:"product_id" gorm:"index"`
+	OrganizationID entity.ID `json:"organization_id"`
+	From           string    `json:"from" gorm:"column:from_status"`
+	To             string    `json:"to" gorm:"column:to_status"`
+	// Actor é o ID do usuário ou "service:<nome>" para identidades de serviço
+	Actor     string    `json:"actor"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func IsValidStatus(status string) bool {
+	_, ok := productTransitions[status]
+	return ok
+}
+
+// CanTransition indica se o papel pode levar o produto do estado from para to.
+func CanTransition(from, to, role string) error {
+	if !IsValidStatus(to) {
+		return ErrInvalidStatus
+	}
+	roles, ok := productTransitions[from][to]
+	if !ok {
+		return ErrTransitionNotAllowed
+	}
+	if !slices.Contains(roles, role) {
+		return ErrTransitionForbidden
+	}
+	return nil
+}
+
+// Transition muda o estado do produto, conferindo a máquina de estados e o
+// papel de quem pede, e retorna o registro da mudança para o histórico.
+func (p *Product) Transition(to, role, actor, comment string) (*ProductTransition, error) {
+	if err := CanTransition(p.Status, to, role); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	transition := &ProductTransition{
+		ID:             entity.NewID(),
+		ProductID:      p.ID,
+		OrganizationID: p.OrganizationID,
+		From:           p.Status,
+		To:             to,
+		Actor:          actor,
+		Comment:        comment,
+		CreatedAt:      now,
+	}
+
+	p.Status = to
+	p.StatusChangedAt = &now
+	if to == StatusPublished {
+		p.PublishedAt = &now
+	}
+	p.UpdatedAt = now
+	return transition, nil
+}
@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProduct_Transition(t *testing.T) {
+	product, _ := NewProduct("Product 1", 10.5)
+	assert.Equal(t, StatusDraft, product.Status)
+	assert.Nil(t, product.PublishedAt)
+
+	transition, err := product.Transition(StatusInReview, RoleMember, "user-1", "ready")
+	assert.Nil(t, err)
+	assert.Equal(t, StatusDraft, transition.From)
+	assert.Equal(t, StatusInReview, transition.To)
+	assert.Equal(t, "user-1", transition.Actor)
+	assert.Equal(t, StatusInReview, product.Status)
+	assert.NotNil(t, product.StatusChangedAt)
+
+	// Membros não publicam
+	_, err = product.Transition(StatusPublished, RoleMember, "user-1", "")
+	assert.Equal(t, ErrTransitionForbidden, err)
+	assert.Equal(t, StatusInReview, product.Status)
+
+	_, err = product.Transition(StatusPublished, RoleAdmin, "user-2", "")
+	assert.Nil(t, err)
+	assert.Equal(t, StatusPublished, product.Status)
+	assert.NotNil(t, product.PublishedAt)
+
+	_, err = product.Transition(StatusInReview, RoleOwner, "user-2", "")
+	assert.Equal(t, ErrTransitionNotAllowed, err)
+	_, err = product.Transition("deleted", RoleOwner, "user-2", "")
+	assert.Equal(t, ErrInvalidStatus, err)
+
+	_, err = product.Transition(StatusArchived, RoleOwner, "user-2", "")
+	assert.Nil(t, err)
+	_, err = product.Transition(StatusDraft, RoleOwner, "user-2", "")
+	assert.Nil(t, err)
+	assert.Equal(t, StatusDraft, product.Status)
+}
+
+func TestProduct_ValidateStatus(t *testing.T) {
+	product, _ := NewProduct("Product 1", 10.5)
+	product.Status = "live"
+	assert.Equal(t, ErrInvalidStatus, product.Validate())
+}
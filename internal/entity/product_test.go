@@ -1,7 +1,10 @@
 package entity
 
 import (
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -41,3 +44,63 @@ func TestProduct_Validate(t *testing.T) {
 	assert.NotNil(t, product)
 	assert.Nil(t, product.Validate())
 }
+
+func TestProduct_LastModified(t *testing.T) {
+	product, _ := NewProduct("Product 1", 10.5)
+	assert.Equal(t, product.UpdatedAt, product.LastModified())
+
+	product.UpdatedAt = time.Time{}
+	assert.Equal(t, product.CreatedAt, product.LastModified())
+}
+
+func TestProduct_SetIdentifiers(t *testing.T) {
+	product, _ := NewProduct("Product 1", 10.5)
+
+	assert.Nil(t, product.SetIdentifiers("ABC-123", "4006381333931"))
+	assert.Equal(t, "ABC-123", *product.SKU)
+	assert.Equal(t, "4006381333931", *product.Barcode)
+
+	assert.Nil(t, product.SetIdentifiers("", ""))
+	assert.Nil(t, product.SKU)
+	assert.Nil(t, product.Barcode)
+
+	assert.Equal(t, ErrInvalidSKU, product.SetIdentifiers("ABC 123", ""))
+	assert.Equal(t, ErrInvalidBarcode, product.SetIdentifiers("ABC-123", "4006381333932"))
+}
+
+func TestProduct_SetTags(t *testing.T) {
+	product, _ := NewProduct("Product 1", 10.5)
+
+	assert.Nil(t, product.SetTags([]string{" Sale", "outdoor", "sale", "NEW"}))
+	assert.Equal(t, []string{"new", "outdoor", "sale"}, product.Tags)
+	assert.Nil(t, product.Validate())
+
+	assert.Nil(t, product.SetTags(nil))
+	assert.Nil(t, product.Tags)
+
+	assert.ErrorIs(t, product.SetTags([]string{"ok", " "}), ErrInvalidTags)
+	assert.ErrorIs(t, product.SetTags([]string{strings.Repeat("a", MaxTagLength+1)}), ErrInvalidTags)
+
+	tooMany := make([]string, MaxTags+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("tag-%d", i)
+	}
+	assert.ErrorIs(t, product.SetTags(tooMany), ErrInvalidTags)
+
+	// Validate recusa tags fora da forma normalizada
+	product.Tags = []string{"Sale"}
+	assert.Equal(t, ErrInvalidTags, product.Validate())
+}
+
+func TestProduct_Stock(t *testing.T) {
+	product, _ := NewProduct("Product 1", 10)
+	assert.True(t, product.InStock(1000))
+
+	stock := 2
+	product.Stock = &stock
+	assert.True(t, product.InStock(2))
+	assert.False(t, product.InStock(3))
+
+	stock = -1
+	assert.Equal(t, ErrInvalidStock, product.Validate())
+}
@@ -0,0 +1,111 @@
+package entity
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/otthonleao/go-products.git/pkg/validation"
+)
+
+var (
+	ErrProductHasNoOptionAxes = errors.New("product has no option axes")
+	ErrInvalidOptions         = errors.New("options must have exactly one non-empty value for each option axis of the product")
+	ErrInvalidStock           = errors.New("invalid stock")
+)
+
+// ProductVariant é uma combinação de valores dos eixos de opção do produto
+// (ex.: size=M, color=red) com SKU, preço e estoque próprios.
+type ProductVariant struct {
+	ID             entity.ID         `json:"id"`
+	ProductID      entity.ID         `json:"product_id" gorm:"index;uniqueIndex:idx_variants_product_combination"`
+	OrganizationID entity.ID         `json:"organization_id" gorm:"uniqueIndex:idx_variants_org_sku"`
+	SKU            *string           `json:"sku,omitempty" gorm:"uniqueIndex:idx_variants_org_sku"`
+	Options        map[string]string `json:"options" gorm:"serializer:json"`
+	// Combination é a forma canônica de Options e garante que cada combinação
+	// apareça uma única vez por produto
+	Combination string `json:"-" gorm:"uniqueIndex:idx_variants_product_combination"`
+	// Price substitui o preço do produto quando informado
+	Price     *float64  `json:"price,omitempty"`
+	Stock     int       `json:"stock"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewProductVariant(product *Product, options map[string]string, sku string, price *float64, stock int) (*ProductVariant, error) {
+	now := time.Now()
+	variant := &ProductVariant{
+		ID:             entity.NewID(),
+		ProductID:      product.ID,
+		OrganizationID: product.OrganizationID,
+		SKU:            optional(sku),
+		Options:        options,
+		Price:          price,
+		Stock:          stock,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	if err := variant.Validate(product); err != nil {
+		return nil, err
+	}
+
+	return variant, nil
+}
+
+// Validate confere a variante contra os eixos de opção do produto e atualiza Combination.
+func (v *ProductVariant) Validate(product *Product) error {
+	if len(product.OptionAxes) == 0 {
+		return ErrProductHasNoOptionAxes
+	}
+
+	if len(v.Options) != len(product.OptionAxes) {
+		return ErrInvalidOptions
+	}
+	for _, axis := range product.OptionAxes {
+		if strings.TrimSpace(v.Options[axis]) == "" {
+			return ErrInvalidOptions
+		}
+	}
+
+	if v.SKU != nil && (len(*v.SKU) > MaxSKULength || !validation.IsIdentifier(*v.SKU)) {
+		return ErrInvalidSKU
+	}
+
+	if v.Price != nil && *v.Price <= 0 {
+		return ErrInvalidPrice
+	}
+
+	if v.Stock < 0 {
+		return ErrInvalidStock
+	}
+
+	v.Combination = combination(v.Options)
+	return nil
+}
+
+// EffectivePrice retorna o preço da variante ou, sem substituição, o do produto.
+func (v *ProductVariant) EffectivePrice(product *Product) float64 {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return product.Price
+}
+
+// combination serializa as opções em ordem alfabética dos eixos, como
+// "color=red;size=m". Os valores são comparados sem diferenciar maiúsculas.
+func combination(options map[string]string) string {
+	axes := make([]string, 0, len(options))
+	for axis := range options {
+		axes = append(axes, axis)
+	}
+	sort.Strings(axes)
+
+	parts := make([]string, 0, len(axes))
+	for _, axis := range axes {
+		parts = append(parts, axis+"="+strings.ToLower(strings.TrimSpace(options[axis])))
+	}
+	return strings.Join(parts, ";")
+}
@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newShirt(t *testing.T) *Product {
+	product, err := NewProduct("T-Shirt", 50)
+	assert.Nil(t, err)
+	product.OptionAxes = []string{"size", "color"}
+	assert.Nil(t, product.Validate())
+	return product
+}
+
+func TestNewProductVariant(t *testing.T) {
+	product := newShirt(t)
+	price := 55.0
+
+	variant, err := NewProductVariant(product, map[string]string{"size": "M", "color": "Red"}, "SHIRT-M-RED", &price, 10)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, variant.ID)
+	assert.Equal(t, product.ID, variant.ProductID)
+	assert.Equal(t, "SHIRT-M-RED", *variant.SKU)
+	assert.Equal(t, "color=red;size=m", variant.Combination)
+	assert.Equal(t, 55.0, variant.EffectivePrice(product))
+
+	variant.Price = nil
+	assert.Equal(t, 50.0, variant.EffectivePrice(product))
+}
+
+func TestNewProductVariant_WhenOptionsDoNotMatchAxes(t *testing.T) {
+	product := newShirt(t)
+
+	for _, options := range []map[string]string{
+		{"size": "M"},
+		{"size": "M", "fabric": "cotton"},
+		{"size": "M", "color": " "},
+		{"size": "M", "color": "red", "fabric": "cotton"},
+	} {
+		variant, err := NewProductVariant(product, options, "", nil, 0)
+		assert.Nil(t, variant)
+		assert.Equal(t, ErrInvalidOptions, err)
+	}
+}
+
+func TestNewProductVariant_WhenInvalid(t *testing.T) {
+	product := newShirt(t)
+	options := map[string]string{"size": "M", "color": "red"}
+	price := -1.0
+
+	_, err := NewProductVariant(product, options, "", &price, 0)
+	assert.Equal(t, ErrInvalidPrice, err)
+
+	_, err = NewProductVariant(product, options, "", nil, -1)
+	assert.Equal(t, ErrInvalidStock, err)
+
+	_, err = NewProductVariant(product, options, "SKU M", nil, 0)
+	assert.Equal(t, ErrInvalidSKU, err)
+
+	withoutAxes, _ := NewProduct("Mug", 20)
+	_, err = NewProductVariant(withoutAxes, map[string]string{"size": "M"}, "", nil, 0)
+	assert.Equal(t, ErrProductHasNoOptionAxes, err)
+}
+
+func TestProduct_WhenOptionAxesAreInvalid(t *testing.T) {
+	product, _ := NewProduct("T-Shirt", 50)
+
+	for _, axes := range [][]string{
+		{"size", "size"},
+		{"size", ""},
+		{"shoe size"},
+		{"a", "b", "c", "d"},
+	} {
+		product.OptionAxes = axes
+		assert.Equal(t, ErrInvalidOptionAxes, product.Validate())
+	}
+}
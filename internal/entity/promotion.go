@@ -0,0 +1,214 @@
+package entity
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/otthonleao/go-products.git/pkg/money"
+	"github.com/otthonleao/go-products.git/pkg/validation"
+)
+
+// Tipos de promoção. Todos calculam o desconto por linha: percentual sobre o
+// valor da linha, valor fixo por unidade ou, em "leve X ganhe Y", Y unidades
+// grátis a cada X+Y unidades do mesmo produto, somando as variações.
+const (
+	PromotionPercentage = "percentage"
+	PromotionFixed      = "fixed"
+	PromotionBuyXGetY   = "buy_x_get_y"
+)
+
+const MaxCouponCodeLength = 32
+
+var (
+	ErrInvalidPromotionKind   = errors.New("promotion kind must be percentage, fixed or buy_x_get_y")
+	ErrInvalidPercentage      = errors.New("percentage must be greater than 0 and at most 100")
+	ErrInvalidAmount          = errors.New("amount must be greater than 0")
+	ErrInvalidBuyXGetY        = errors.New("buy_quantity and free_quantity must be greater than 0")
+	ErrInvalidPromotionWindow = errors.New("ends_at must be after starts_at")
+	ErrInvalidCouponCode      = errors.New("invalid coupon code")
+	ErrInvalidUsageLimit      = errors.New("usage limit must be greater than 0")
+	ErrCouponNotValid         = errors.New("coupon is not valid at this time")
+	ErrCouponExhausted        = errors.New("coupon usage limit reached")
+	// ErrCouponUnavailable é a resposta pública para qualquer cupom recusado,
+	// para não revelar quais códigos existem nem o estado deles.
+	ErrCouponUnavailable = errors.New("coupon is not valid")
+)
+
+// Promotion é uma regra de desconto da organização. Sem Code ela é automática
+// e vale para todas as compras; com Code só vale quando o cupom é informado.
+// Sem ProductIDs e CategoryIDs vale para todos os produtos.
+type Promotion struct {
+	ID             entity.ID `json:"id"`
+	OrganizationID entity.ID `json:"organization_id" gorm:"uniqueIndex:idx_promotions_org_code"`
+	Name           string    `json:"name"`
+	Kind           string    `json:"kind"`
+	// Percentage é o desconto percentual, com até duas casas decimais
+	Percentage float64 `json:"percentage,omitempty"`
+	// Amount é o desconto fixo por unidade, em centavos
+	Amount       money.Cents `json:"amount,omitempty"`
+	BuyQuantity  int         `json:"buy_quantity,omitempty"`
+	FreeQuantity int         `json:"free_quantity,omitempty"`
+	ProductIDs   []entity.ID `json:"product_ids,omitempty" gorm:"serializer:json"`
+	CategoryIDs  []entity.ID `json:"category_ids,omitempty" gorm:"serializer:json"`
+	Code         *string     `json:"code,omitempty" gorm:"uniqueIndex:idx_promotions_org_code"`
+	// UsageLimit nulo permite usos ilimitados do cupom
+	UsageLimit *int       `json:"usage_limit,omitempty"`
+	UsageCount int        `json:"usage_count"`
+	StartsAt   *time.Time `json:"starts_at,omitempty"`
+	EndsAt     *time.Time `json:"ends_at,omitempty"`
+	Active     bool       `json:"active"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// NewPromotion cria uma promoção ativa. Os valores do desconto são preenchidos
+// por quem chama e conferidos por Validate.
+func NewPromotion(name, kind string) *Promotion {
+	now := time.Now()
+	return &Promotion{
+		ID:        entity.NewID(),
+		Name:      name,
+		Kind:      kind,
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// NormalizeCouponCode compara cupons sem diferenciar maiúsculas e espaços nas pontas.
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (p *Promotion) Validate() error {
+	if p.Name == "" {
+		return ErrNameIsRequired
+	}
+
+	switch p.Kind {
+	case PromotionPercentage:
+		if p.Percentage <= 0 || p.Percentage > 100 {
+			return ErrInvalidPercentage
+		}
+	case PromotionFixed:
+		if p.Amount <= 0 {
+			return ErrInvalidAmount
+		}
+	case PromotionBuyXGetY:
+		if p.BuyQuantity < 1 || p.FreeQuantity < 1 {
+			return ErrInvalidBuyXGetY
+		}
+	default:
+		return ErrInvalidPromotionKind
+	}
+
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return ErrInvalidPromotionWindow
+	}
+
+	if p.Code != nil {
+		code := NormalizeCouponCode(*p.Code)
+		if code == "" || len(code) > MaxCouponCodeLength || !validation.IsIdentifier(code) {
+			return ErrInvalidCouponCode
+		}
+		p.Code = &code
+	}
+	if p.UsageLimit != nil && *p.UsageLimit < 1 {
+		return ErrInvalidUsageLimit
+	}
+	return nil
+}
+
+// ValidAt indica se a promoção está ativa e dentro da vigência em now.
+func (p *Promotion) ValidAt(now time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return false
+	}
+	return p.EndsAt == nil || now.Before(*p.EndsAt)
+}
+
+// CheckCoupon confere se o cupom pode ser usado em now.
+func (p *Promotion) CheckCoupon(now time.Time) error {
+	if !p.ValidAt(now) {
+		return ErrCouponNotValid
+	}
+	if p.UsageLimit != nil && p.UsageCount >= *p.UsageLimit {
+		return ErrCouponExhausted
+	}
+	return nil
+}
+
+// Applies indica se a promoção vale para o produto, pelo ID ou pela categoria.
+func (p *Promotion) Applies(productID entity.ID, categoryID *entity.ID) bool {
+	if len(p.ProductIDs) == 0 && len(p.CategoryIDs) == 0 {
+		return true
+	}
+	if slices.Contains(p.ProductIDs, productID) {
+		return true
+	}
+	return categoryID != nil && slices.Contains(p.CategoryIDs, *categoryID)
+}
+
+// FreeUnits reparte as unidades grátis de "leve X ganhe Y" entre as linhas.
+// As unidades de um produto são contadas juntas, mesmo em variações de linhas
+// diferentes, e as grátis são as mais baratas. Linhas fora da promoção ficam
+// com zero.
+func (p *Promotion) FreeUnits(lines []PricingLine) []int {
+	free := make([]int, len(lines))
+	if p.Kind != PromotionBuyXGetY {
+		return free
+	}
+	groups := map[entity.ID][]int{}
+	var products []entity.ID
+	for i, line := range lines {
+		if !p.Applies(line.ProductID, line.CategoryID) {
+			continue
+		}
+		if _, ok := groups[line.ProductID]; !ok {
+			products = append(products, line.ProductID)
+		}
+		groups[line.ProductID] = append(groups[line.ProductID], i)
+	}
+	for _, productID := range products {
+		indexes := groups[productID]
+		var quantity int
+		for _, i := range indexes {
+			quantity += lines[i].Quantity
+		}
+		remaining := quantity / (p.BuyQuantity + p.FreeQuantity) * p.FreeQuantity
+		slices.SortStableFunc(indexes, func(a, b int) int {
+			return cmp.Compare(lines[a].UnitPrice, lines[b].UnitPrice)
+		})
+		for _, i := range indexes {
+			free[i] = min(lines[i].Quantity, remaining)
+			remaining -= free[i]
+		}
+	}
+	return free
+}
+
+// Discount calcula o desconto da promoção numa linha, limitado a base, o
+// valor da linha ainda não descontado. O percentual é aplicado sobre base em
+// pontos-base e arredondado para o centavo mais próximo.
+func (p *Promotion) Discount(unitPrice money.Cents, quantity int, base money.Cents) money.Cents {
+	var discount money.Cents
+	switch p.Kind {
+	case PromotionPercentage:
+		basisPoints := money.Cents(math.Round(p.Percentage * 100))
+		discount = (base*basisPoints + 5000) / 10000
+	case PromotionFixed:
+		discount = min(p.Amount, unitPrice).Times(quantity)
+	case PromotionBuyXGetY:
+		free := quantity / (p.BuyQuantity + p.FreeQuantity) * p.FreeQuantity
+		discount = unitPrice.Times(free)
+	}
+	return max(min(discount, base), 0)
+}
@@ -0,0 +1,180 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/otthonleao/go-products.git/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromotion_Validate(t *testing.T) {
+	promotion := NewPromotion("Summer", PromotionPercentage)
+	assert.True(t, promotion.Active)
+	assert.ErrorIs(t, promotion.Validate(), ErrInvalidPercentage)
+	promotion.Percentage = 100.5
+	assert.ErrorIs(t, promotion.Validate(), ErrInvalidPercentage)
+	promotion.Percentage = 12.5
+	assert.NoError(t, promotion.Validate())
+
+	fixed := NewPromotion("Fixed", PromotionFixed)
+	assert.ErrorIs(t, fixed.Validate(), ErrInvalidAmount)
+	bundle := NewPromotion("Bundle", PromotionBuyXGetY)
+	bundle.BuyQuantity = 2
+	assert.ErrorIs(t, bundle.Validate(), ErrInvalidBuyXGetY)
+	assert.ErrorIs(t, NewPromotion("Other", "bogus").Validate(), ErrInvalidPromotionKind)
+	assert.ErrorIs(t, NewPromotion("", PromotionFixed).Validate(), ErrNameIsRequired)
+
+	now := time.Now()
+	promotion.StartsAt, promotion.EndsAt = &now, &now
+	assert.ErrorIs(t, promotion.Validate(), ErrInvalidPromotionWindow)
+	promotion.StartsAt = nil
+
+	code := " summer-10 "
+	promotion.Code = &code
+	assert.NoError(t, promotion.Validate())
+	assert.Equal(t, "SUMMER-10", *promotion.Code)
+	code = "summer 10"
+	promotion.Code = &code
+	assert.ErrorIs(t, promotion.Validate(), ErrInvalidCouponCode)
+	promotion.Code = nil
+
+	limit := 0
+	promotion.UsageLimit = &limit
+	assert.ErrorIs(t, promotion.Validate(), ErrInvalidUsageLimit)
+}
+
+func TestPromotion_CheckCoupon(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+	promotion := NewPromotion("Coupon", PromotionFixed)
+	assert.NoError(t, promotion.CheckCoupon(now))
+
+	promotion.StartsAt = &later
+	assert.ErrorIs(t, promotion.CheckCoupon(now), ErrCouponNotValid)
+	promotion.StartsAt, promotion.EndsAt = nil, &now
+	assert.ErrorIs(t, promotion.CheckCoupon(now), ErrCouponNotValid)
+	promotion.EndsAt = &later
+	promotion.Active = false
+	assert.ErrorIs(t, promotion.CheckCoupon(now), ErrCouponNotValid)
+	promotion.Active = true
+
+	limit := 2
+	promotion.UsageLimit = &limit
+	promotion.UsageCount = 2
+	assert.ErrorIs(t, promotion.CheckCoupon(now), ErrCouponExhausted)
+	promotion.UsageCount = 1
+	assert.NoError(t, promotion.CheckCoupon(now))
+}
+
+func TestPromotion_Discount(t *testing.T) {
+	percentage := &Promotion{Kind: PromotionPercentage, Percentage: 12.5}
+	// 12,5% de 3 x 3,33 = 1,24875, arredondado para 1,25
+	assert.Equal(t, money.Cents(125), percentage.Discount(333, 3, 999))
+
+	fixed := &Promotion{Kind: PromotionFixed, Amount: 300}
+	assert.Equal(t, money.Cents(600), fixed.Discount(1000, 2, 2000))
+	// O desconto fixo não passa do preço da unidade nem do que sobrou da linha
+	assert.Equal(t, money.Cents(400), fixed.Discount(200, 2, 400))
+	assert.Equal(t, money.Cents(100), fixed.Discount(1000, 2, 100))
+
+	bundle := &Promotion{Kind: PromotionBuyXGetY, BuyQuantity: 2, FreeQuantity: 1}
+	assert.Equal(t, money.Cents(0), bundle.Discount(500, 2, 1000))
+	assert.Equal(t, money.Cents(500), bundle.Discount(500, 3, 1500))
+	assert.Equal(t, money.Cents(1000), bundle.Discount(500, 7, 3500))
+}
+
+func TestPromotion_Applies(t *testing.T) {
+	productID, categoryID := entity.NewID(), entity.NewID()
+	promotion := &Promotion{}
+	assert.True(t, promotion.Applies(productID, nil))
+
+	promotion.CategoryIDs = []entity.ID{categoryID}
+	assert.False(t, promotion.Applies(productID, nil))
+	assert.True(t, promotion.Applies(productID, &categoryID))
+
+	promotion.CategoryIDs = nil
+	promotion.ProductIDs = []entity.ID{productID}
+	assert.True(t, promotion.Applies(productID, nil))
+	assert.False(t, promotion.Applies(entity.NewID(), &categoryID))
+}
+
+func TestPriceLines(t *testing.T) {
+	pen, shirt := entity.NewID(), entity.NewID()
+	clothing := entity.NewID()
+	lines := []PricingLine{
+		{ProductID: pen, Name: "Pen", UnitPrice: 250, Quantity: 3},
+		{ProductID: shirt, CategoryID: &clothing, Name: "Shirt", UnitPrice: 4000, Quantity: 1},
+	}
+
+	tenOff := Promotion{ID: entity.NewID(), Name: "10% off", Kind: PromotionPercentage, Percentage: 10}
+	bundle := Promotion{ID: entity.NewID(), Name: "Buy 2 get 1", Kind: PromotionBuyXGetY, BuyQuantity: 2, FreeQuantity: 1, ProductIDs: []entity.ID{pen}}
+	code := "CLOTHES"
+	coupon := &Promotion{ID: entity.NewID(), Name: "Clothes", Kind: PromotionFixed, Amount: 1000, CategoryIDs: []entity.ID{clothing}, Code: &code}
+
+	quote := PriceLines(lines, []Promotion{tenOff, bundle}, coupon)
+
+	// A caneta fica com a melhor promoção automática (uma grátis)
+	assert.Equal(t, money.Cents(750), quote.Lines[0].Subtotal)
+	assert.Equal(t, money.Cents(250), quote.Lines[0].Discount)
+	assert.Equal(t, money.Cents(500), quote.Lines[0].Total)
+	assert.Len(t, quote.Lines[0].Promotions, 1)
+	assert.Equal(t, bundle.ID, quote.Lines[0].Promotions[0].PromotionID)
+
+	// A camisa recebe 10% e o cupom sobre o restante
+	assert.Equal(t, money.Cents(1400), quote.Lines[1].Discount)
+	assert.Equal(t, money.Cents(2600), quote.Lines[1].Total)
+	assert.Len(t, quote.Lines[1].Promotions, 2)
+	assert.Equal(t, "CLOTHES", quote.Lines[1].Promotions[1].Code)
+
+	assert.Equal(t, money.Cents(4750), quote.Subtotal)
+	assert.Equal(t, money.Cents(1650), quote.Discount)
+	assert.Equal(t, money.Cents(3100), quote.Total)
+	assert.Len(t, quote.Promotions, 3)
+
+	// Sem promoções o total é o subtotal
+	quote = PriceLines(lines, nil, nil)
+	assert.Equal(t, quote.Subtotal, quote.Total)
+	assert.Empty(t, quote.Promotions)
+	assert.Empty(t, quote.Lines[0].Promotions)
+}
+
+func TestPriceLines_BuyXGetYAcrossVariants(t *testing.T) {
+	shirt, pen := entity.NewID(), entity.NewID()
+	small, large := entity.NewID(), entity.NewID()
+	lines := []PricingLine{
+		{ProductID: shirt, VariantID: &large, Name: "Shirt L", UnitPrice: 4500, Quantity: 2},
+		{ProductID: pen, Name: "Pen", UnitPrice: 250, Quantity: 1},
+		{ProductID: shirt, VariantID: &small, Name: "Shirt S", UnitPrice: 4000, Quantity: 1},
+	}
+	bundle := Promotion{ID: entity.NewID(), Name: "Buy 2 get 1", Kind: PromotionBuyXGetY, BuyQuantity: 2, FreeQuantity: 1}
+
+	// As três camisas contam juntas e a grátis é a mais barata
+	assert.Equal(t, []int{0, 0, 1}, bundle.FreeUnits(lines))
+
+	quote := PriceLines(lines, []Promotion{bundle}, nil)
+	assert.Equal(t, money.Cents(0), quote.Lines[0].Discount)
+	assert.Equal(t, money.Cents(0), quote.Lines[1].Discount)
+	assert.Equal(t, money.Cents(4000), quote.Lines[2].Discount)
+	assert.Equal(t, money.Cents(4000), quote.Discount)
+}
+
+func TestOrder_ApplyQuote(t *testing.T) {
+	product, _ := NewProduct("Pen", 2.5)
+	item, _ := NewOrderItem(product, nil, 3)
+	order, _ := NewOrder(entity.NewID(), entity.NewID(), []OrderItem{*item})
+	assert.Equal(t, money.Cents(750), order.Subtotal)
+	assert.Equal(t, order.Subtotal, order.Total)
+
+	code := "PENS"
+	coupon := Promotion{ID: entity.NewID(), Name: "Pens", Kind: PromotionFixed, Amount: 100, Code: &code}
+	quote := PriceLines([]PricingLine{item.PricingLine(nil)}, nil, &coupon)
+	order.ApplyQuote(quote, &coupon)
+	assert.Equal(t, money.Cents(300), order.Discount)
+	assert.Equal(t, money.Cents(450), order.Total)
+	assert.Equal(t, money.Cents(300), order.Items[0].Discount)
+	assert.Equal(t, money.Cents(450), order.Items[0].Total)
+	assert.Equal(t, coupon.ID, *order.CouponID)
+	assert.Equal(t, "PENS", *order.CouponCode)
+}
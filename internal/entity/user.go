@@ -8,7 +8,7 @@ import (
 type User struct {
 	ID       entity.ID `json:"id"`
 	Name     string    `json:"name"`
-	Email    string    `json:"email"`
+	Email    string    `json:"email" gorm:"uniqueIndex"`
 	Password string    `json:"-"`
 }
 
@@ -16,7 +16,7 @@ func NewUser(name, email, password string) (*User, error) {
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	return &User{
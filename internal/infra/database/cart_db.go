@@ -0,0 +1,191 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/tracing"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// Cart guarda os carrinhos e suas linhas. O acesso (dono ou token) é conferido
+// pelo chamador depois de FindByID.
+type Cart struct {
+	DB *gorm.DB
+}
+
+func NewCart(db *gorm.DB) *Cart {
+	return &Cart{
+		DB: db,
+	}
+}
+
+// WithContext retorna uma cópia do repositório que propaga ctx para as consultas.
+func (c *Cart) WithContext(ctx context.Context) CartInterface {
+	return &Cart{
+		DB: c.DB.WithContext(ctx),
+	}
+}
+
+func (c *Cart) trace(method string) (*gorm.DB, trace.Span) {
+	ctx, span := tracing.Start(c.DB.Statement.Context, "database.Cart."+method)
+	return c.DB.WithContext(ctx), span
+}
+
+func (c *Cart) Create(cart *entity.Cart) (err error) {
+	db, span := c.trace("Create")
+	defer func() { tracing.End(span, err) }()
+
+	return db.Create(cart).Error
+}
+
+// FindByID carrega o carrinho com as linhas na ordem em que foram adicionadas
+// e os totais calculados.
+func (c *Cart) FindByID(id string) (_ *entity.Cart, err error) {
+	db, span := c.trace("FindByID")
+	defer func() { tracing.End(span, err) }()
+
+	var cart entity.Cart
+	if err = db.First(&cart, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	cart.Items = []entity.CartItem{}
+	if err = db.Where("cart_id = ?", id).Order("created_at asc").Find(&cart.Items).Error; err != nil {
+		return nil, err
+	}
+	cart.Totals()
+	return &cart, nil
+}
+
+// touchCart grava a nova validade do carrinho dentro da transação de uma
+// alteração. Por ser a primeira escrita da transação, ela também trava o
+// carrinho até o fim, serializando as alterações concorrentes nas linhas.
+func touchCart(tx *gorm.DB, cart *entity.Cart) error {
+	result := tx.Model(&entity.Cart{}).Where("id = ?", cart.ID).Updates(map[string]interface{}{
+		"expires_at": cart.ExpiresAt,
+		"updated_at": cart.UpdatedAt,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// AddItem soma quantity à linha do produto e variante, criando a linha se
+// preciso, e renova a validade do carrinho. As linhas são relidas depois de
+// travar o carrinho, então adições concorrentes somam na mesma linha e
+// respeitam MaxCartItems. Passar de limit unidades na linha retorna
+// entity.ErrInsufficientStock. Depois da gravação cart.Items traz as linhas
+// atuais do carrinho.
+func (c *Cart) AddItem(cart *entity.Cart, product *entity.Product, variant *entity.ProductVariant, quantity, limit int) (err error) {
+	db, span := c.trace("AddItem")
+	defer func() { tracing.End(span, err) }()
+
+	locked := *cart
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := touchCart(tx, cart); err != nil {
+			return err
+		}
+		locked.Items = []entity.CartItem{}
+		if err := tx.Where("cart_id = ?", cart.ID).Order("created_at asc").Find(&locked.Items).Error; err != nil {
+			return err
+		}
+
+		item, err := locked.AddItem(product, variant, quantity)
+		if err != nil {
+			return err
+		}
+		if item.Quantity > limit {
+			return entity.ErrInsufficientStock
+		}
+		return tx.Save(item).Error
+	})
+	if err != nil {
+		return err
+	}
+	cart.Items = locked.Items
+	return nil
+}
+
+// UpdateItem grava a nova quantidade da linha e renova a validade do carrinho.
+// Só a quantidade muda, então uma linha removida por outra requisição não volta.
+func (c *Cart) UpdateItem(cart *entity.Cart, item *entity.CartItem) (err error) {
+	db, span := c.trace("UpdateItem")
+	defer func() { tracing.End(span, err) }()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := touchCart(tx, cart); err != nil {
+			return err
+		}
+		result := tx.Model(&entity.CartItem{}).Where("id = ? AND cart_id = ?", item.ID, cart.ID).Updates(map[string]interface{}{
+			"quantity":   item.Quantity,
+			"updated_at": item.UpdatedAt,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// DeleteItem remove a linha e renova a validade do carrinho.
+func (c *Cart) DeleteItem(cart *entity.Cart, itemID string) (err error) {
+	db, span := c.trace("DeleteItem")
+	defer func() { tracing.End(span, err) }()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("cart_id = ?", cart.ID).Delete(&entity.CartItem{}, "id = ?", itemID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return touchCart(tx, cart)
+	})
+}
+
+func (c *Cart) Delete(id string) (err error) {
+	db, span := c.trace("Delete")
+	defer func() { tracing.End(span, err) }()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("cart_id = ?", id).Delete(&entity.CartItem{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&entity.Cart{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// DeleteExpired remove os carrinhos abandonados, com suas linhas, e retorna
+// quantos carrinhos foram apagados.
+func (c *Cart) DeleteExpired(now time.Time) (deleted int64, err error) {
+	db, span := c.trace("DeleteExpired")
+	defer func() { tracing.End(span, err) }()
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		expired := tx.Model(&entity.Cart{}).Select("id").Where("expires_at <= ?", now)
+		if err := tx.Where("cart_id IN (?)", expired).Delete(&entity.CartItem{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("expires_at <= ?", now).Delete(&entity.Cart{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	return deleted, err
+}
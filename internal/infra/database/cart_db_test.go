@@ -0,0 +1,77 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/otthonleao/go-products.git/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCartLifecycle(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	db.AutoMigrate(&entity.Cart{}, &entity.CartItem{})
+	cartDB := NewCart(db)
+
+	cart, _ := entity.NewCart(entityPkg.NewID(), nil, time.Hour)
+	assert.NoError(t, cartDB.Create(cart))
+
+	product, _ := entity.NewProduct("Product 1", 12.5)
+	assert.NoError(t, cartDB.AddItem(cart, product, nil, 2, 10))
+	assert.Len(t, cart.Items, 1)
+	item := cart.Items[0]
+
+	cartFound, err := cartDB.FindByID(cart.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, cartFound.Items, 1)
+	assert.Equal(t, money.Cents(2500), cartFound.Subtotal)
+	assert.True(t, cartFound.CheckToken(cart.Token))
+
+	// Adições a partir de cópias desatualizadas somam na mesma linha e
+	// respeitam o limite com a quantidade gravada
+	stale, _ := cartDB.FindByID(cart.ID.String())
+	assert.NoError(t, cartDB.AddItem(cartFound, product, nil, 3, 10))
+	assert.NoError(t, cartDB.AddItem(stale, product, nil, 4, 10))
+	assert.Len(t, stale.Items, 1)
+	assert.Equal(t, 9, stale.Items[0].Quantity)
+	assert.ErrorIs(t, cartDB.AddItem(cartFound, product, nil, 2, 10), entity.ErrInsufficientStock)
+	cartFound, _ = cartDB.FindByID(cart.ID.String())
+	assert.Len(t, cartFound.Items, 1)
+	assert.Equal(t, 9, cartFound.Items[0].Quantity)
+
+	item.SetQuantity(1)
+	assert.NoError(t, cartDB.UpdateItem(cart, &item))
+	cartFound, _ = cartDB.FindByID(cart.ID.String())
+	assert.Equal(t, 1, cartFound.Items[0].Quantity)
+
+	assert.NoError(t, cartDB.DeleteItem(cart, item.ID.String()))
+	assert.ErrorIs(t, cartDB.DeleteItem(cart, item.ID.String()), gorm.ErrRecordNotFound)
+	// Uma linha removida não volta com a alteração de quantidade
+	assert.ErrorIs(t, cartDB.UpdateItem(cart, &item), gorm.ErrRecordNotFound)
+
+	// Só os carrinhos vencidos são apagados, com suas linhas
+	expired, _ := entity.NewCart(entityPkg.NewID(), nil, -time.Minute)
+	assert.NoError(t, cartDB.Create(expired))
+	expiredItem, _ := entity.NewCartItem(expired, product, nil, 1)
+	assert.NoError(t, db.Create(expiredItem).Error)
+
+	deleted, err := cartDB.DeleteExpired(time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	_, err = cartDB.FindByID(expired.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	var items int64
+	db.Model(&entity.CartItem{}).Count(&items)
+	assert.Equal(t, int64(0), items)
+
+	assert.NoError(t, cartDB.Delete(cart.ID.String()))
+	assert.ErrorIs(t, cartDB.Delete(cart.ID.String()), gorm.ErrRecordNotFound)
+}
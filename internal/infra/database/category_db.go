@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/tracing"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// Category guarda as categorias. Como os produtos, as consultas ficam restritas
+// ao tenant informado em ForTenant.
+type Category struct {
+	DB       *gorm.DB
+	TenantID string
+}
+
+func NewCategory(db *gorm.DB) *Category {
+	return &Category{
+		DB: db,
+	}
+}
+
+// ForTenant retorna uma cópia do repositório restrita à organização informada.
+func (c *Category) ForTenant(organizationID string) CategoryInterface {
+	return &Category{
+		DB:       c.DB,
+		TenantID: organizationID,
+	}
+}
+
+// WithContext retorna uma cópia do repositório que propaga ctx para as consultas.
+func (c *Category) WithContext(ctx context.Context) CategoryInterface {
+	return &Category{
+		DB:       c.DB.WithContext(ctx),
+		TenantID: c.TenantID,
+	}
+}
+
+func (c *Category) trace(method string) (*gorm.DB, trace.Span) {
+	ctx, span := tracing.Start(c.DB.Statement.Context, "database.Category."+method)
+	return c.DB.WithContext(ctx), span
+}
+
+func (c *Category) Create(category *entity.Category) (err error) {
+	db, span := c.trace("Create")
+	defer func() { tracing.End(span, err) }()
+
+	category.OrganizationID, err = entityPkg.ParseID(c.TenantID)
+	if err != nil {
+		return err
+	}
+	return translateConflict(db.Create(category).Error, "name")
+}
+
+func (c *Category) FindAll() (categories []entity.Category, err error) {
+	db, span := c.trace("FindAll")
+	defer func() { tracing.End(span, err) }()
+
+	err = db.Where("organization_id = ?", c.TenantID).Order("name asc").Find(&categories).Error
+	return categories, err
+}
+
+func (c *Category) FindByID(id string) (_ *entity.Category, err error) {
+	db, span := c.trace("FindByID")
+	defer func() { tracing.End(span, err) }()
+
+	var category entity.Category
+	err = db.Where("organization_id = ?", c.TenantID).First(&category, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (c *Category) Update(category *entity.Category) (err error) {
+	db, span := c.trace("Update")
+	defer func() { tracing.End(span, err) }()
+
+	current, err := c.WithContext(db.Statement.Context).FindByID(category.ID.String())
+	if err != nil {
+		return err
+	}
+	category.OrganizationID = current.OrganizationID
+	category.CreatedAt = current.CreatedAt
+	return translateConflict(db.Save(category).Error, "name")
+}
+
+func (c *Category) Delete(id string) (err error) {
+	db, span := c.trace("Delete")
+	defer func() { tracing.End(span, err) }()
+
+	category, err := c.WithContext(db.Statement.Context).FindByID(id)
+	if err != nil {
+		return err
+	}
+	return db.Delete(category).Error
+}
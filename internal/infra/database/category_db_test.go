@@ -0,0 +1,116 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCategories(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	db.AutoMigrate(&entity.Category{})
+
+	acme := NewCategory(db).ForTenant(entityPkg.NewID().String())
+	globex := NewCategory(db).ForTenant(entityPkg.NewID().String())
+
+	category, _ := entity.NewCategory("Clothing", []entity.AttributeDefinition{{Name: "fabric", Type: entity.AttributeString}})
+	assert.NoError(t, acme.Create(category))
+
+	duplicated, _ := entity.NewCategory("Clothing", nil)
+	err = acme.Create(duplicated)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "conflict: name is already in use", err.Error())
+
+	// Outra organização pode ter uma categoria com o mesmo nome
+	other, _ := entity.NewCategory("Clothing", nil)
+	assert.NoError(t, globex.Create(other))
+
+	_, err = globex.FindByID(category.ID.String())
+	assert.Error(t, err)
+
+	categoryFound, err := acme.FindByID(category.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.AttributeDefinition{{Name: "fabric", Type: entity.AttributeString}}, categoryFound.Attributes)
+
+	category.Name = "Apparel"
+	assert.NoError(t, acme.Update(category))
+	categories, err := acme.FindAll()
+	assert.NoError(t, err)
+	assert.Len(t, categories, 1)
+	assert.Equal(t, "Apparel", categories[0].Name)
+
+	assert.Error(t, globex.Delete(category.ID.String()))
+	assert.NoError(t, acme.Delete(category.ID.String()))
+	categories, _ = acme.FindAll()
+	assert.Empty(t, categories)
+}
+
+func TestFindAllFilteredByAttributes(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	db.AutoMigrate(Models()...)
+
+	tenantID := entityPkg.NewID().String()
+	productDB := NewProduct(db).ForTenant(tenantID)
+	categoryID := entityPkg.NewID()
+
+	newProduct := func(name string, attributes map[string]interface{}) *entity.Product {
+		product, _ := entity.NewProduct(name, 10)
+		product.CategoryID = &categoryID
+		product.Attributes = attributes
+		return product
+	}
+	red := newProduct("Red", map[string]interface{}{"color": "red", "weight": 1.5, "organic": true})
+	blue := newProduct("Blue", map[string]interface{}{"color": "blue", "weight": 3.0})
+	assert.NoError(t, productDB.Create(red))
+	assert.NoError(t, productDB.CreateMany([]*entity.Product{blue}))
+	plain, _ := entity.NewProduct("Plain", 10)
+	assert.NoError(t, productDB.Create(plain))
+
+	// Outra organização com o mesmo atributo não aparece
+	assert.NoError(t, NewProduct(db).ForTenant(entityPkg.NewID().String()).Create(newProduct("Other", map[string]interface{}{"color": "red"})))
+
+	names := func(filter ProductFilter) []string {
+		products, err := productDB.FindAllFiltered(0, 0, "asc", filter)
+		assert.NoError(t, err)
+		var names []string
+		for _, product := range products {
+			names = append(names, product.Name)
+		}
+		return names
+	}
+	two := 2.0
+	three := 3.0
+
+	assert.Equal(t, []string{"Red"}, names(ProductFilter{Attributes: []AttributeFilter{{Name: "color", Operator: OperatorEqual, Value: "red"}}}))
+	assert.Equal(t, []string{"Red"}, names(ProductFilter{Attributes: []AttributeFilter{{Name: "weight", Operator: OperatorLess, Value: "2", Number: &two}}}))
+	assert.Equal(t, []string{"Blue"}, names(ProductFilter{Attributes: []AttributeFilter{{Name: "weight", Operator: OperatorEqual, Value: "3", Number: &three}}}))
+	assert.Equal(t, []string{"Red"}, names(ProductFilter{Attributes: []AttributeFilter{{Name: "organic", Operator: OperatorEqual, Value: "true"}}}))
+	assert.Empty(t, names(ProductFilter{Attributes: []AttributeFilter{
+		{Name: "color", Operator: OperatorEqual, Value: "red"},
+		{Name: "weight", Operator: OperatorGreaterOrEqual, Value: "2", Number: &two},
+	}}))
+	assert.Equal(t, []string{"Red", "Blue"}, names(ProductFilter{CategoryID: categoryID.String()}))
+
+	// Atualizar os atributos regrava o índice
+	red.Attributes = map[string]interface{}{"color": "green"}
+	assert.NoError(t, productDB.Update(red))
+	assert.Empty(t, names(ProductFilter{Attributes: []AttributeFilter{{Name: "color", Operator: OperatorEqual, Value: "red"}}}))
+	assert.Equal(t, []string{"Red"}, names(ProductFilter{Attributes: []AttributeFilter{{Name: "color", Operator: OperatorEqual, Value: "green"}}}))
+
+	assert.NoError(t, productDB.Delete(red.ID.String()))
+	var count int64
+	db.Model(&productAttributeValue{}).Where("product_id = ?", red.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrConflict indica que a gravação violou uma restrição de unicidade.
+var ErrConflict = errors.New("conflict")
+
+// translateConflict converte violações de índice único em ErrConflict, citando o
+// campo quando ele aparece na mensagem do banco. Funciona com ou sem a opção
+// TranslateError do gorm.
+func translateConflict(err error, fields ...string) error {
+	if err == nil {
+		return nil
+	}
+
+	message := err.Error()
+	unique := errors.Is(err, gorm.ErrDuplicatedKey) ||
+		strings.Contains(message, "UNIQUE constraint failed") || // sqlite
+		strings.Contains(message, "duplicate key value") // postgres
+	if !unique {
+		return err
+	}
+
+	for _, field := range fields {
+		if strings.Contains(message, field) {
+			return fmt.Errorf("%w: %s is already in use", ErrConflict, field)
+		}
+	}
+	return ErrConflict
+}
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestPendingMigrations(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	assert.NoError(t, Ping(context.Background(), db))
+
+	pending, err := PendingMigrations(context.Background(), db)
+	assert.NoError(t, err)
+	assert.Contains(t, pending, "products")
+	assert.Contains(t, pending, "users")
+
+	db.Exec("CREATE TABLE users (id text)")
+	pending, err = PendingMigrations(context.Background(), db)
+	assert.NoError(t, err)
+	assert.Contains(t, pending, "users.email")
+
+	db.Exec("DROP TABLE users")
+	db.AutoMigrate(Models()...)
+	pending, err = PendingMigrations(context.Background(), db)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+}
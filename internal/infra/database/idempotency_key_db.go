@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/tracing"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+type IdempotencyKey struct {
+	DB *gorm.DB
+}
+
+func NewIdempotencyKey(db *gorm.DB) *IdempotencyKey {
+	return &IdempotencyKey{
+		DB: db,
+	}
+}
+
+// WithContext retorna uma cópia do repositório que propaga ctx para as consultas.
+func (i *IdempotencyKey) WithContext(ctx context.Context) IdempotencyKeyInterface {
+	return &IdempotencyKey{
+		DB: i.DB.WithContext(ctx),
+	}
+}
+
+func (i *IdempotencyKey) trace(method string) (*gorm.DB, trace.Span) {
+	ctx, span := tracing.Start(i.DB.Statement.Context, "database.IdempotencyKey."+method)
+	return i.DB.WithContext(ctx), span
+}
+
+// Create reserva a chave. Falha se outra requisição já reservou a mesma chave,
+// o que serializa retentativas concorrentes.
+func (i *IdempotencyKey) Create(key *entity.IdempotencyKey) (err error) {
+	db, span := i.trace("Create")
+	defer func() { tracing.End(span, err) }()
+
+	return db.Create(key).Error
+}
+
+func (i *IdempotencyKey) Find(scope, key string) (_ *entity.IdempotencyKey, err error) {
+	db, span := i.trace("Find")
+	defer func() { tracing.End(span, err) }()
+
+	var idempotencyKey entity.IdempotencyKey
+	err = db.Where("scope = ? AND key = ?", scope, key).First(&idempotencyKey).Error
+	if err != nil {
+		return nil, err
+	}
+	return &idempotencyKey, nil
+}
+
+// Update grava a resposta da chave. Se a reserva foi assumida por outra
+// requisição (ReservedBy diferente), nada é alterado.
+func (i *IdempotencyKey) Update(key *entity.IdempotencyKey) (err error) {
+	db, span := i.trace("Update")
+	defer func() { tracing.End(span, err) }()
+
+	return db.Model(key).Where("reserved_by = ?", key.ReservedBy).Select("*").Updates(key).Error
+}
+
+// Delete apaga a chave se ela ainda pertencer à mesma reserva, para que uma
+// requisição atrasada não apague a reserva de quem assumiu a chave.
+func (i *IdempotencyKey) Delete(key *entity.IdempotencyKey) (err error) {
+	db, span := i.trace("Delete")
+	defer func() { tracing.End(span, err) }()
+
+	return db.Where("reserved_by = ?", key.ReservedBy).Delete(key).Error
+}
+
+// DeleteExpired remove as chaves vencidas e retorna quantas foram apagadas.
+func (i *IdempotencyKey) DeleteExpired(now time.Time) (_ int64, err error) {
+	db, span := i.trace("DeleteExpired")
+	defer func() { tracing.End(span, err) }()
+
+	result := db.Where("expires_at <= ?", now).Delete(&entity.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
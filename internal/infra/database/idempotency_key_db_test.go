@@ -0,0 +1,50 @@
+package database
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestIdempotencyKeyLifecycle(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	db.AutoMigrate(&entity.IdempotencyKey{})
+	idempotencyDB := NewIdempotencyKey(db)
+
+	key, _ := entity.NewIdempotencyKey("user-1", "key-1", "fingerprint", time.Hour)
+	assert.NoError(t, idempotencyDB.Create(key))
+
+	// A mesma chave no mesmo escopo não pode ser reservada duas vezes
+	duplicated, _ := entity.NewIdempotencyKey("user-1", "key-1", "fingerprint", time.Hour)
+	assert.Error(t, idempotencyDB.Create(duplicated))
+
+	// Mas pode ser usada por outro escopo
+	otherScope, _ := entity.NewIdempotencyKey("user-2", "key-1", "fingerprint", time.Hour)
+	assert.NoError(t, idempotencyDB.Create(otherScope))
+
+	key.Complete(http.StatusCreated, http.Header{"Location": {"/products/1"}}, []byte(`{"id":"1"}`))
+	assert.NoError(t, idempotencyDB.Update(key))
+
+	keyFound, err := idempotencyDB.Find("user-1", "key-1")
+	assert.NoError(t, err)
+	assert.True(t, keyFound.Completed)
+	assert.Equal(t, http.StatusCreated, keyFound.StatusCode)
+	assert.Equal(t, "/products/1", keyFound.Header.Get("Location"))
+	assert.Equal(t, `{"id":"1"}`, string(keyFound.Body))
+
+	deleted, err := idempotencyDB.DeleteExpired(time.Now().Add(2 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	_, err = idempotencyDB.Find("user-1", "key-1")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
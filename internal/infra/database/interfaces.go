@@ -1,16 +1,133 @@
 package database
 
-import "github.com/otthonleao/go-products.git/internal/entity"
+import (
+	"context"
+	"time"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+)
 
 type UserInterface interface {
+	WithContext(ctx context.Context) UserInterface
 	Create(user *entity.User) error
+	FindByID(id string) (*entity.User, error)
 	FindByEmail(email string) (*entity.User, error)
 }
 
+type OrganizationInterface interface {
+	WithContext(ctx context.Context) OrganizationInterface
+	Create(organization *entity.Organization) error
+	// CreateWithOwner grava a organização e a participação do dono na mesma transação.
+	CreateWithOwner(organization *entity.Organization, owner *entity.Membership) error
+	FindByID(id string) (*entity.Organization, error)
+	AddMember(membership *entity.Membership) error
+	FindMembership(userID, organizationID string) (*entity.Membership, error)
+	FindMembershipsByUser(userID string) ([]entity.Membership, error)
+}
+
 type ProductInterface interface {
+	// ForTenant retorna um repositório restrito aos produtos da organização informada.
+	ForTenant(organizationID string) ProductInterface
+	WithContext(ctx context.Context) ProductInterface
 	Create(product *entity.Product) error
+	CreateMany(products []*entity.Product) error
 	FindAll(page, limit int, sort string) ([]entity.Product, error)
+	// FindAllFiltered lista os produtos que atendem a todos os critérios do filtro.
+	FindAllFiltered(page, limit int, sort string, filter ProductFilter) ([]entity.Product, error)
+	// Facets conta os produtos do filtro por tag, categoria e faixa de preço.
+	Facets(filter ProductFilter, options FacetOptions) (*entity.ProductFacets, error)
 	FindByID(id string) (*entity.Product, error)
+	FindBySKU(sku string) (*entity.Product, error)
+	FindByBarcode(code string) (*entity.Product, error)
 	Update(product *entity.Product) error
+	// UpdateTags grava só as tags e o updated_at do produto.
+	UpdateTags(product *entity.Product) error
+	// Touch avança só o updated_at do produto, para alterações em dados ligados a ele.
+	Touch(id string, at time.Time) error
+	Delete(id string) error
+	// Transition grava a mudança de estado do produto e o histórico.
+	Transition(product *entity.Product, transition *entity.ProductTransition) error
+	FindTransitions(productID string) ([]entity.ProductTransition, error)
+}
+
+type CategoryInterface interface {
+	ForTenant(organizationID string) CategoryInterface
+	WithContext(ctx context.Context) CategoryInterface
+	Create(category *entity.Category) error
+	FindAll() ([]entity.Category, error)
+	FindByID(id string) (*entity.Category, error)
+	Update(category *entity.Category) error
 	Delete(id string) error
-}
\ No newline at end of file
+}
+
+type ProductVariantInterface interface {
+	WithContext(ctx context.Context) ProductVariantInterface
+	Create(variant *entity.ProductVariant) error
+	FindByProduct(productID string) ([]entity.ProductVariant, error)
+	// FindByProducts agrupa as variantes pelo ID do produto.
+	FindByProducts(productIDs []string) (map[string][]entity.ProductVariant, error)
+	FindByID(productID, id string) (*entity.ProductVariant, error)
+	Count(productID string) (int64, error)
+	Update(variant *entity.ProductVariant) error
+	Delete(productID, id string) error
+}
+
+type ProductImageInterface interface {
+	WithContext(ctx context.Context) ProductImageInterface
+	Create(image *entity.ProductImage) error
+	FindByProduct(productID string) ([]entity.ProductImage, error)
+	// FindByProducts agrupa as imagens pelo ID do produto.
+	FindByProducts(productIDs []string) (map[string][]entity.ProductImage, error)
+	FindByID(productID, id string) (*entity.ProductImage, error)
+	SetPrimary(productID, id string) error
+	Reorder(productID string, ids []string) error
+	Delete(productID, id string) error
+}
+
+type CartInterface interface {
+	WithContext(ctx context.Context) CartInterface
+	Create(cart *entity.Cart) error
+	// FindByID carrega o carrinho com as linhas e os totais.
+	FindByID(id string) (*entity.Cart, error)
+	// AddItem soma a quantidade à linha do produto e variante, ou cria a linha,
+	// conferindo os limites com as linhas relidas na transação.
+	AddItem(cart *entity.Cart, product *entity.Product, variant *entity.ProductVariant, quantity, limit int) error
+	UpdateItem(cart *entity.Cart, item *entity.CartItem) error
+	DeleteItem(cart *entity.Cart, itemID string) error
+	Delete(id string) error
+	DeleteExpired(now time.Time) (int64, error)
+}
+
+type OrderInterface interface {
+	// ForTenant retorna um repositório restrito aos pedidos da organização informada.
+	ForTenant(organizationID string) OrderInterface
+	WithContext(ctx context.Context) OrderInterface
+	// Place grava o pedido reservando o estoque e o cupom e remove o carrinho de origem, se houver.
+	Place(order *entity.Order, cartID string) error
+	FindByID(id string) (*entity.Order, error)
+	FindAll(page, limit int, filter OrderFilter) ([]entity.Order, error)
+	// Transition grava o novo estado e devolve o estoque e o cupom dos pedidos cancelados.
+	Transition(order *entity.Order, from string) error
+}
+
+type PromotionInterface interface {
+	ForTenant(organizationID string) PromotionInterface
+	WithContext(ctx context.Context) PromotionInterface
+	Create(promotion *entity.Promotion) error
+	FindAll(page, limit int) ([]entity.Promotion, error)
+	FindByID(id string) (*entity.Promotion, error)
+	FindByCode(code string) (*entity.Promotion, error)
+	// FindAutomatic lista as promoções sem cupom vigentes em now.
+	FindAutomatic(now time.Time) ([]entity.Promotion, error)
+	Update(promotion *entity.Promotion) error
+	Delete(id string) error
+}
+
+type IdempotencyKeyInterface interface {
+	WithContext(ctx context.Context) IdempotencyKeyInterface
+	Create(key *entity.IdempotencyKey) error
+	Find(scope, key string) (*entity.IdempotencyKey, error)
+	Update(key *entity.IdempotencyKey) error
+	Delete(key *entity.IdempotencyKey) error
+	DeleteExpired(now time.Time) (int64, error)
+}
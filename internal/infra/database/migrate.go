@@ -0,0 +1,65 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"gorm.io/gorm"
+)
+
+// ErrDuplicateEmails indica que a tabela de usuários tem e-mails repetidos e
+// não pode receber o índice único de e-mail.
+var ErrDuplicateEmails = errors.New("users table has duplicated emails")
+
+// Migrate confere os dados gravados por versões anteriores e migra o esquema de
+// todas as entidades de Models.
+func Migrate(db *gorm.DB) error {
+	if err := checkDuplicateEmails(db); err != nil {
+		return err
+	}
+
+	migrator := db.Migrator()
+	addsStatus := migrator.HasTable(&entity.Product{}) && !migrator.HasColumn(&entity.Product{}, "Status")
+	if err := db.AutoMigrate(Models()...); err != nil {
+		return err
+	}
+	if addsStatus {
+		return publishLegacyProducts(db)
+	}
+	return nil
+}
+
+// publishLegacyProducts publica os produtos criados antes do fluxo de
+// publicação. Roda só na migração que cria a coluna de estado, em que todas as
+// linhas existentes são anteriores ao fluxo e receberam o default (draft) ou
+// nenhum valor, conforme o banco. Depois dela um produto sem estado é um
+// rascunho, nunca um produto publicado.
+func publishLegacyProducts(db *gorm.DB) error {
+	return db.Model(&entity.Product{}).
+		Where("status IS NULL OR status = '' OR status = ?", entity.StatusDraft).
+		Update("status", entity.StatusPublished).Error
+}
+
+// checkDuplicateEmails impede a migração quando o índice único de e-mail ainda
+// não existe e há e-mails repetidos. Não há como escolher sozinho qual conta
+// manter, já que cada uma pode ter pedidos e participações, então os
+// duplicados precisam ser resolvidos à mão antes de subir a nova versão.
+func checkDuplicateEmails(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&entity.User{}) || migrator.HasIndex(&entity.User{}, "Email") {
+		return nil
+	}
+
+	var duplicated int64
+	err := db.Table("(?) AS duplicated",
+		db.Model(&entity.User{}).Select("email").Group("email").Having("COUNT(*) > 1"),
+	).Count(&duplicated).Error
+	if err != nil {
+		return err
+	}
+	if duplicated > 0 {
+		return fmt.Errorf("%w: %d emails are used by more than one user", ErrDuplicateEmails, duplicated)
+	}
+	return nil
+}
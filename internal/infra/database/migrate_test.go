@@ -0,0 +1,54 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMigrate_DuplicateEmails(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// Tabela de uma versão anterior, sem o índice único de e-mail
+	assert.NoError(t, db.Exec("CREATE TABLE users (id text PRIMARY KEY, name text, email text, password text)").Error)
+	assert.NoError(t, db.Exec("INSERT INTO users (id, name, email) VALUES ('1', 'John', 'j@j.com'), ('2', 'Johnny', 'j@j.com')").Error)
+
+	assert.ErrorIs(t, Migrate(db), ErrDuplicateEmails)
+	assert.False(t, db.Migrator().HasIndex(&entity.User{}, "Email"))
+
+	assert.NoError(t, db.Exec("UPDATE users SET email = 'johnny@j.com' WHERE id = '2'").Error)
+	assert.NoError(t, Migrate(db))
+	assert.True(t, db.Migrator().HasIndex(&entity.User{}, "Email"))
+
+	// Com o índice criado a conferência não roda mais
+	assert.NoError(t, Migrate(db))
+}
+
+func TestMigrate_PublishesLegacyProducts(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// Produto de uma versão anterior ao fluxo de publicação
+	assert.NoError(t, db.Exec("CREATE TABLE products (id text PRIMARY KEY, name text, price real)").Error)
+	assert.NoError(t, db.Exec("INSERT INTO products (id, name, price) VALUES ('1', 'Legacy', 10)").Error)
+	assert.NoError(t, Migrate(db))
+
+	var status string
+	db.Raw("SELECT status FROM products WHERE id = '1'").Scan(&status)
+	assert.Equal(t, entity.StatusPublished, status)
+
+	// Depois da migração um produto gravado sem estado é rascunho, mesmo que o
+	// servidor reinicie
+	assert.NoError(t, db.Exec("INSERT INTO products (id, name, price) VALUES ('2', 'Imported', 10)").Error)
+	assert.NoError(t, Migrate(db))
+	db.Raw("SELECT status FROM products WHERE id = '2'").Scan(&status)
+	assert.Equal(t, entity.StatusDraft, status)
+}
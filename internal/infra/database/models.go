@@ -0,0 +1,25 @@
+package database
+
+import "github.com/otthonleao/go-products.git/internal/entity"
+
+// Models lista as entidades persistidas, na ordem em que devem ser migradas.
+func Models() []interface{} {
+	return []interface{}{
+		&entity.Product{},
+		&productAttributeValue{},
+		&productTag{},
+		&entity.Category{},
+		&entity.ProductVariant{},
+		&entity.ProductImage{},
+		&entity.ProductTransition{},
+		&entity.Cart{},
+		&entity.CartItem{},
+		&entity.Order{},
+		&entity.OrderItem{},
+		&entity.Promotion{},
+		&entity.User{},
+		&entity.Organization{},
+		&entity.Membership{},
+		&entity.IdempotencyKey{},
+	}
+}
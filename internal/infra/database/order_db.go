@@ -0,0 +1,309 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/tracing"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// OrderFilter restringe a listagem de pedidos. Campos vazios não filtram.
+type OrderFilter struct {
+	Statuses []string
+	UserID   string
+	// From e To limitam a data de criação, com From inclusivo e To exclusivo
+	From *time.Time
+	To   *time.Time
+}
+
+// Order guarda os pedidos e controla o estoque reservado por eles.
+type Order struct {
+	DB       *gorm.DB
+	TenantID string
+	// ProductCache, quando informado, perde as entradas dos produtos cujo
+	// estoque Place ou Transition alteraram.
+	ProductCache *ProductCache
+	// scopedToTenant garante que um tenant vazio não vire acesso irrestrito.
+	scopedToTenant bool
+}
+
+func NewOrder(db *gorm.DB) *Order {
+	return &Order{
+		DB: db,
+	}
+}
+
+// ForTenant retorna uma cópia do repositório restrita aos pedidos feitos à
+// organização informada.
+func (o *Order) ForTenant(organizationID string) OrderInterface {
+	return &Order{
+		DB:             o.DB,
+		TenantID:       organizationID,
+		ProductCache:   o.ProductCache,
+		scopedToTenant: true,
+	}
+}
+
+// WithContext retorna uma cópia do repositório que propaga ctx para as consultas.
+func (o *Order) WithContext(ctx context.Context) OrderInterface {
+	return &Order{
+		DB:             o.DB.WithContext(ctx),
+		TenantID:       o.TenantID,
+		ProductCache:   o.ProductCache,
+		scopedToTenant: o.scopedToTenant,
+	}
+}
+
+func (o *Order) trace(method string) (*gorm.DB, trace.Span) {
+	ctx, span := tracing.Start(o.DB.Statement.Context, "database.Order."+method)
+	return o.DB.WithContext(ctx), span
+}
+
+func (o *Order) scoped(db *gorm.DB) *gorm.DB {
+	if !o.scopedToTenant {
+		return db
+	}
+	return db.Where("organization_id = ?", o.TenantID)
+}
+
+// Place grava o pedido reservando o estoque de cada linha numa única
+// transação: se alguma linha não couber no estoque nada é gravado e o retorno
+// é entity.ErrInsufficientStock. O cupom do pedido é consumido na mesma
+// transação, retornando entity.ErrCouponExhausted quando o limite de usos já
+// foi atingido. Com cartID o carrinho de origem também é removido; se ele já
+// não existir o retorno é ErrConflict.
+func (o *Order) Place(order *entity.Order, cartID string) (err error) {
+	db, span := o.trace("Place")
+	defer func() { tracing.End(span, err) }()
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, item := range order.Items {
+			if err := reserveStock(tx, order, item); err != nil {
+				return err
+			}
+		}
+		if err := redeemCoupon(tx, order); err != nil {
+			return err
+		}
+
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&order.Items).Error; err != nil {
+			return err
+		}
+
+		if cartID == "" {
+			return nil
+		}
+		if err := tx.Where("cart_id = ?", cartID).Delete(&entity.CartItem{}).Error; err != nil {
+			return err
+		}
+		// Se outra requisição já converteu o carrinho, este pedido é desfeito
+		result := tx.Delete(&entity.Cart{}, "id = ?", cartID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: cart was already checked out", ErrConflict)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	o.invalidateProducts(order)
+	return nil
+}
+
+// invalidateProducts remove do cache os produtos das linhas do pedido, depois
+// que a transação que mexeu no estoque deles foi confirmada.
+func (o *Order) invalidateProducts(order *entity.Order) {
+	if o.ProductCache == nil {
+		return
+	}
+	for _, item := range order.Items {
+		o.ProductCache.Invalidate(item.ProductID.String())
+	}
+}
+
+// reserveStock baixa a quantidade da linha do estoque da variante ou do
+// produto. A condição no UPDATE impede que pedidos concorrentes deixem o
+// estoque negativo; produtos sem estoque controlado (NULL) não mudam.
+func reserveStock(tx *gorm.DB, order *entity.Order, item entity.OrderItem) error {
+	var result *gorm.DB
+	if item.VariantID != nil {
+		result = tx.Model(&entity.ProductVariant{}).
+			Where("id = ? AND product_id = ? AND organization_id = ? AND stock >= ?", item.VariantID, item.ProductID, order.OrganizationID, item.Quantity).
+			Updates(map[string]interface{}{"stock": gorm.Expr("stock - ?", item.Quantity), "updated_at": order.CreatedAt})
+	} else {
+		result = tx.Model(&entity.Product{}).
+			Where("id = ? AND organization_id = ? AND (stock IS NULL OR stock >= ?)", item.ProductID, order.OrganizationID, item.Quantity).
+			Updates(map[string]interface{}{"stock": gorm.Expr("stock - ?", item.Quantity), "updated_at": order.CreatedAt})
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", entity.ErrInsufficientStock, item.Name)
+	}
+	return nil
+}
+
+// releaseStock devolve ao estoque a quantidade da linha, com as mesmas
+// condições de reserveStock. Produtos ou variantes removidos depois do pedido
+// são ignorados.
+func releaseStock(tx *gorm.DB, order *entity.Order, item entity.OrderItem) error {
+	query := tx.Model(&entity.Product{}).Where("id = ? AND organization_id = ?", item.ProductID, order.OrganizationID)
+	if item.VariantID != nil {
+		query = tx.Model(&entity.ProductVariant{}).Where("id = ? AND product_id = ? AND organization_id = ?", item.VariantID, item.ProductID, order.OrganizationID)
+	}
+	return query.Updates(map[string]interface{}{"stock": gorm.Expr("stock + ?", item.Quantity), "updated_at": order.UpdatedAt}).Error
+}
+
+// redeemCoupon conta um uso do cupom do pedido. Como em reserveStock, a
+// condição no UPDATE impede que pedidos concorrentes passem do limite.
+func redeemCoupon(tx *gorm.DB, order *entity.Order) error {
+	if order.CouponID == nil {
+		return nil
+	}
+	result := tx.Model(&entity.Promotion{}).
+		Where("id = ? AND organization_id = ? AND (usage_limit IS NULL OR usage_count < usage_limit)", order.CouponID, order.OrganizationID).
+		Update("usage_count", gorm.Expr("usage_count + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return entity.ErrCouponExhausted
+	}
+	return nil
+}
+
+// releaseCoupon devolve o uso do cupom de um pedido cancelado.
+func releaseCoupon(tx *gorm.DB, order *entity.Order) error {
+	if order.CouponID == nil {
+		return nil
+	}
+	return tx.Model(&entity.Promotion{}).
+		Where("id = ? AND usage_count > 0", order.CouponID).
+		Update("usage_count", gorm.Expr("usage_count - 1")).Error
+}
+
+// FindByID carrega o pedido com as linhas.
+func (o *Order) FindByID(id string) (_ *entity.Order, err error) {
+	db, span := o.trace("FindByID")
+	defer func() { tracing.End(span, err) }()
+
+	var order entity.Order
+	if err = o.scoped(db).First(&order, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	if err = loadOrderItems(db, &order); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// FindAll lista os pedidos do filtro, dos mais recentes aos mais antigos, com
+// as linhas de cada um.
+func (o *Order) FindAll(page, limit int, filter OrderFilter) (orders []entity.Order, err error) {
+	db, span := o.trace("FindAll")
+	defer func() { tracing.End(span, err) }()
+
+	query := o.scoped(db)
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	if page != 0 && limit != 0 {
+		query = query.Limit(limit).Offset((page - 1) * limit)
+	}
+	if err = query.Order("created_at desc").Find(&orders).Error; err != nil {
+		return nil, err
+	}
+
+	pointers := make([]*entity.Order, 0, len(orders))
+	for i := range orders {
+		pointers = append(pointers, &orders[i])
+	}
+	return orders, loadOrderItems(db, pointers...)
+}
+
+// loadOrderItems carrega numa única consulta as linhas dos pedidos.
+func loadOrderItems(db *gorm.DB, orders ...*entity.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	byOrder := make(map[string]*entity.Order, len(orders))
+	ids := make([]string, 0, len(orders))
+	for _, order := range orders {
+		order.Items = []entity.OrderItem{}
+		byOrder[order.ID.String()] = order
+		ids = append(ids, order.ID.String())
+	}
+
+	var items []entity.OrderItem
+	if err := db.Where("order_id IN ?", ids).Order("position").Find(&items).Error; err != nil {
+		return err
+	}
+	for _, item := range items {
+		order := byOrder[item.OrderID.String()]
+		order.Items = append(order.Items, item)
+	}
+	return nil
+}
+
+// Transition grava o novo estado do pedido. Como em Product.Transition, a
+// atualização só acontece se o estado no banco ainda for from, e o
+// cancelamento devolve o estoque e o uso do cupom na mesma transação.
+func (o *Order) Transition(order *entity.Order, from string) (err error) {
+	db, span := o.trace("Transition")
+	defer func() { tracing.End(span, err) }()
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		result := o.scoped(tx.Model(&entity.Order{})).
+			Where("id = ? AND status = ?", order.ID, from).
+			Updates(map[string]interface{}{
+				"status":       order.Status,
+				"paid_at":      order.PaidAt,
+				"fulfilled_at": order.FulfilledAt,
+				"cancelled_at": order.CancelledAt,
+				"refunded_at":  order.RefundedAt,
+				"updated_at":   order.UpdatedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: order status was changed by another request", ErrConflict)
+		}
+
+		if !order.ReleasesStock() {
+			return nil
+		}
+		for _, item := range order.Items {
+			if err := releaseStock(tx, order, item); err != nil {
+				return err
+			}
+		}
+		return releaseCoupon(tx, order)
+	})
+	if err != nil || !order.ReleasesStock() {
+		return err
+	}
+	o.invalidateProducts(order)
+	return nil
+}
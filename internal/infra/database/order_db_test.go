@@ -0,0 +1,118 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestOrderLifecycle(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	db.AutoMigrate(Models()...)
+
+	organizationID := entityPkg.NewID()
+	// Os produtos são lidos pelo cache para conferir que os pedidos o invalidam
+	cache := NewProductCache(NewProduct(db), time.Minute, 100)
+	productDB := cache.ForTenant(organizationID.String())
+	stock := 3
+	pen, _ := entity.NewProduct("Pen", 2.5)
+	pen.Stock = &stock
+	assert.NoError(t, productDB.Create(pen))
+	untracked, _ := entity.NewProduct("Service", 10)
+	assert.NoError(t, productDB.Create(untracked))
+	shirt, _ := entity.NewProduct("Shirt", 20)
+	shirt.OptionAxes = []string{"size"}
+	assert.NoError(t, productDB.Create(shirt))
+	variant, _ := entity.NewProductVariant(shirt, map[string]string{"size": "M"}, "", nil, 1)
+	assert.NoError(t, NewProductVariant(db).Create(variant))
+
+	cart, _ := entity.NewCart(organizationID, nil, time.Hour)
+	cartDB := NewCart(db)
+	assert.NoError(t, cartDB.Create(cart))
+
+	orderDB := NewOrder(db)
+	orderDB.ProductCache = cache
+	userID := entityPkg.NewID()
+	newOrder := func(lines ...*entity.OrderItem) *entity.Order {
+		items := make([]entity.OrderItem, 0, len(lines))
+		for _, line := range lines {
+			items = append(items, *line)
+		}
+		order, err := entity.NewOrder(organizationID, userID, items)
+		assert.NoError(t, err)
+		return order
+	}
+
+	penItem, _ := entity.NewOrderItem(pen, nil, 2)
+	serviceItem, _ := entity.NewOrderItem(untracked, nil, 5)
+	shirtItem, _ := entity.NewOrderItem(shirt, variant, 1)
+	order := newOrder(penItem, serviceItem, shirtItem)
+	productDB.FindByID(pen.ID.String())
+	assert.NoError(t, orderDB.Place(order, cart.ID.String()))
+
+	// O carrinho de origem é removido junto
+	_, err = cartDB.FindByID(cart.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// Um carrinho já convertido em pedido não gera outro pedido
+	penItem, _ = entity.NewOrderItem(pen, nil, 1)
+	duplicated := newOrder(penItem)
+	assert.ErrorIs(t, orderDB.Place(duplicated, cart.ID.String()), ErrConflict)
+	_, err = orderDB.FindByID(duplicated.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	found, _ := productDB.FindByID(pen.ID.String())
+	assert.Equal(t, 1, *found.Stock)
+	found, _ = productDB.FindByID(untracked.ID.String())
+	assert.Nil(t, found.Stock)
+	foundVariant, _ := NewProductVariant(db).FindByID(shirt.ID.String(), variant.ID.String())
+	assert.Equal(t, 0, foundVariant.Stock)
+
+	// Sem estoque nada é gravado, nem a baixa das linhas anteriores
+	penItem, _ = entity.NewOrderItem(pen, nil, 1)
+	shirtItem, _ = entity.NewOrderItem(shirt, variant, 1)
+	rejected := newOrder(penItem, shirtItem)
+	assert.ErrorIs(t, orderDB.Place(rejected, ""), entity.ErrInsufficientStock)
+	found, _ = productDB.FindByID(pen.ID.String())
+	assert.Equal(t, 1, *found.Stock)
+	_, err = orderDB.FindByID(rejected.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	orderFound, err := orderDB.FindByID(order.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, orderFound.Items, 3)
+	assert.Equal(t, "Pen", orderFound.Items[0].Name)
+	assert.Equal(t, order.Total, orderFound.Total)
+
+	// O cancelamento devolve o estoque; repetir a mudança é um conflito
+	from, _ := orderFound.Transition(entity.OrderCancelled, entity.RoleBuyer)
+	assert.NoError(t, orderDB.Transition(orderFound, from))
+	assert.ErrorIs(t, orderDB.Transition(orderFound, from), ErrConflict)
+	found, _ = productDB.FindByID(pen.ID.String())
+	assert.Equal(t, 3, *found.Stock)
+	foundVariant, _ = NewProductVariant(db).FindByID(shirt.ID.String(), variant.ID.String())
+	assert.Equal(t, 1, foundVariant.Stock)
+
+	// Filtros e escopo da organização
+	penItem, _ = entity.NewOrderItem(pen, nil, 1)
+	assert.NoError(t, orderDB.Place(newOrder(penItem), ""))
+	orders, err := orderDB.ForTenant(organizationID.String()).FindAll(1, 10, OrderFilter{Statuses: []string{entity.OrderPending}})
+	assert.NoError(t, err)
+	assert.Len(t, orders, 1)
+	assert.Len(t, orders[0].Items, 1)
+	orders, _ = orderDB.FindAll(0, 0, OrderFilter{UserID: userID.String()})
+	assert.Len(t, orders, 2)
+	future := time.Now().Add(time.Hour)
+	orders, _ = orderDB.FindAll(0, 0, OrderFilter{From: &future})
+	assert.Len(t, orders, 0)
+	orders, _ = orderDB.ForTenant(entityPkg.NewID().String()).FindAll(0, 0, OrderFilter{})
+	assert.Len(t, orders, 0)
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/tracing"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+type Organization struct {
+	DB *gorm.DB
+}
+
+func NewOrganization(db *gorm.DB) *Organization {
+	return &Organization{
+		DB: db,
+	}
+}
+
+// WithContext retorna uma cópia do repositório que propaga ctx para as consultas.
+func (o *Organization) WithContext(ctx context.Context) OrganizationInterface {
+	return &Organization{
+		DB: o.DB.WithContext(ctx),
+	}
+}
+
+func (o *Organization) trace(method string) (*gorm.DB, trace.Span) {
+	ctx, span := tracing.Start(o.DB.Statement.Context, "database.Organization."+method)
+	return o.DB.WithContext(ctx), span
+}
+
+func (o *Organization) Create(organization *entity.Organization) (err error) {
+	db, span := o.trace("Create")
+	defer func() { tracing.End(span, err) }()
+
+	return db.Create(organization).Error
+}
+
+// CreateWithOwner grava a organização e a participação do dono numa única
+// transação, para que nenhuma organização fique sem dono.
+func (o *Organization) CreateWithOwner(organization *entity.Organization, owner *entity.Membership) (err error) {
+	db, span := o.trace("CreateWithOwner")
+	defer func() { tracing.End(span, err) }()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(organization).Error; err != nil {
+			return err
+		}
+		return tx.Create(owner).Error
+	})
+}
+
+func (o *Organization) FindByID(id string) (_ *entity.Organization, err error) {
+	db, span := o.trace("FindByID")
+	defer func() { tracing.End(span, err) }()
+
+	var organization entity.Organization
+	err = db.First(&organization, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &organization, nil
+}
+
+func (o *Organization) AddMember(membership *entity.Membership) (err error) {
+	db, span := o.trace("AddMember")
+	defer func() { tracing.End(span, err) }()
+
+	return db.Create(membership).Error
+}
+
+func (o *Organization) FindMembership(userID, organizationID string) (_ *entity.Membership, err error) {
+	db, span := o.trace("FindMembership")
+	defer func() { tracing.End(span, err) }()
+
+	var membership entity.Membership
+	err = db.Where("user_id = ? AND organization_id = ?", userID, organizationID).First(&membership).Error
+	if err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+func (o *Organization) FindMembershipsByUser(userID string) (memberships []entity.Membership, err error) {
+	db, span := o.trace("FindMembershipsByUser")
+	defer func() { tracing.End(span, err) }()
+
+	err = db.Where("user_id = ?", userID).Order("created_at asc").Find(&memberships).Error
+	return memberships, err
+}
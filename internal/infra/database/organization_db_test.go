@@ -0,0 +1,94 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCreateOrganization(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	db.AutoMigrate(&entity.Organization{})
+
+	organization, err := entity.NewOrganization("Acme")
+	assert.NoError(t, err)
+
+	organizationDB := NewOrganization(db)
+	err = organizationDB.Create(organization)
+	assert.NoError(t, err)
+
+	organizationFound, err := organizationDB.FindByID(organization.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, organization.ID, organizationFound.ID)
+	assert.Equal(t, organization.Name, organizationFound.Name)
+}
+
+func TestOrganizationMemberships(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	db.AutoMigrate(&entity.Organization{}, &entity.Membership{})
+
+	user, _ := entity.NewUser("Otthon Leao", "test@mail.com", "123456")
+	acme, _ := entity.NewOrganization("Acme")
+	globex, _ := entity.NewOrganization("Globex")
+
+	organizationDB := NewOrganization(db)
+	assert.NoError(t, organizationDB.Create(acme))
+	assert.NoError(t, organizationDB.Create(globex))
+
+	membership, _ := entity.NewMembership(user.ID, acme.ID, entity.RoleOwner)
+	assert.NoError(t, organizationDB.AddMember(membership))
+
+	// O mesmo usuário não pode entrar duas vezes na mesma organização
+	duplicated, _ := entity.NewMembership(user.ID, acme.ID, entity.RoleMember)
+	assert.Error(t, organizationDB.AddMember(duplicated))
+
+	membershipFound, err := organizationDB.FindMembership(user.ID.String(), acme.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, entity.RoleOwner, membershipFound.Role)
+
+	_, err = organizationDB.FindMembership(user.ID.String(), globex.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	memberships, err := organizationDB.FindMembershipsByUser(user.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, memberships, 1)
+	assert.Equal(t, acme.ID, memberships[0].OrganizationID)
+}
+
+func TestCreateOrganizationWithOwner(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	db.AutoMigrate(&entity.Organization{}, &entity.Membership{})
+
+	user, _ := entity.NewUser("Otthon Leao", "test@mail.com", "123456")
+	acme, _ := entity.NewOrganization("Acme")
+	owner, _ := entity.NewMembership(user.ID, acme.ID, entity.RoleOwner)
+
+	organizationDB := NewOrganization(db)
+	assert.NoError(t, organizationDB.CreateWithOwner(acme, owner))
+	membership, err := organizationDB.FindMembership(user.ID.String(), acme.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, entity.RoleOwner, membership.Role)
+
+	// Se a participação falha a organização também não é gravada
+	globex, _ := entity.NewOrganization("Globex")
+	duplicated := *owner
+	duplicated.OrganizationID = globex.ID
+	assert.Error(t, organizationDB.CreateWithOwner(globex, &duplicated))
+	_, err = organizationDB.FindByID(globex.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
@@ -0,0 +1,202 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+)
+
+// ProductCache é um cache em memória, de leitura direta (read-through), na frente
+// de ProductInterface.FindByID. Update, UpdateTags, Touch e Delete invalidam a entrada do produto.
+// O cache é por processo: com várias instâncias, uma alteração feita em outra
+// instância só aparece depois do TTL.
+type ProductCache struct {
+	next     ProductInterface
+	store    *productCacheStore
+	TenantID string
+	// scopedToTenant segue a mesma regra do repositório: tenant vazio não vê nada.
+	scopedToTenant bool
+}
+
+type productCacheEntry struct {
+	product   entity.Product
+	expiresAt time.Time
+}
+
+type productCacheStore struct {
+	mutex      sync.Mutex
+	entries    map[string]productCacheEntry
+	ttl        time.Duration
+	maxEntries int
+	// generation muda a cada invalidação para que uma leitura iniciada antes
+	// de uma alteração não grave o valor antigo no cache.
+	generation uint64
+}
+
+func NewProductCache(next ProductInterface, ttl time.Duration, maxEntries int) *ProductCache {
+	return &ProductCache{
+		next: next,
+		store: &productCacheStore{
+			entries:    make(map[string]productCacheEntry),
+			ttl:        ttl,
+			maxEntries: maxEntries,
+		},
+	}
+}
+
+func (c *ProductCache) ForTenant(organizationID string) ProductInterface {
+	return &ProductCache{
+		next:           c.next.ForTenant(organizationID),
+		store:          c.store,
+		TenantID:       organizationID,
+		scopedToTenant: true,
+	}
+}
+
+func (c *ProductCache) WithContext(ctx context.Context) ProductInterface {
+	return &ProductCache{
+		next:           c.next.WithContext(ctx),
+		store:          c.store,
+		TenantID:       c.TenantID,
+		scopedToTenant: c.scopedToTenant,
+	}
+}
+
+func (c *ProductCache) Create(product *entity.Product) error {
+	return c.next.Create(product)
+}
+
+func (c *ProductCache) CreateMany(products []*entity.Product) error {
+	return c.next.CreateMany(products)
+}
+
+func (c *ProductCache) FindAll(page, limit int, sort string) ([]entity.Product, error) {
+	return c.next.FindAll(page, limit, sort)
+}
+
+func (c *ProductCache) FindAllFiltered(page, limit int, sort string, filter ProductFilter) ([]entity.Product, error) {
+	return c.next.FindAllFiltered(page, limit, sort, filter)
+}
+
+func (c *ProductCache) Facets(filter ProductFilter, options FacetOptions) (*entity.ProductFacets, error) {
+	return c.next.Facets(filter, options)
+}
+
+func (c *ProductCache) FindByID(id string) (*entity.Product, error) {
+	product, ok, generation := c.store.get(id)
+	// Um produto de outra organização no cache é tratado como ausente e a busca
+	// segue para o repositório, que aplica o filtro de tenant.
+	if ok && (!c.scopedToTenant || product.OrganizationID.String() == c.TenantID) {
+		return product, nil
+	}
+
+	product, err := c.next.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	c.store.set(id, product, generation)
+	return product, nil
+}
+
+func (c *ProductCache) FindBySKU(sku string) (*entity.Product, error) {
+	return c.next.FindBySKU(sku)
+}
+
+func (c *ProductCache) FindByBarcode(code string) (*entity.Product, error) {
+	return c.next.FindByBarcode(code)
+}
+
+func (c *ProductCache) Update(product *entity.Product) error {
+	defer c.store.invalidate(product.ID.String())
+	return c.next.Update(product)
+}
+
+func (c *ProductCache) UpdateTags(product *entity.Product) error {
+	defer c.store.invalidate(product.ID.String())
+	return c.next.UpdateTags(product)
+}
+
+func (c *ProductCache) Touch(id string, at time.Time) error {
+	defer c.store.invalidate(id)
+	return c.next.Touch(id, at)
+}
+
+func (c *ProductCache) Delete(id string) error {
+	defer c.store.invalidate(id)
+	return c.next.Delete(id)
+}
+
+func (c *ProductCache) Transition(product *entity.Product, transition *entity.ProductTransition) error {
+	defer c.store.invalidate(product.ID.String())
+	return c.next.Transition(product, transition)
+}
+
+func (c *ProductCache) FindTransitions(productID string) ([]entity.ProductTransition, error) {
+	return c.next.FindTransitions(productID)
+}
+
+// Invalidate remove os produtos do cache, para alterações feitas no banco por
+// fora deste repositório, como a baixa de estoque dos pedidos.
+func (c *ProductCache) Invalidate(ids ...string) {
+	for _, id := range ids {
+		c.store.invalidate(id)
+	}
+}
+
+// get retorna uma cópia do produto em cache e a geração atual do cache.
+func (s *productCacheStore) get(id string) (*entity.Product, bool, uint64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, ok := s.entries[id]
+	if !ok {
+		return nil, false, s.generation
+	}
+	if !time.Now().Before(entry.expiresAt) {
+		delete(s.entries, id)
+		return nil, false, s.generation
+	}
+	product := entry.product
+	return &product, true, s.generation
+}
+
+func (s *productCacheStore) set(id string, product *entity.Product, generation uint64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if generation != s.generation {
+		return
+	}
+	if _, ok := s.entries[id]; !ok && s.maxEntries > 0 && len(s.entries) >= s.maxEntries {
+		s.evict()
+	}
+	s.entries[id] = productCacheEntry{product: *product, expiresAt: time.Now().Add(s.ttl)}
+}
+
+func (s *productCacheStore) invalidate(id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.generation++
+	delete(s.entries, id)
+}
+
+// evict remove as entradas vencidas e, se o cache continuar cheio, uma entrada
+// qualquer. Deve ser chamado com o mutex travado.
+func (s *productCacheStore) evict() {
+	now := time.Now()
+	for id, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, id)
+		}
+	}
+	if len(s.entries) < s.maxEntries {
+		return
+	}
+	for id := range s.entries {
+		delete(s.entries, id)
+		return
+	}
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newProductCache(t *testing.T, ttl time.Duration) (*gorm.DB, *ProductCache) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductVariant{}, &entity.ProductImage{}, &entity.ProductTransition{})
+	return db, NewProductCache(NewProduct(db), ttl, 100)
+}
+
+func TestProductCache_ReadThrough(t *testing.T) {
+	db, cache := newProductCache(t, time.Minute)
+	tenantID := entityPkg.NewID().String()
+	products := cache.ForTenant(tenantID)
+
+	product, _ := entity.NewProduct("Product 1", 10)
+	assert.NoError(t, products.Create(product))
+
+	productFound, err := products.FindByID(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "Product 1", productFound.Name)
+
+	// Alterações feitas por fora do cache só aparecem depois do TTL
+	db.Model(&entity.Product{}).Where("id = ?", product.ID).Update("name", "Changed outside")
+	productFound, err = products.FindByID(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "Product 1", productFound.Name)
+
+	// Alterar a cópia devolvida não altera o cache
+	productFound.Name = "Mutated"
+	productFound, _ = products.FindByID(product.ID.String())
+	assert.Equal(t, "Product 1", productFound.Name)
+}
+
+func TestProductCache_UpdateAndDeleteInvalidate(t *testing.T) {
+	_, cache := newProductCache(t, time.Minute)
+	tenantID := entityPkg.NewID().String()
+	products := cache.ForTenant(tenantID)
+
+	product, _ := entity.NewProduct("Product 1", 10)
+	assert.NoError(t, products.Create(product))
+	products.FindByID(product.ID.String())
+
+	product.Name = "Product 2"
+	assert.NoError(t, products.Update(product))
+
+	productFound, err := products.FindByID(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "Product 2", productFound.Name)
+
+	at := productFound.UpdatedAt.Add(time.Hour)
+	assert.NoError(t, products.Touch(product.ID.String(), at))
+	productFound, _ = products.FindByID(product.ID.String())
+	assert.True(t, productFound.UpdatedAt.Equal(at))
+
+	assert.NoError(t, products.Delete(product.ID.String()))
+	_, err = products.FindByID(product.ID.String())
+	assert.Error(t, err)
+}
+
+func TestProductCache_TenantIsolation(t *testing.T) {
+	_, cache := newProductCache(t, time.Minute)
+	acmeID := entityPkg.NewID().String()
+
+	product, _ := entity.NewProduct("Acme Product", 10)
+	assert.NoError(t, cache.ForTenant(acmeID).Create(product))
+	_, err := cache.ForTenant(acmeID).FindByID(product.ID.String())
+	assert.NoError(t, err)
+
+	_, err = cache.ForTenant(entityPkg.NewID().String()).FindByID(product.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	_, err = cache.ForTenant("").FindByID(product.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestProductCache_Expiration(t *testing.T) {
+	db, cache := newProductCache(t, time.Millisecond)
+	tenantID := entityPkg.NewID().String()
+	products := cache.ForTenant(tenantID)
+
+	product, _ := entity.NewProduct("Product 1", 10)
+	assert.NoError(t, products.Create(product))
+	products.FindByID(product.ID.String())
+
+	db.Model(&entity.Product{}).Where("id = ?", product.ID).Update("name", "Changed outside")
+	time.Sleep(5 * time.Millisecond)
+
+	productFound, err := products.FindByID(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "Changed outside", productFound.Name)
+}
@@ -1,12 +1,25 @@
 package database
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/tracing"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
 )
 
+// productUniqueFields são as colunas únicas por organização citadas nos conflitos.
+var productUniqueFields = []string{"sku", "barcode"}
+
 type Product struct {
-	DB *gorm.DB
+	DB       *gorm.DB
+	TenantID string
+	// scopedToTenant garante que um tenant vazio não vire acesso irrestrito.
+	scopedToTenant bool
 }
 
 func NewProduct(db *gorm.DB) *Product {
@@ -15,48 +28,302 @@ func NewProduct(db *gorm.DB) *Product {
 	}
 }
 
-func (p *Product) Create(product *entity.Product) error {
-	return p.DB.Create(product).Error
+// ForTenant retorna uma cópia do repositório em que todas as consultas ficam
+// restritas à organização informada.
+func (p *Product) ForTenant(organizationID string) ProductInterface {
+	return &Product{
+		DB:             p.DB,
+		TenantID:       organizationID,
+		scopedToTenant: true,
+	}
+}
+
+// WithContext retorna uma cópia do repositório que propaga ctx (cancelamento e
+// tracing) para as consultas.
+func (p *Product) WithContext(ctx context.Context) ProductInterface {
+	return &Product{
+		DB:             p.DB.WithContext(ctx),
+		TenantID:       p.TenantID,
+		scopedToTenant: p.scopedToTenant,
+	}
+}
+
+// trace abre o span do método e devolve o banco já associado a ele.
+func (p *Product) trace(method string) (*gorm.DB, trace.Span) {
+	ctx, span := tracing.Start(p.DB.Statement.Context, "database.Product."+method)
+	return p.DB.WithContext(ctx), span
+}
+
+// scoped aplica o filtro de tenant quando o repositório está restrito a uma organização.
+func (p *Product) scoped(db *gorm.DB) *gorm.DB {
+	if !p.scopedToTenant {
+		return db
+	}
+	return db.Where("organization_id = ?", p.TenantID)
+}
+
+func (p *Product) Create(product *entity.Product) (err error) {
+	db, span := p.trace("Create")
+	defer func() { tracing.End(span, err) }()
+
+	if p.scopedToTenant {
+		organizationID, err := entityPkg.ParseID(p.TenantID)
+		if err != nil {
+			return err
+		}
+		product.OrganizationID = organizationID
+	}
+	return translateConflict(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		if len(product.Attributes) > 0 {
+			if err := replaceAttributeValues(tx, product); err != nil {
+				return err
+			}
+		}
+		if len(product.Tags) == 0 {
+			return nil
+		}
+		return replaceTags(tx, product)
+	}), productUniqueFields...)
+}
+
+// CreateMany grava todos os produtos numa única transação: ou todos são criados
+// ou nenhum.
+func (p *Product) CreateMany(products []*entity.Product) (err error) {
+	db, span := p.trace("CreateMany")
+	defer func() { tracing.End(span, err) }()
+
+	if p.scopedToTenant {
+		organizationID, err := entityPkg.ParseID(p.TenantID)
+		if err != nil {
+			return err
+		}
+		for _, product := range products {
+			product.OrganizationID = organizationID
+		}
+	}
+	return translateConflict(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(products, 100).Error; err != nil {
+			return err
+		}
+
+		var withAttributes, withTags []*entity.Product
+		for _, product := range products {
+			if len(product.Attributes) > 0 {
+				withAttributes = append(withAttributes, product)
+			}
+			if len(product.Tags) > 0 {
+				withTags = append(withTags, product)
+			}
+		}
+		if len(withAttributes) > 0 {
+			if err := replaceAttributeValues(tx, withAttributes...); err != nil {
+				return err
+			}
+		}
+		if len(withTags) == 0 {
+			return nil
+		}
+		return replaceTags(tx, withTags...)
+	}), productUniqueFields...)
 }
 
-func (p *Product) FindAll(page, limit int, sort string) ([]entity.Product, error) {
-	var products []entity.Product
-	var err error
+func (p *Product) FindAll(page, limit int, sort string) (products []entity.Product, err error) {
+	db, span := p.trace("FindAll")
+	defer func() { tracing.End(span, err) }()
 
+	return p.findAll(db, page, limit, sort, ProductFilter{})
+}
+
+func (p *Product) FindAllFiltered(page, limit int, sort string, filter ProductFilter) (products []entity.Product, err error) {
+	db, span := p.trace("FindAllFiltered")
+	defer func() { tracing.End(span, err) }()
+
+	return p.findAll(db, page, limit, sort, filter)
+}
+
+func (p *Product) findAll(db *gorm.DB, page, limit int, sort string, filter ProductFilter) (products []entity.Product, err error) {
 	if sort != "" && sort != "asc" && sort != "desc" {
 		sort = "asc"
 	}
 
+	query := applyProductFilter(db, p.scoped(db), filter)
 	if page != 0 && limit != 0 {
-		err = p.DB.Limit(limit).Offset((page - 1) * limit).Order("created_at " + sort).Find(&products).Error
+		err = query.Limit(limit).Offset((page - 1) * limit).Order("created_at " + sort).Find(&products).Error
 	} else {
-		err = p.DB.Order("created_at " + sort).Find(&products).Error
+		err = query.Order("created_at " + sort).Find(&products).Error
 	}
 
 	return products, err
 }
 
-func (p *Product) FindByID(id string) (*entity.Product, error) {
+func (p *Product) FindByID(id string) (_ *entity.Product, err error) {
+	db, span := p.trace("FindByID")
+	defer func() { tracing.End(span, err) }()
+
+	var product entity.Product
+	err = p.scoped(db).First(&product, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// FindBySKU busca o produto pelo SKU dentro da organização.
+func (p *Product) FindBySKU(sku string) (_ *entity.Product, err error) {
+	db, span := p.trace("FindBySKU")
+	defer func() { tracing.End(span, err) }()
+
 	var product entity.Product
-	err := p.DB.First(&product, "id = ?", id).Error
+	err = p.scoped(db).First(&product, "sku = ?", sku).Error
 	if err != nil {
 		return nil, err
 	}
 	return &product, nil
 }
 
-func (p *Product) Update(product *entity.Product) error {
-	_, err := p.FindByID(product.ID.String())
+// FindByBarcode busca o produto pelo código de barras (EAN/UPC/GTIN) dentro da organização.
+func (p *Product) FindByBarcode(code string) (_ *entity.Product, err error) {
+	db, span := p.trace("FindByBarcode")
+	defer func() { tracing.End(span, err) }()
+
+	var product entity.Product
+	err = p.scoped(db).First(&product, "barcode = ?", code).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (p *Product) Update(product *entity.Product) (err error) {
+	db, span := p.trace("Update")
+	defer func() { tracing.End(span, err) }()
+
+	current, err := p.WithContext(db.Statement.Context).FindByID(product.ID.String())
 	if err != nil {
 		return err
 	}
-	return p.DB.Save(product).Error
+	// O tenant de um produto nunca muda, independente do que vier no corpo da requisição.
+	product.OrganizationID = current.OrganizationID
+	return translateConflict(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(product).Error; err != nil {
+			return err
+		}
+		if len(current.Attributes) > 0 || len(product.Attributes) > 0 {
+			if err := replaceAttributeValues(tx, product); err != nil {
+				return err
+			}
+		}
+		if len(current.Tags) == 0 && len(product.Tags) == 0 {
+			return nil
+		}
+		return replaceTags(tx, product)
+	}), productUniqueFields...)
 }
 
-func (p *Product) Delete(id string) error {
-	product, err := p.FindByID(id)
+// UpdateTags grava apenas as tags e o updated_at do produto e refaz o índice de
+// tags, sem regravar os demais campos a partir de uma cópia desatualizada.
+func (p *Product) UpdateTags(product *entity.Product) (err error) {
+	db, span := p.trace("UpdateTags")
+	defer func() { tracing.End(span, err) }()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := p.scoped(tx.Model(product)).Select("tags", "updated_at").Updates(product)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return replaceTags(tx, product)
+	})
+}
+
+// Touch grava apenas o updated_at, sem regravar o produto a partir de uma
+// cópia que pode estar desatualizada.
+func (p *Product) Touch(id string, at time.Time) (err error) {
+	db, span := p.trace("Touch")
+	defer func() { tracing.End(span, err) }()
+
+	result := p.scoped(db.Model(&entity.Product{})).Where("id = ?", id).Update("updated_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (p *Product) Delete(id string) (err error) {
+	db, span := p.trace("Delete")
+	defer func() { tracing.End(span, err) }()
+
+	product, err := p.WithContext(db.Statement.Context).FindByID(id)
 	if err != nil {
 		return err
 	}
-	return p.DB.Delete(product).Error
+	// Variantes, imagens, atributos, tags e histórico não existem sem o produto. Os arquivos das imagens
+	// ficam no storage e são removidos por quem chama.
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", product.ID).Delete(&entity.ProductVariant{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&entity.ProductImage{}).Error; err != nil {
+			return err
+		}
+		if len(product.Attributes) > 0 {
+			if err := tx.Where("product_id = ?", product.ID).Delete(&productAttributeValue{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(product.Tags) > 0 {
+			if err := tx.Where("product_id = ?", product.ID).Delete(&productTag{}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&entity.ProductTransition{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(product).Error
+	})
+}
+
+// Transition grava o novo estado do produto e o registro da mudança numa
+// transação. A atualização só acontece se o estado no banco ainda for
+// transition.From; caso contrário outra requisição mudou o estado antes e o
+// retorno é ErrConflict.
+func (p *Product) Transition(product *entity.Product, transition *entity.ProductTransition) (err error) {
+	db, span := p.trace("Transition")
+	defer func() { tracing.End(span, err) }()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := p.scoped(tx.Model(&entity.Product{})).
+			Where("id = ? AND status = ?", product.ID, transition.From).
+			Updates(map[string]interface{}{
+				"status":            product.Status,
+				"status_changed_at": product.StatusChangedAt,
+				"published_at":      product.PublishedAt,
+				"updated_at":        product.UpdatedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: product status was changed by another request", ErrConflict)
+		}
+		return tx.Create(transition).Error
+	})
+}
+
+// FindTransitions retorna o histórico de estados do produto, do mais antigo ao
+// mais recente.
+func (p *Product) FindTransitions(productID string) (transitions []entity.ProductTransition, err error) {
+	db, span := p.trace("FindTransitions")
+	defer func() { tracing.End(span, err) }()
+
+	err = p.scoped(db).Where("product_id = ?", productID).Order("created_at").Find(&transitions).Error
+	return transitions, err
 }
@@ -1,12 +1,19 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/tracing"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
@@ -113,7 +120,7 @@ func TestUpdateProduct(t *testing.T) {
 	assert.Equal(t, product.Price, productFound.Price)
 }
 
-func TestDeleteProduct(t *testing.T) {
+func TestTouchProduct(t *testing.T) {
 	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
 	if err != nil {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
@@ -121,6 +128,34 @@ func TestDeleteProduct(t *testing.T) {
 
 	db.AutoMigrate(&entity.Product{})
 
+	tenantID := entityPkg.NewID().String()
+	productDB := NewProduct(db).ForTenant(tenantID)
+	product, err := entity.NewProduct("Product Test to Touch", 10.00)
+	assert.NoError(t, err)
+	assert.NoError(t, productDB.Create(product))
+
+	// Só o updated_at muda, mesmo que a cópia em memória esteja desatualizada
+	db.Model(&entity.Product{}).Where("id = ?", product.ID).Update("name", "Renamed")
+	at := product.UpdatedAt.Add(time.Hour)
+	assert.NoError(t, productDB.Touch(product.ID.String(), at))
+
+	productFound, err := productDB.FindByID(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "Renamed", productFound.Name)
+	assert.True(t, productFound.UpdatedAt.Equal(at))
+
+	otherTenant := NewProduct(db).ForTenant(entityPkg.NewID().String())
+	assert.ErrorIs(t, otherTenant.Touch(product.ID.String(), at), gorm.ErrRecordNotFound)
+}
+
+func TestDeleteProduct(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	db.AutoMigrate(&entity.Product{}, &entity.ProductVariant{}, &entity.ProductImage{}, &entity.ProductTransition{})
+
 	product, err := entity.NewProduct("Product Test to Delete", 10.00)
 	assert.NoError(t, err)
 	db.Create(product)
@@ -132,4 +167,257 @@ func TestDeleteProduct(t *testing.T) {
 	productFound, err := productDB.FindByID(product.ID.String())
 	assert.Error(t, err)
 	assert.Nil(t, productFound)
-}
\ No newline at end of file
+}
+
+func TestProductTenantIsolation(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	db.AutoMigrate(&entity.Product{})
+
+	acme := NewProduct(db).ForTenant(entityPkg.NewID().String())
+	globex := NewProduct(db).ForTenant(entityPkg.NewID().String())
+
+	acmeProduct, _ := entity.NewProduct("Acme Product", 10)
+	assert.NoError(t, acme.Create(acmeProduct))
+	globexProduct, _ := entity.NewProduct("Globex Product", 20)
+	assert.NoError(t, globex.Create(globexProduct))
+
+	// Listagem só retorna produtos do próprio tenant
+	products, err := acme.FindAll(0, 0, "asc")
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, acmeProduct.ID, products[0].ID)
+
+	// Leitura, atualização e remoção de produtos de outro tenant não encontram o registro
+	_, err = acme.FindByID(globexProduct.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	globexProduct.Name = "Hijacked"
+	err = acme.Update(globexProduct)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	err = acme.Delete(globexProduct.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	productFound, err := globex.FindByID(globexProduct.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "Globex Product", productFound.Name)
+
+	// Um update não consegue mover o produto para outro tenant
+	acmeProduct.OrganizationID = productFound.OrganizationID
+	assert.NoError(t, acme.Update(acmeProduct))
+	_, err = acme.FindByID(acmeProduct.ID.String())
+	assert.NoError(t, err)
+}
+
+func TestProductEmptyTenantSeesNothing(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	db.AutoMigrate(&entity.Product{})
+
+	product, _ := entity.NewProduct("Acme Product", 10)
+	assert.NoError(t, NewProduct(db).ForTenant(entityPkg.NewID().String()).Create(product))
+
+	noTenant := NewProduct(db).ForTenant("")
+	products, err := noTenant.FindAll(0, 0, "asc")
+	assert.NoError(t, err)
+	assert.Empty(t, products)
+
+	other, _ := entity.NewProduct("Other Product", 10)
+	assert.Error(t, noTenant.Create(other))
+}
+
+func TestProductTracing(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	db.AutoMigrate(&entity.Product{})
+	assert.NoError(t, tracing.InstrumentDB(db, "sqlite"))
+
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	product, _ := entity.NewProduct("Product Test", 10)
+	db.Create(product)
+
+	ctx, parent := tracing.Start(context.Background(), "request")
+	_, err = NewProduct(db).WithContext(ctx).FindByID(product.ID.String())
+	parent.End()
+	assert.NoError(t, err)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	method, query := spans["database.Product.FindByID"], spans["gorm.query"]
+	if !assert.NotNil(t, method) || !assert.NotNil(t, query) {
+		return
+	}
+	assert.Equal(t, parent.SpanContext().SpanID(), method.Parent().SpanID())
+	assert.Equal(t, method.SpanContext().SpanID(), query.Parent().SpanID())
+
+	var statement string
+	for _, attribute := range query.Attributes() {
+		if attribute.Key == "db.query.text" {
+			statement = attribute.Value.AsString()
+		}
+	}
+	assert.Contains(t, statement, "SELECT * FROM `products`")
+}
+
+func TestCreateManyProducts(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	db.AutoMigrate(&entity.Product{})
+
+	tenantID := entityPkg.NewID().String()
+	productDB := NewProduct(db).ForTenant(tenantID)
+
+	var products []*entity.Product
+	for i := 1; i <= 150; i++ {
+		product, _ := entity.NewProduct(fmt.Sprintf("Product %d", i), float64(i))
+		products = append(products, product)
+	}
+	assert.NoError(t, productDB.CreateMany(products))
+
+	productsFound, err := productDB.FindAll(0, 0, "asc")
+	assert.NoError(t, err)
+	assert.Len(t, productsFound, 150)
+	assert.Equal(t, tenantID, productsFound[0].OrganizationID.String())
+
+	// Um item com ID repetido desfaz o lote inteiro
+	duplicated, _ := entity.NewProduct("Duplicated", 10)
+	duplicated.ID = products[0].ID
+	fresh, _ := entity.NewProduct("Fresh", 10)
+	assert.Error(t, productDB.CreateMany([]*entity.Product{fresh, duplicated}))
+
+	_, err = productDB.FindByID(fresh.ID.String())
+	assert.Error(t, err)
+}
+
+func TestProductSKUAndBarcodeAreUniquePerTenant(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	db.AutoMigrate(&entity.Product{})
+
+	acme := NewProduct(db).ForTenant(entityPkg.NewID().String())
+	globex := NewProduct(db).ForTenant(entityPkg.NewID().String())
+
+	product, _ := entity.NewProduct("Product 1", 10)
+	product.SetIdentifiers("SKU-1", "4006381333931")
+	assert.NoError(t, acme.Create(product))
+
+	// Produtos sem SKU nem código de barras não conflitam entre si
+	withoutIdentifiers, _ := entity.NewProduct("Product 2", 10)
+	assert.NoError(t, acme.Create(withoutIdentifiers))
+	another, _ := entity.NewProduct("Product 3", 10)
+	assert.NoError(t, acme.Create(another))
+
+	sameSKU, _ := entity.NewProduct("Product 4", 10)
+	sameSKU.SetIdentifiers("SKU-1", "")
+	err = acme.Create(sameSKU)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "conflict: sku is already in use", err.Error())
+
+	sameBarcode, _ := entity.NewProduct("Product 5", 10)
+	sameBarcode.SetIdentifiers("SKU-5", "4006381333931")
+	err = acme.Create(sameBarcode)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "conflict: barcode is already in use", err.Error())
+
+	another.SetIdentifiers("SKU-1", "")
+	assert.ErrorIs(t, acme.Update(another), ErrConflict)
+
+	// Outra organização pode usar os mesmos identificadores
+	otherTenant, _ := entity.NewProduct("Product 1", 10)
+	otherTenant.SetIdentifiers("SKU-1", "4006381333931")
+	assert.NoError(t, globex.Create(otherTenant))
+
+	productFound, err := acme.FindBySKU("SKU-1")
+	assert.NoError(t, err)
+	assert.Equal(t, product.ID, productFound.ID)
+
+	productFound, err = acme.FindByBarcode("4006381333931")
+	assert.NoError(t, err)
+	assert.Equal(t, product.ID, productFound.ID)
+
+	productFound, err = globex.FindBySKU("SKU-1")
+	assert.NoError(t, err)
+	assert.Equal(t, otherTenant.ID, productFound.ID)
+
+	_, err = acme.FindBySKU("SKU-404")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestProductTransition(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	db.AutoMigrate(Models()...)
+
+	tenantID := entityPkg.NewID().String()
+	productDB := NewProduct(db).ForTenant(tenantID)
+	product, _ := entity.NewProduct("Product 1", 10)
+	assert.NoError(t, productDB.Create(product))
+
+	// Uma cópia lida antes da mudança representa uma requisição concorrente
+	stale, _ := productDB.FindByID(product.ID.String())
+
+	transition, err := product.Transition(entity.StatusInReview, entity.RoleMember, "user-1", "")
+	assert.NoError(t, err)
+	assert.NoError(t, productDB.Transition(product, transition))
+
+	staleTransition, err := stale.Transition(entity.StatusArchived, entity.RoleOwner, "user-2", "")
+	assert.NoError(t, err)
+	assert.ErrorIs(t, productDB.Transition(stale, staleTransition), ErrConflict)
+
+	transition, _ = product.Transition(entity.StatusPublished, entity.RoleAdmin, "user-2", "approved")
+	assert.NoError(t, productDB.Transition(product, transition))
+
+	found, err := productDB.FindByID(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, entity.StatusPublished, found.Status)
+	assert.NotNil(t, found.PublishedAt)
+
+	transitions, err := productDB.FindTransitions(product.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, transitions, 2)
+	assert.Equal(t, entity.StatusDraft, transitions[0].From)
+	assert.Equal(t, "approved", transitions[1].Comment)
+
+	// O histórico é do tenant
+	transitions, err = NewProduct(db).ForTenant(entityPkg.NewID().String()).FindTransitions(product.ID.String())
+	assert.NoError(t, err)
+	assert.Empty(t, transitions)
+
+	published, err := productDB.FindAllFiltered(0, 0, "", ProductFilter{Statuses: []string{entity.StatusPublished}})
+	assert.NoError(t, err)
+	assert.Len(t, published, 1)
+	drafts, err := productDB.FindAllFiltered(0, 0, "", ProductFilter{Statuses: []string{entity.StatusDraft}})
+	assert.NoError(t, err)
+	assert.Empty(t, drafts)
+
+	assert.NoError(t, productDB.Delete(product.ID.String()))
+	var count int64
+	db.Model(&entity.ProductTransition{}).Where("product_id = ?", product.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
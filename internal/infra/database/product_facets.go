@@ -0,0 +1,102 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/tracing"
+	"gorm.io/gorm"
+)
+
+// maxFacetValues limita os valores retornados em cada contagem de tags ou categorias.
+const maxFacetValues = 100
+
+// FacetOptions escolhe as contagens calculadas por Facets.
+type FacetOptions struct {
+	Tags       bool
+	Categories bool
+	// PriceBuckets são os limites das faixas de preço, em ordem crescente. As
+	// faixas vão de 0 ao primeiro limite, entre limites vizinhos e do último
+	// limite em diante
+	PriceBuckets []float64
+}
+
+// Facets conta os produtos que atendem ao filtro por tag, por categoria e por
+// faixa de preço. As contagens são feitas com GROUP BY no banco, sem carregar
+// os produtos.
+func (p *Product) Facets(filter ProductFilter, options FacetOptions) (_ *entity.ProductFacets, err error) {
+	db, span := p.trace("Facets")
+	defer func() { tracing.End(span, err) }()
+
+	// filtered é refeita a cada uso porque o gorm acumula condições na instância
+	filtered := func() *gorm.DB {
+		return applyProductFilter(db, p.scoped(db.Model(&entity.Product{})), filter)
+	}
+
+	facets := &entity.ProductFacets{}
+	if options.Tags {
+		err = db.Model(&productTag{}).
+			Select("tag AS value, COUNT(*) AS count").
+			Where("product_id IN (?)", filtered().Select("id")).
+			Group("tag").
+			Order("count DESC, tag").
+			Limit(maxFacetValues).
+			Scan(&facets.Tags).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.Categories {
+		err = filtered().
+			Select("category_id AS value, COUNT(*) AS count").
+			Where("category_id IS NOT NULL").
+			Group("category_id").
+			Order("count DESC, category_id").
+			Limit(maxFacetValues).
+			Scan(&facets.Categories).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(options.PriceBuckets) > 0 {
+		facets.Prices, err = priceBuckets(filtered(), options.PriceBuckets)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return facets, nil
+}
+
+// priceBuckets conta todas as faixas numa única consulta, com uma soma
+// condicional por faixa.
+func priceBuckets(query *gorm.DB, limits []float64) ([]entity.PriceBucket, error) {
+	buckets := make([]entity.PriceBucket, 0, len(limits)+1)
+	columns := make([]string, 0, len(limits)+1)
+	args := make([]interface{}, 0, 2*len(limits)+1)
+
+	lower := 0.0
+	for i, limit := range limits {
+		upper := limit
+		buckets = append(buckets, entity.PriceBucket{Min: lower, Max: &upper})
+		columns = append(columns, fmt.Sprintf("COALESCE(SUM(CASE WHEN price >= ? AND price < ? THEN 1 ELSE 0 END), 0) AS bucket_%d", i))
+		args = append(args, lower, upper)
+		lower = limit
+	}
+	buckets = append(buckets, entity.PriceBucket{Min: lower})
+	columns = append(columns, fmt.Sprintf("COALESCE(SUM(CASE WHEN price >= ? THEN 1 ELSE 0 END), 0) AS bucket_%d", len(limits)))
+	args = append(args, lower)
+
+	row := query.Select(strings.Join(columns, ", "), args...).Row()
+	counts := make([]interface{}, len(buckets))
+	for i := range buckets {
+		counts[i] = &buckets[i].Count
+	}
+	if err := row.Scan(counts...); err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestFindAllFilteredByTags(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	db.AutoMigrate(Models()...)
+
+	productDB := NewProduct(db).ForTenant(entityPkg.NewID().String())
+	newProduct := func(name string, tags ...string) *entity.Product {
+		product, _ := entity.NewProduct(name, 10)
+		product.SetTags(tags)
+		return product
+	}
+	tent := newProduct("Tent", "outdoor", "sale")
+	lamp := newProduct("Lamp", "outdoor")
+	assert.NoError(t, productDB.Create(tent))
+	assert.NoError(t, productDB.CreateMany([]*entity.Product{lamp, newProduct("Chair")}))
+	assert.NoError(t, NewProduct(db).ForTenant(entityPkg.NewID().String()).Create(newProduct("Other", "sale")))
+
+	names := func(filter ProductFilter) []string {
+		products, err := productDB.FindAllFiltered(0, 0, "asc", filter)
+		assert.NoError(t, err)
+		var names []string
+		for _, product := range products {
+			names = append(names, product.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"Tent", "Lamp"}, names(ProductFilter{Tags: []string{"outdoor", "sale"}}))
+	assert.Equal(t, []string{"Tent", "Lamp"}, names(ProductFilter{Tags: []string{"outdoor", "sale"}, TagMatch: TagMatchAny}))
+	assert.Equal(t, []string{"Tent"}, names(ProductFilter{Tags: []string{"outdoor", "sale"}, TagMatch: TagMatchAll}))
+	assert.Empty(t, names(ProductFilter{Tags: []string{"indoor"}}))
+
+	// Atualizar as tags regrava o índice
+	tent.SetTags(nil)
+	assert.NoError(t, productDB.Update(tent))
+	assert.Empty(t, names(ProductFilter{Tags: []string{"sale"}}))
+
+	// UpdateTags grava só as tags, sem desfazer alterações feitas por outra requisição
+	db.Model(&entity.Product{}).Where("id = ?", lamp.ID).Update("name", "Lantern")
+	lamp.SetTags([]string{"sale"})
+	assert.NoError(t, productDB.UpdateTags(lamp))
+	assert.Equal(t, []string{"Lantern"}, names(ProductFilter{Tags: []string{"sale"}}))
+	assert.Empty(t, names(ProductFilter{Tags: []string{"outdoor"}}))
+	found, err := productDB.FindByID(lamp.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sale"}, found.Tags)
+	assert.ErrorIs(t, NewProduct(db).ForTenant(entityPkg.NewID().String()).UpdateTags(lamp), gorm.ErrRecordNotFound)
+
+	assert.NoError(t, productDB.Delete(lamp.ID.String()))
+	var count int64
+	db.Model(&productTag{}).Where("product_id = ?", lamp.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestProductFacets(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	db.AutoMigrate(Models()...)
+
+	productDB := NewProduct(db).ForTenant(entityPkg.NewID().String())
+	tools := entityPkg.NewID()
+	newProduct := func(name string, price float64, category *entityPkg.ID, tags ...string) *entity.Product {
+		product, _ := entity.NewProduct(name, price)
+		product.CategoryID = category
+		product.SetTags(tags)
+		return product
+	}
+	assert.NoError(t, productDB.CreateMany([]*entity.Product{
+		newProduct("Hammer", 5, &tools, "sale", "steel"),
+		newProduct("Saw", 25, &tools, "steel"),
+		newProduct("Drill", 150, &tools, "sale", "electric"),
+		newProduct("Gloves", 10, nil, "sale"),
+	}))
+	assert.NoError(t, NewProduct(db).ForTenant(entityPkg.NewID().String()).Create(newProduct("Other", 5, &tools, "sale")))
+
+	facets, err := productDB.Facets(ProductFilter{}, FacetOptions{Tags: true, Categories: true, PriceBuckets: []float64{10, 100}})
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.FacetCount{{Value: "sale", Count: 3}, {Value: "steel", Count: 2}, {Value: "electric", Count: 1}}, facets.Tags)
+	assert.Equal(t, []entity.FacetCount{{Value: tools.String(), Count: 3}}, facets.Categories)
+	ten, hundred := 10.0, 100.0
+	assert.Equal(t, []entity.PriceBucket{
+		{Min: 0, Max: &ten, Count: 1},
+		{Min: 10, Max: &hundred, Count: 2},
+		{Min: 100, Count: 1},
+	}, facets.Prices)
+
+	// As contagens seguem o filtro e só trazem o que foi pedido
+	facets, err = productDB.Facets(ProductFilter{Tags: []string{"steel"}}, FacetOptions{Tags: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.FacetCount{{Value: "steel", Count: 2}, {Value: "sale", Count: 1}}, facets.Tags)
+	assert.Nil(t, facets.Categories)
+	assert.Nil(t, facets.Prices)
+
+	facets, err = productDB.Facets(ProductFilter{Tags: []string{"missing"}}, FacetOptions{PriceBuckets: []float64{10}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), facets.Prices[0].Count+facets.Prices[1].Count)
+}
@@ -0,0 +1,171 @@
+package database
+
+import (
+	"github.com/otthonleao/go-products.git/internal/entity"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"gorm.io/gorm"
+)
+
+// Operadores dos filtros de atributo.
+const (
+	OperatorEqual          = "eq"
+	OperatorLess           = "lt"
+	OperatorLessOrEqual    = "lte"
+	OperatorGreater        = "gt"
+	OperatorGreaterOrEqual = "gte"
+)
+
+// Modos de combinação do filtro de tags.
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
+// ProductFilter restringe a listagem de produtos. Campos vazios não filtram.
+type ProductFilter struct {
+	// Statuses restringe os estados listados; vazio lista todos
+	Statuses   []string
+	CategoryID string
+	Attributes []AttributeFilter
+	// Tags casa produtos com qualquer uma das tags, ou com todas quando
+	// TagMatch é TagMatchAll. As tags devem vir normalizadas, como em
+	// entity.NormalizeTags
+	Tags     []string
+	TagMatch string
+}
+
+// AttributeFilter compara um atributo do produto com Value. Os operadores de
+// ordem (lt, lte, gt, gte) só se aplicam a atributos numéricos e exigem Number.
+type AttributeFilter struct {
+	Name     string
+	Operator string
+	Value    string
+	// Number é Value convertido, quando ele é numérico
+	Number *float64
+}
+
+// productAttributeValue indexa os atributos dos produtos, uma linha por
+// atributo, para que os filtros usem SQL comum em vez de funções de JSON de
+// cada banco. A fonte da verdade continua sendo Product.Attributes.
+type productAttributeValue struct {
+	ProductID      entityPkg.ID `gorm:"primaryKey"`
+	Name           string       `gorm:"primaryKey;index:idx_product_attribute_values_lookup,priority:1"`
+	OrganizationID entityPkg.ID `gorm:"index"`
+	TextValue      string       `gorm:"index:idx_product_attribute_values_lookup,priority:2"`
+	NumberValue    *float64
+}
+
+func (productAttributeValue) TableName() string {
+	return "product_attribute_values"
+}
+
+// productTag indexa as tags dos produtos para os filtros e as contagens.
+type productTag struct {
+	ProductID      entityPkg.ID `gorm:"primaryKey"`
+	Tag            string       `gorm:"primaryKey;index"`
+	OrganizationID entityPkg.ID `gorm:"index"`
+}
+
+func (productTag) TableName() string {
+	return "product_tags"
+}
+
+// replaceTags regrava o índice de tags dos produtos.
+func replaceTags(tx *gorm.DB, products ...*entity.Product) error {
+	ids := make([]entityPkg.ID, 0, len(products))
+	var tags []productTag
+	for _, product := range products {
+		ids = append(ids, product.ID)
+		for _, tag := range product.Tags {
+			tags = append(tags, productTag{ProductID: product.ID, Tag: tag, OrganizationID: product.OrganizationID})
+		}
+	}
+
+	if err := tx.Where("product_id IN ?", ids).Delete(&productTag{}).Error; err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(tags, 100).Error
+}
+
+// replaceAttributeValues regrava o índice de atributos dos produtos.
+func replaceAttributeValues(tx *gorm.DB, products ...*entity.Product) error {
+	ids := make([]entityPkg.ID, 0, len(products))
+	var values []productAttributeValue
+	for _, product := range products {
+		ids = append(ids, product.ID)
+		for name, value := range product.Attributes {
+			row := productAttributeValue{
+				ProductID:      product.ID,
+				Name:           name,
+				OrganizationID: product.OrganizationID,
+				TextValue:      entity.AttributeText(value),
+			}
+			if number, ok := value.(float64); ok {
+				row.NumberValue = &number
+			}
+			values = append(values, row)
+		}
+	}
+
+	if err := tx.Where("product_id IN ?", ids).Delete(&productAttributeValue{}).Error; err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(values, 100).Error
+}
+
+// applyProductFilter acrescenta à consulta de produtos as condições do filtro.
+// Cada atributo vira uma subconsulta sobre product_attribute_values.
+func applyProductFilter(db, query *gorm.DB, filter ProductFilter) *gorm.DB {
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.CategoryID != "" {
+		query = query.Where("category_id = ?", filter.CategoryID)
+	}
+
+	for _, attribute := range filter.Attributes {
+		values := db.Model(&productAttributeValue{}).Select("product_id").Where("name = ?", attribute.Name)
+		switch attribute.Operator {
+		case OperatorLess:
+			values = values.Where("number_value < ?", number(attribute))
+		case OperatorLessOrEqual:
+			values = values.Where("number_value <= ?", number(attribute))
+		case OperatorGreater:
+			values = values.Where("number_value > ?", number(attribute))
+		case OperatorGreaterOrEqual:
+			values = values.Where("number_value >= ?", number(attribute))
+		default:
+			// Números são comparados pelo valor, para que "2" encontre 2.0
+			if attribute.Number != nil {
+				values = values.Where("(text_value = ? OR number_value = ?)", attribute.Value, *attribute.Number)
+			} else {
+				values = values.Where("text_value = ?", attribute.Value)
+			}
+		}
+		query = query.Where("id IN (?)", values)
+	}
+
+	if len(filter.Tags) > 0 {
+		tagged := db.Model(&productTag{}).Select("product_id").Where("tag IN ?", filter.Tags)
+		if filter.TagMatch == TagMatchAll {
+			tagged = tagged.Group("product_id").Having("COUNT(*) = ?", len(filter.Tags))
+		}
+		query = query.Where("id IN (?)", tagged)
+	}
+	return query
+}
+
+// number retorna o valor numérico do filtro. Sem ele a comparação com NULL não
+// casa nenhuma linha, que é o resultado certo para um filtro inválido.
+func number(attribute AttributeFilter) interface{} {
+	if attribute.Number == nil {
+		return nil
+	}
+	return *attribute.Number
+}
@@ -0,0 +1,182 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/tracing"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// ErrImagesMismatch indica que a nova ordem não lista exatamente as imagens do produto.
+var ErrImagesMismatch = errors.New("image ids must list every image of the product exactly once")
+
+// ProductImage guarda os metadados das imagens. Como nas variantes, o tenant é
+// verificado pelo chamador ao carregar o produto.
+type ProductImage struct {
+	DB *gorm.DB
+}
+
+func NewProductImage(db *gorm.DB) *ProductImage {
+	return &ProductImage{
+		DB: db,
+	}
+}
+
+// WithContext retorna uma cópia do repositório que propaga ctx para as consultas.
+func (i *ProductImage) WithContext(ctx context.Context) ProductImageInterface {
+	return &ProductImage{
+		DB: i.DB.WithContext(ctx),
+	}
+}
+
+func (i *ProductImage) trace(method string) (*gorm.DB, trace.Span) {
+	ctx, span := tracing.Start(i.DB.Statement.Context, "database.ProductImage."+method)
+	return i.DB.WithContext(ctx), span
+}
+
+// Create coloca a imagem no fim da ordem. A primeira imagem do produto vira a principal.
+func (i *ProductImage) Create(image *entity.ProductImage) (err error) {
+	db, span := i.trace("Create")
+	defer func() { tracing.End(span, err) }()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&entity.ProductImage{}).Where("product_id = ?", image.ProductID).Count(&count).Error; err != nil {
+			return err
+		}
+		image.Position = int(count)
+		image.Primary = count == 0
+		return tx.Create(image).Error
+	})
+}
+
+// FindByProduct lista as imagens do produto pela posição.
+func (i *ProductImage) FindByProduct(productID string) (images []entity.ProductImage, err error) {
+	db, span := i.trace("FindByProduct")
+	defer func() { tracing.End(span, err) }()
+
+	err = db.Where("product_id = ?", productID).Order("position asc").Find(&images).Error
+	return images, err
+}
+
+// FindByProducts lista as imagens de vários produtos numa única consulta,
+// agrupadas pelo ID do produto.
+func (i *ProductImage) FindByProducts(productIDs []string) (_ map[string][]entity.ProductImage, err error) {
+	db, span := i.trace("FindByProducts")
+	defer func() { tracing.End(span, err) }()
+
+	grouped := make(map[string][]entity.ProductImage, len(productIDs))
+	if len(productIDs) == 0 {
+		return grouped, nil
+	}
+
+	var images []entity.ProductImage
+	err = db.Where("product_id IN ?", productIDs).Order("position asc").Find(&images).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, image := range images {
+		productID := image.ProductID.String()
+		grouped[productID] = append(grouped[productID], image)
+	}
+	return grouped, nil
+}
+
+func (i *ProductImage) FindByID(productID, id string) (_ *entity.ProductImage, err error) {
+	db, span := i.trace("FindByID")
+	defer func() { tracing.End(span, err) }()
+
+	var image entity.ProductImage
+	err = db.Where("product_id = ?", productID).First(&image, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// SetPrimary marca a imagem como principal e desmarca as demais do produto.
+func (i *ProductImage) SetPrimary(productID, id string) (err error) {
+	db, span := i.trace("SetPrimary")
+	defer func() { tracing.End(span, err) }()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.ProductImage{}).Where("product_id = ? AND id = ?", productID, id).Update("is_primary", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&entity.ProductImage{}).Where("product_id = ? AND id <> ?", productID, id).Update("is_primary", false).Error
+	})
+}
+
+// Reorder grava a posição de cada imagem conforme a ordem de ids, que deve
+// conter todas as imagens do produto.
+func (i *ProductImage) Reorder(productID string, ids []string) (err error) {
+	db, span := i.trace("Reorder")
+	defer func() { tracing.End(span, err) }()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var current []string
+		if err := tx.Model(&entity.ProductImage{}).Where("product_id = ?", productID).Pluck("id", &current).Error; err != nil {
+			return err
+		}
+		if !sameIDs(current, ids) {
+			return ErrImagesMismatch
+		}
+
+		for position, id := range ids {
+			err := tx.Model(&entity.ProductImage{}).Where("product_id = ? AND id = ?", productID, id).Update("position", position).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete remove a imagem, fecha o buraco na ordem e, se ela era a principal,
+// promove a primeira das restantes.
+func (i *ProductImage) Delete(productID, id string) (err error) {
+	db, span := i.trace("Delete")
+	defer func() { tracing.End(span, err) }()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var image entity.ProductImage
+		if err := tx.Where("product_id = ?", productID).First(&image, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&image).Error; err != nil {
+			return err
+		}
+
+		err := tx.Model(&entity.ProductImage{}).
+			Where("product_id = ? AND position > ?", productID, image.Position).
+			Update("position", gorm.Expr("position - 1")).Error
+		if err != nil || !image.Primary {
+			return err
+		}
+		return tx.Model(&entity.ProductImage{}).Where("product_id = ? AND position = 0", productID).Update("is_primary", true).Error
+	})
+}
+
+func sameIDs(current, ids []string) bool {
+	if len(current) != len(ids) {
+		return false
+	}
+	remaining := make(map[string]bool, len(current))
+	for _, id := range current {
+		remaining[id] = true
+	}
+	for _, id := range ids {
+		if !remaining[id] {
+			return false
+		}
+		delete(remaining, id)
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestProductImages(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	db.AutoMigrate(&entity.Product{}, &entity.ProductVariant{}, &entity.ProductImage{}, &entity.ProductTransition{})
+
+	productDB := NewProduct(db).ForTenant(entityPkg.NewID().String())
+	imageDB := NewProductImage(db)
+
+	product, _ := entity.NewProduct("Product 1", 10)
+	assert.NoError(t, productDB.Create(product))
+	productID := product.ID.String()
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		image, _ := entity.NewProductImage(product, "image/png", 100, 10, 10)
+		assert.NoError(t, imageDB.Create(image))
+		assert.Equal(t, i, image.Position)
+		assert.Equal(t, i == 0, image.Primary)
+		ids = append(ids, image.ID.String())
+	}
+
+	assert.NoError(t, imageDB.Reorder(productID, []string{ids[2], ids[0], ids[1]}))
+	images, err := imageDB.FindByProduct(productID)
+	assert.NoError(t, err)
+	assert.Equal(t, ids[2], images[0].ID.String())
+	assert.Equal(t, ids[1], images[2].ID.String())
+
+	// A nova ordem precisa listar todas as imagens
+	assert.ErrorIs(t, imageDB.Reorder(productID, []string{ids[0], ids[1]}), ErrImagesMismatch)
+	assert.ErrorIs(t, imageDB.Reorder(productID, []string{ids[0], ids[0], ids[1]}), ErrImagesMismatch)
+
+	assert.NoError(t, imageDB.SetPrimary(productID, ids[1]))
+	assert.Error(t, imageDB.SetPrimary(entityPkg.NewID().String(), ids[1]))
+	images, _ = imageDB.FindByProduct(productID)
+	for _, image := range images {
+		assert.Equal(t, image.ID.String() == ids[1], image.Primary)
+	}
+
+	// Remover a principal promove a primeira da ordem e fecha o buraco
+	assert.NoError(t, imageDB.Delete(productID, ids[1]))
+	images, _ = imageDB.FindByProduct(productID)
+	assert.Len(t, images, 2)
+	assert.Equal(t, ids[2], images[0].ID.String())
+	assert.True(t, images[0].Primary)
+	assert.Equal(t, 1, images[1].Position)
+
+	grouped, err := imageDB.FindByProducts([]string{productID})
+	assert.NoError(t, err)
+	assert.Len(t, grouped[productID], 2)
+
+	_, err = imageDB.FindByID(entityPkg.NewID().String(), ids[0])
+	assert.Error(t, err)
+
+	assert.NoError(t, productDB.Delete(productID))
+	images, _ = imageDB.FindByProduct(productID)
+	assert.Empty(t, images)
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/tracing"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// variantUniqueFields são as restrições de unicidade citadas nos conflitos: o SKU
+// dentro da organização e a combinação de opções dentro do produto.
+var variantUniqueFields = []string{"sku", "combination"}
+
+// ProductVariant guarda as variantes. O tenant é verificado pelo chamador ao
+// carregar o produto; aqui toda consulta fica restrita ao produto informado.
+type ProductVariant struct {
+	DB *gorm.DB
+}
+
+func NewProductVariant(db *gorm.DB) *ProductVariant {
+	return &ProductVariant{
+		DB: db,
+	}
+}
+
+// WithContext retorna uma cópia do repositório que propaga ctx para as consultas.
+func (v *ProductVariant) WithContext(ctx context.Context) ProductVariantInterface {
+	return &ProductVariant{
+		DB: v.DB.WithContext(ctx),
+	}
+}
+
+func (v *ProductVariant) trace(method string) (*gorm.DB, trace.Span) {
+	ctx, span := tracing.Start(v.DB.Statement.Context, "database.ProductVariant."+method)
+	return v.DB.WithContext(ctx), span
+}
+
+func (v *ProductVariant) Create(variant *entity.ProductVariant) (err error) {
+	db, span := v.trace("Create")
+	defer func() { tracing.End(span, err) }()
+
+	return translateConflict(db.Create(variant).Error, variantUniqueFields...)
+}
+
+// FindByProduct lista as variantes do produto na ordem de criação.
+func (v *ProductVariant) FindByProduct(productID string) (variants []entity.ProductVariant, err error) {
+	db, span := v.trace("FindByProduct")
+	defer func() { tracing.End(span, err) }()
+
+	err = db.Where("product_id = ?", productID).Order("created_at asc").Find(&variants).Error
+	return variants, err
+}
+
+// FindByProducts lista as variantes de vários produtos numa única consulta,
+// agrupadas pelo ID do produto.
+func (v *ProductVariant) FindByProducts(productIDs []string) (_ map[string][]entity.ProductVariant, err error) {
+	db, span := v.trace("FindByProducts")
+	defer func() { tracing.End(span, err) }()
+
+	grouped := make(map[string][]entity.ProductVariant, len(productIDs))
+	if len(productIDs) == 0 {
+		return grouped, nil
+	}
+
+	var variants []entity.ProductVariant
+	err = db.Where("product_id IN ?", productIDs).Order("created_at asc").Find(&variants).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, variant := range variants {
+		productID := variant.ProductID.String()
+		grouped[productID] = append(grouped[productID], variant)
+	}
+	return grouped, nil
+}
+
+func (v *ProductVariant) FindByID(productID, id string) (_ *entity.ProductVariant, err error) {
+	db, span := v.trace("FindByID")
+	defer func() { tracing.End(span, err) }()
+
+	var variant entity.ProductVariant
+	err = db.Where("product_id = ?", productID).First(&variant, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// Count retorna quantas variantes o produto tem.
+func (v *ProductVariant) Count(productID string) (count int64, err error) {
+	db, span := v.trace("Count")
+	defer func() { tracing.End(span, err) }()
+
+	err = db.Model(&entity.ProductVariant{}).Where("product_id = ?", productID).Count(&count).Error
+	return count, err
+}
+
+func (v *ProductVariant) Update(variant *entity.ProductVariant) (err error) {
+	db, span := v.trace("Update")
+	defer func() { tracing.End(span, err) }()
+
+	return translateConflict(db.Save(variant).Error, variantUniqueFields...)
+}
+
+func (v *ProductVariant) Delete(productID, id string) (err error) {
+	db, span := v.trace("Delete")
+	defer func() { tracing.End(span, err) }()
+
+	result := db.Where("product_id = ?", productID).Delete(&entity.ProductVariant{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestProductVariants(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	db.AutoMigrate(&entity.Product{}, &entity.ProductVariant{}, &entity.ProductImage{}, &entity.ProductTransition{})
+
+	productDB := NewProduct(db).ForTenant(entityPkg.NewID().String())
+	variantDB := NewProductVariant(db)
+
+	product, _ := entity.NewProduct("T-Shirt", 50)
+	product.OptionAxes = []string{"size", "color"}
+	assert.NoError(t, productDB.Create(product))
+
+	small, _ := entity.NewProductVariant(product, map[string]string{"size": "S", "color": "red"}, "SHIRT-S-RED", nil, 5)
+	assert.NoError(t, variantDB.Create(small))
+	medium, _ := entity.NewProductVariant(product, map[string]string{"size": "M", "color": "red"}, "", nil, 3)
+	assert.NoError(t, variantDB.Create(medium))
+
+	// A mesma combinação, mesmo com outra grafia, conflita
+	duplicated, _ := entity.NewProductVariant(product, map[string]string{"color": "Red", "size": "s"}, "", nil, 1)
+	err = variantDB.Create(duplicated)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "conflict: combination is already in use", err.Error())
+
+	sameSKU, _ := entity.NewProductVariant(product, map[string]string{"size": "L", "color": "red"}, "SHIRT-S-RED", nil, 1)
+	err = variantDB.Create(sameSKU)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "conflict: sku is already in use", err.Error())
+
+	variants, err := variantDB.FindByProduct(product.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, variants, 2)
+	assert.Equal(t, map[string]string{"size": "S", "color": "red"}, variants[0].Options)
+
+	grouped, err := variantDB.FindByProducts([]string{product.ID.String(), entityPkg.NewID().String()})
+	assert.NoError(t, err)
+	assert.Len(t, grouped, 1)
+	assert.Len(t, grouped[product.ID.String()], 2)
+
+	count, err := variantDB.Count(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// A variante só é encontrada pelo produto dela
+	_, err = variantDB.FindByID(entityPkg.NewID().String(), small.ID.String())
+	assert.Error(t, err)
+	assert.Error(t, variantDB.Delete(entityPkg.NewID().String(), small.ID.String()))
+
+	assert.NoError(t, variantDB.Delete(product.ID.String(), small.ID.String()))
+	_, err = variantDB.FindByID(product.ID.String(), small.ID.String())
+	assert.Error(t, err)
+
+	// Remover o produto remove as variantes
+	assert.NoError(t, productDB.Delete(product.ID.String()))
+	count, _ = variantDB.Count(product.ID.String())
+	assert.Equal(t, int64(0), count)
+}
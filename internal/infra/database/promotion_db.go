@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/tracing"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// Promotion guarda as promoções e os cupons. Como as categorias, as consultas
+// ficam restritas ao tenant informado em ForTenant.
+type Promotion struct {
+	DB       *gorm.DB
+	TenantID string
+}
+
+func NewPromotion(db *gorm.DB) *Promotion {
+	return &Promotion{
+		DB: db,
+	}
+}
+
+// ForTenant retorna uma cópia do repositório restrita à organização informada.
+func (p *Promotion) ForTenant(organizationID string) PromotionInterface {
+	return &Promotion{
+		DB:       p.DB,
+		TenantID: organizationID,
+	}
+}
+
+// WithContext retorna uma cópia do repositório que propaga ctx para as consultas.
+func (p *Promotion) WithContext(ctx context.Context) PromotionInterface {
+	return &Promotion{
+		DB:       p.DB.WithContext(ctx),
+		TenantID: p.TenantID,
+	}
+}
+
+func (p *Promotion) trace(method string) (*gorm.DB, trace.Span) {
+	ctx, span := tracing.Start(p.DB.Statement.Context, "database.Promotion."+method)
+	return p.DB.WithContext(ctx), span
+}
+
+func (p *Promotion) Create(promotion *entity.Promotion) (err error) {
+	db, span := p.trace("Create")
+	defer func() { tracing.End(span, err) }()
+
+	promotion.OrganizationID, err = entityPkg.ParseID(p.TenantID)
+	if err != nil {
+		return err
+	}
+	return translateConflict(db.Create(promotion).Error, "code")
+}
+
+// FindAll lista as promoções da organização, das mais recentes às mais antigas.
+func (p *Promotion) FindAll(page, limit int) (promotions []entity.Promotion, err error) {
+	db, span := p.trace("FindAll")
+	defer func() { tracing.End(span, err) }()
+
+	query := db.Where("organization_id = ?", p.TenantID)
+	if page != 0 && limit != 0 {
+		query = query.Limit(limit).Offset((page - 1) * limit)
+	}
+	err = query.Order("created_at desc").Find(&promotions).Error
+	return promotions, err
+}
+
+func (p *Promotion) FindByID(id string) (_ *entity.Promotion, err error) {
+	db, span := p.trace("FindByID")
+	defer func() { tracing.End(span, err) }()
+
+	var promotion entity.Promotion
+	err = db.Where("organization_id = ?", p.TenantID).First(&promotion, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// FindByCode busca o cupom pelo código já normalizado.
+func (p *Promotion) FindByCode(code string) (_ *entity.Promotion, err error) {
+	db, span := p.trace("FindByCode")
+	defer func() { tracing.End(span, err) }()
+
+	var promotion entity.Promotion
+	err = db.Where("organization_id = ?", p.TenantID).First(&promotion, "code = ?", entity.NormalizeCouponCode(code)).Error
+	if err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// FindAutomatic lista as promoções sem cupom ativas e vigentes em now, na
+// ordem de criação.
+func (p *Promotion) FindAutomatic(now time.Time) (promotions []entity.Promotion, err error) {
+	db, span := p.trace("FindAutomatic")
+	defer func() { tracing.End(span, err) }()
+
+	err = db.Where("organization_id = ? AND code IS NULL AND active = ?", p.TenantID, true).
+		Where("starts_at IS NULL OR starts_at <= ?", now).
+		Where("ends_at IS NULL OR ends_at > ?", now).
+		Order("created_at asc").
+		Find(&promotions).Error
+	return promotions, err
+}
+
+// Update grava a promoção mantendo a organização, a criação e os usos do cupom.
+func (p *Promotion) Update(promotion *entity.Promotion) (err error) {
+	db, span := p.trace("Update")
+	defer func() { tracing.End(span, err) }()
+
+	current, err := p.WithContext(db.Statement.Context).FindByID(promotion.ID.String())
+	if err != nil {
+		return err
+	}
+	promotion.OrganizationID = current.OrganizationID
+	promotion.CreatedAt = current.CreatedAt
+	promotion.UsageCount = current.UsageCount
+	return translateConflict(db.Omit("usage_count").Save(promotion).Error, "code")
+}
+
+func (p *Promotion) Delete(id string) (err error) {
+	db, span := p.trace("Delete")
+	defer func() { tracing.End(span, err) }()
+
+	promotion, err := p.WithContext(db.Statement.Context).FindByID(id)
+	if err != nil {
+		return err
+	}
+	return db.Delete(promotion).Error
+}
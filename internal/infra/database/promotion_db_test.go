@@ -0,0 +1,116 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestPromotionRepository(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	db.AutoMigrate(Models()...)
+
+	organizationID := entityPkg.NewID()
+	promotionDB := NewPromotion(db).ForTenant(organizationID.String())
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	automatic := entity.NewPromotion("Summer", entity.PromotionPercentage)
+	automatic.Percentage = 10
+	automatic.ProductIDs = []entityPkg.ID{entityPkg.NewID()}
+	assert.NoError(t, automatic.Validate())
+	assert.NoError(t, promotionDB.Create(automatic))
+
+	scheduled := entity.NewPromotion("Next week", entity.PromotionPercentage)
+	scheduled.Percentage = 20
+	scheduled.StartsAt = &future
+	assert.NoError(t, promotionDB.Create(scheduled))
+	expired := entity.NewPromotion("Last week", entity.PromotionPercentage)
+	expired.Percentage = 20
+	expired.EndsAt = &past
+	assert.NoError(t, promotionDB.Create(expired))
+	inactive := entity.NewPromotion("Paused", entity.PromotionPercentage)
+	inactive.Percentage = 20
+	inactive.Active = false
+	assert.NoError(t, promotionDB.Create(inactive))
+
+	code := "welcome"
+	coupon := entity.NewPromotion("Welcome", entity.PromotionFixed)
+	coupon.Amount = 500
+	coupon.Code = &code
+	limit := 1
+	coupon.UsageLimit = &limit
+	assert.NoError(t, coupon.Validate())
+	assert.NoError(t, promotionDB.Create(coupon))
+
+	// O código é único por organização
+	duplicate := entity.NewPromotion("Again", entity.PromotionFixed)
+	duplicate.Amount = 100
+	duplicate.Code = coupon.Code
+	assert.ErrorIs(t, promotionDB.Create(duplicate), ErrConflict)
+	assert.NoError(t, NewPromotion(db).ForTenant(entityPkg.NewID().String()).Create(duplicate))
+
+	found, err := promotionDB.FindAutomatic(now)
+	assert.NoError(t, err)
+	assert.Len(t, found, 1)
+	assert.Equal(t, automatic.ID, found[0].ID)
+	assert.Equal(t, automatic.ProductIDs, found[0].ProductIDs)
+
+	promotion, err := promotionDB.FindByCode(" Welcome ")
+	assert.NoError(t, err)
+	assert.Equal(t, coupon.ID, promotion.ID)
+	_, err = NewPromotion(db).ForTenant(entityPkg.NewID().String()).FindByCode("WELCOME")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	all, err := promotionDB.FindAll(1, 10)
+	assert.NoError(t, err)
+	assert.Len(t, all, 5)
+
+	// Pedidos com o cupom consomem e devolvem os usos
+	orderDB := NewOrder(db)
+	product, _ := entity.NewProduct("Pen", 10)
+	assert.NoError(t, NewProduct(db).ForTenant(organizationID.String()).Create(product))
+	newOrder := func() *entity.Order {
+		item, _ := entity.NewOrderItem(product, nil, 1)
+		order, _ := entity.NewOrder(organizationID, entityPkg.NewID(), []entity.OrderItem{*item})
+		quote := entity.PriceLines([]entity.PricingLine{item.PricingLine(nil)}, nil, coupon)
+		order.ApplyQuote(quote, coupon)
+		return order
+	}
+	first := newOrder()
+	assert.NoError(t, orderDB.Place(first, ""))
+	promotion, _ = promotionDB.FindByID(coupon.ID.String())
+	assert.Equal(t, 1, promotion.UsageCount)
+
+	second := newOrder()
+	assert.ErrorIs(t, orderDB.Place(second, ""), entity.ErrCouponExhausted)
+	_, err = orderDB.FindByID(second.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// Update mantém os usos já contados
+	promotion.Name = "Welcome back"
+	promotion.UsageCount = 0
+	assert.NoError(t, promotionDB.Update(promotion))
+	promotion, _ = promotionDB.FindByID(coupon.ID.String())
+	assert.Equal(t, "Welcome back", promotion.Name)
+	assert.Equal(t, 1, promotion.UsageCount)
+
+	from, _ := first.Transition(entity.OrderCancelled, entity.RoleBuyer)
+	assert.NoError(t, orderDB.Transition(first, from))
+	promotion, _ = promotionDB.FindByID(coupon.ID.String())
+	assert.Equal(t, 0, promotion.UsageCount)
+	assert.NoError(t, orderDB.Place(second, ""))
+
+	assert.NoError(t, promotionDB.Delete(automatic.ID.String()))
+	_, err = promotionDB.FindByID(automatic.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, promotionDB.Delete(automatic.ID.String()), gorm.ErrRecordNotFound)
+}
@@ -1,7 +1,11 @@
 package database
 
 import (
+	"context"
+
 	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/tracing"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
 )
 
@@ -15,18 +19,46 @@ func NewUser(db *gorm.DB) *User {
 	}
 }
 
-func (u *User) Create(user *entity.User) error{
-	return u.DB.Create(user).Error
+// WithContext retorna uma cópia do repositório que propaga ctx para as consultas.
+func (u *User) WithContext(ctx context.Context) UserInterface {
+	return &User{
+		DB: u.DB.WithContext(ctx),
+	}
 }
 
-func (u *User) FindByEmail(email string) (*entity.User, error) {
-	
+func (u *User) trace(method string) (*gorm.DB, trace.Span) {
+	ctx, span := tracing.Start(u.DB.Statement.Context, "database.User."+method)
+	return u.DB.WithContext(ctx), span
+}
+
+func (u *User) Create(user *entity.User) (err error) {
+	db, span := u.trace("Create")
+	defer func() { tracing.End(span, err) }()
+
+	return translateConflict(db.Create(user).Error, "email")
+}
+
+func (u *User) FindByID(id string) (_ *entity.User, err error) {
+	db, span := u.trace("FindByID")
+	defer func() { tracing.End(span, err) }()
+
 	var user entity.User
-	err := u.DB.Where("email = ?", email).First(&user).Error
+	if err = db.First(&user, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (u *User) FindByEmail(email string) (_ *entity.User, err error) {
+	db, span := u.trace("FindByEmail")
+	defer func() { tracing.End(span, err) }()
+
+	var user entity.User
+	err = db.Where("email = ?", email).First(&user).Error
 
 	if err != nil {
 		return nil, err
 	}
 	
 	return &user, nil
-}
\ No newline at end of file
+}
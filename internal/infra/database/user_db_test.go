@@ -30,6 +30,9 @@ func TestCreateUser(t *testing.T) {
 	assert.Equal(t, user.Name, userFound.Name)
 	assert.Equal(t, user.Email, userFound.Email)
 	assert.NotNil(t, userFound.Password)
+
+	duplicated, _ := entity.NewUser("Other", "test@mail.com", "654321")
+	assert.ErrorIs(t, userDB.Create(duplicated), ErrConflict)
 }
 
 func TestFindByEmail(t *testing.T) {
@@ -0,0 +1,129 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys são atributos cujo valor nunca deve aparecer nos logs.
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"authorization": true,
+	"cookie":        true,
+	"secret":        true,
+	"jwt_secret":    true,
+	"db_password":   true,
+	"api_key":       true,
+}
+
+// New cria um logger estruturado. level aceita debug, info, warn e error;
+// format aceita json ou text.
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var slogLevel slog.Level
+	if err := slogLevel.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+
+	options := &slog.HandlerOptions{
+		Level:       slogLevel,
+		ReplaceAttr: redact,
+	}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "", "json":
+		handler = slog.NewJSONHandler(w, options)
+	case "text":
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return nil, fmt.Errorf("invalid log format %q", format)
+	}
+
+	return slog.New(&contextHandler{Handler: handler}), nil
+}
+
+func redact(groups []string, attr slog.Attr) slog.Attr {
+	if IsSensitive(attr.Key) {
+		return slog.String(attr.Key, redacted)
+	}
+	return attr
+}
+
+// IsSensitive indica se um campo com esse nome deve ser mascarado.
+func IsSensitive(key string) bool {
+	return sensitiveKeys[strings.ToLower(key)]
+}
+
+type fieldsKey struct{}
+
+// fields guarda os dados de correlação da requisição. É um ponteiro para que
+// middlewares internos (ex.: autenticação) possam completar o usuário e o log
+// de acesso, emitido no middleware externo, também o enxergue.
+type fields struct {
+	mu        sync.RWMutex
+	requestID string
+	userID    string
+}
+
+// WithRequestID inicia os campos de correlação da requisição no contexto.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, fieldsKey{}, &fields{requestID: requestID})
+}
+
+// RequestID retorna o ID da requisição presente no contexto.
+func RequestID(ctx context.Context) string {
+	f, ok := ctx.Value(fieldsKey{}).(*fields)
+	if !ok {
+		return ""
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.requestID
+}
+
+// SetUserID associa o usuário autenticado às próximas linhas de log da requisição.
+func SetUserID(ctx context.Context, userID string) {
+	f, ok := ctx.Value(fieldsKey{}).(*fields)
+	if !ok {
+		return
+	}
+	f.mu.Lock()
+	f.userID = userID
+	f.mu.Unlock()
+}
+
+// contextHandler acrescenta request_id e user_id a todo registro emitido com contexto.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if f, ok := ctx.Value(fieldsKey{}).(*fields); ok {
+		f.mu.RLock()
+		if f.requestID != "" {
+			record.AddAttrs(slog.String("request_id", f.requestID))
+		}
+		if f.userID != "" {
+			record.AddAttrs(slog.String("user_id", f.userID))
+		}
+		f.mu.RUnlock()
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew_RedactsSensitiveFields(t *testing.T) {
+	var buffer bytes.Buffer
+	log, err := New(&buffer, "info", "json")
+	assert.NoError(t, err)
+
+	log.Info("login", "email", "test@mail.com", "password", "123456",
+		slog.Group("headers", slog.String("Authorization", "Bearer abc")),
+		"access_token", "abc")
+
+	var line map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &line))
+	assert.Equal(t, "test@mail.com", line["email"])
+	assert.Equal(t, redacted, line["password"])
+	assert.Equal(t, redacted, line["access_token"])
+	assert.Equal(t, redacted, line["headers"].(map[string]interface{})["Authorization"])
+	assert.NotContains(t, buffer.String(), "123456")
+}
+
+func TestNew_AddsCorrelationFields(t *testing.T) {
+	var buffer bytes.Buffer
+	log, err := New(&buffer, "debug", "json")
+	assert.NoError(t, err)
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	SetUserID(ctx, "user-1")
+	log.DebugContext(ctx, "handled")
+
+	var line map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &line))
+	assert.Equal(t, "req-1", line["request_id"])
+	assert.Equal(t, "user-1", line["user_id"])
+	assert.Equal(t, "req-1", RequestID(ctx))
+}
+
+func TestNew_Level(t *testing.T) {
+	var buffer bytes.Buffer
+	log, err := New(&buffer, "warn", "text")
+	assert.NoError(t, err)
+
+	log.Info("ignored")
+	assert.Empty(t, buffer.String())
+	log.Warn("kept")
+	assert.Contains(t, buffer.String(), "kept")
+
+	_, err = New(&buffer, "verbose", "json")
+	assert.Error(t, err)
+	_, err = New(&buffer, "info", "xml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gorm.io/gorm"
+)
+
+const startTimeKey = "metrics:start_time"
+
+var dbQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "db_query_duration_seconds",
+	Help:      "Duração das consultas do gorm por operação e tabela.",
+	Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+}, []string{"operation", "table"})
+
+// InstrumentDB registra callbacks no gorm para medir a duração das consultas e
+// exporta as estatísticas do pool de conexões.
+func InstrumentDB(db *gorm.DB, name string) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	err = prometheus.Register(collectors.NewDBStatsCollector(sqlDB, name))
+	if err != nil && !errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+		return err
+	}
+
+	register := func(operation string, before, after func(string, func(*gorm.DB)) error) error {
+		if err := before("metrics:before_"+operation, startTimer); err != nil {
+			return err
+		}
+		return after("metrics:after_"+operation, observe(operation))
+	}
+
+	callback := db.Callback()
+	steps := []error{
+		register("create", callback.Create().Before("gorm:create").Register, callback.Create().After("gorm:create").Register),
+		register("query", callback.Query().Before("gorm:query").Register, callback.Query().After("gorm:query").Register),
+		register("update", callback.Update().Before("gorm:update").Register, callback.Update().After("gorm:update").Register),
+		register("delete", callback.Delete().Before("gorm:delete").Register, callback.Delete().After("gorm:delete").Register),
+		register("row", callback.Row().Before("gorm:row").Register, callback.Row().After("gorm:row").Register),
+		register("raw", callback.Raw().Before("gorm:raw").Register, callback.Raw().After("gorm:raw").Register),
+	}
+	return errors.Join(steps...)
+}
+
+func startTimer(db *gorm.DB) {
+	db.InstanceSet(startTimeKey, time.Now())
+}
+
+func observe(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(startTimeKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		dbQueryDuration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "go_products"
+
+// unmatchedRoute agrupa requisições sem rota para não explodir a cardinalidade.
+const unmatchedRoute = "unmatched"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Total de requisições HTTP por rota, método e status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latência das requisições HTTP por rota e método.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	httpInFlight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "Requisições HTTP em andamento por rota.",
+	}, []string{"method", "route"})
+
+	logins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Tentativas de login por resultado.",
+	}, []string{"result"})
+
+	productEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "product_events_total",
+		Help:      "Eventos de negócio de produtos (created, deleted).",
+	}, []string{"event"})
+)
+
+// Handler expõe as métricas no formato texto do Prometheus.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware registra contagem, latência e requisições em andamento usando o
+// padrão da rota do chi (ex.: /products/{id}) e não o caminho bruto.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		route := routePattern(request)
+
+		inFlight := httpInFlight.WithLabelValues(request.Method, route)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		wrapped := middleware.NewWrapResponseWriter(response, request.ProtoMajor)
+		defer func() {
+			status := wrapped.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			httpRequests.WithLabelValues(request.Method, route, strconv.Itoa(status)).Inc()
+			httpDuration.WithLabelValues(request.Method, route).Observe(time.Since(start).Seconds())
+		}()
+
+		next.ServeHTTP(wrapped, request)
+	})
+}
+
+func routePattern(request *http.Request) string {
+	routeContext := chi.RouteContext(request.Context())
+	if routeContext == nil || routeContext.Routes == nil {
+		return unmatchedRoute
+	}
+
+	pattern := routeContext.Routes.Find(chi.NewRouteContext(), request.Method, request.URL.Path)
+	if pattern == "" {
+		return unmatchedRoute
+	}
+	return pattern
+}
+
+func RecordLogin(succeeded bool) {
+	if succeeded {
+		logins.WithLabelValues("succeeded").Inc()
+		return
+	}
+	logins.WithLabelValues("failed").Inc()
+}
+
+func RecordProductCreated() {
+	productEvents.WithLabelValues("created").Inc()
+}
+
+func RecordProductDeleted() {
+	productEvents.WithLabelValues("deleted").Inc()
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMiddleware_UsesRoutePattern(t *testing.T) {
+	route := chi.NewRouter()
+	route.Use(Middleware)
+	route.Route("/products", func(chiRoute chi.Router) {
+		chiRoute.Get("/{id}", func(response http.ResponseWriter, request *http.Request) {
+			response.WriteHeader(http.StatusNotFound)
+		})
+	})
+
+	before := testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "/products/{id}", "404"))
+	for _, id := range []string{"a", "b", "c"} {
+		route.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/products/"+id, nil))
+	}
+	after := testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "/products/{id}", "404"))
+	assert.Equal(t, 3.0, after-before)
+
+	route.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+	assert.Equal(t, 1.0, testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, unmatchedRoute, "404")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(httpInFlight.WithLabelValues(http.MethodGet, "/products/{id}")))
+}
+
+func TestDomainCounters(t *testing.T) {
+	RecordLogin(true)
+	RecordLogin(false)
+	RecordLogin(false)
+	RecordProductCreated()
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(logins.WithLabelValues("succeeded")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(logins.WithLabelValues("failed")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(productEvents.WithLabelValues("created")))
+}
+
+func TestInstrumentDB(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	assert.NoError(t, InstrumentDB(db, "test"))
+
+	type Sample struct {
+		ID   int
+		Name string
+	}
+	db.AutoMigrate(&Sample{})
+	db.Create(&Sample{Name: "sample"})
+	var samples []Sample
+	db.Find(&samples)
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := recorder.Body.String()
+	assert.Contains(t, body, `go_products_db_query_duration_seconds_count{operation="create",table="samples"} 1`)
+	assert.Contains(t, body, `go_products_db_query_duration_seconds_count{operation="query",table="samples"} 1`)
+	assert.Contains(t, body, `go_sql_open_connections{db_name="test"}`)
+}
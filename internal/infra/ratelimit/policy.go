@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	KeyIP     = "ip"
+	KeyUser   = "user"
+	KeyAPIKey = "api_key"
+)
+
+// Policy limita as requisições que casam com Method/Pattern a Limit por Period,
+// contadas separadamente para cada cliente identificado por Key.
+type Policy struct {
+	Method  string
+	Pattern string
+	Key     string
+	Limit   int
+	Period  time.Duration
+}
+
+// ParsePolicies lê políticas no formato "[MÉTODO ]PADRÃO=CHAVE:LIMITE/PERÍODO"
+// separadas por ";", por exemplo: "POST /users/login=ip:5/1m;/products=user:100/1m;*=ip:300/1m".
+// A primeira política que casar com a rota é aplicada.
+func ParsePolicies(value string) ([]Policy, error) {
+	policies := []Policy{}
+
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, rule, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit policy %q", entry)
+		}
+
+		policy := Policy{}
+		route = strings.TrimSpace(route)
+		if method, pattern, ok := strings.Cut(route, " "); ok {
+			policy.Method = strings.ToUpper(method)
+			route = strings.TrimSpace(pattern)
+		}
+		policy.Pattern = route
+
+		key, quota, ok := strings.Cut(strings.TrimSpace(rule), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit policy %q", entry)
+		}
+		switch key {
+		case KeyIP, KeyUser, KeyAPIKey:
+			policy.Key = key
+		default:
+			return nil, fmt.Errorf("invalid rate limit key %q", key)
+		}
+
+		limit, period, ok := strings.Cut(quota, "/")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit policy %q", entry)
+		}
+
+		var err error
+		policy.Limit, err = strconv.Atoi(limit)
+		if err != nil || policy.Limit <= 0 {
+			return nil, fmt.Errorf("invalid rate limit %q", limit)
+		}
+		policy.Period, err = time.ParseDuration(period)
+		if err != nil || policy.Period <= 0 {
+			return nil, fmt.Errorf("invalid rate limit period %q", period)
+		}
+
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+// Matches indica se a política vale para a rota. "/products" casa com
+// "/products" e com qualquer rota abaixo dela; "*" casa com tudo.
+func (p Policy) Matches(method, route string) bool {
+	if p.Method != "" && p.Method != method {
+		return false
+	}
+	if p.Pattern == "*" {
+		return true
+	}
+	pattern := strings.TrimSuffix(p.Pattern, "/")
+	return route == pattern || route == pattern+"/" || strings.HasPrefix(route, pattern+"/")
+}
+
+func (p Policy) String() string {
+	return fmt.Sprintf("%d;w=%d", p.Limit, int(p.Period.Seconds()))
+}
+
+func (p Policy) id() string {
+	return p.Method + " " + p.Pattern + "=" + p.Key
+}
+
+// LongestPeriod é o tempo após o qual qualquer bucket ocioso já está cheio e
+// pode ser descartado sem mudar o comportamento do limitador.
+func LongestPeriod(policies []Policy) time.Duration {
+	var longest time.Duration
+	for _, policy := range policies {
+		if policy.Period > longest {
+			longest = policy.Period
+		}
+	}
+	return longest
+}
@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/jwtauth"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+type Limiter struct {
+	store     Store
+	policies  []Policy
+	tokenAuth *jwtauth.JWTAuth
+	log       *slog.Logger
+	// apiKeys guarda o hash das API keys conhecidas
+	apiKeys map[string]bool
+}
+
+// NewLimiter cria o limitador. tokenAuth é usado para identificar o usuário nas
+// políticas por "user", já que o limitador roda antes da autenticação das rotas.
+func NewLimiter(store Store, policies []Policy, tokenAuth *jwtauth.JWTAuth, log *slog.Logger) *Limiter {
+	return &Limiter{
+		store:     store,
+		policies:  policies,
+		tokenAuth: tokenAuth,
+		log:       log,
+	}
+}
+
+// WithAPIKeys informa as API keys válidas para as políticas por "api_key".
+// Chaves desconhecidas contam pelo IP, para que um cabeçalho inventado a cada
+// requisição não ganhe um bucket novo.
+func (l *Limiter) WithAPIKeys(keys []string) *Limiter {
+	l.apiKeys = make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if key != "" {
+			l.apiKeys[hashAPIKey(key)] = true
+		}
+	}
+	return l
+}
+
+// hashAPIKey evita que a chave fique em memória/store em texto puro.
+func hashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// Middleware aplica a primeira política que casar com a rota do chi e responde
+// 429 com Retry-After quando o cliente esgota seus tokens.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		policy, ok := l.policyFor(request)
+		if !ok {
+			next.ServeHTTP(response, request)
+			return
+		}
+
+		key := policy.id() + "|" + l.clientKey(policy, request)
+		result, err := l.store.Take(request.Context(), key, policy.Limit, policy.Period)
+		if err != nil {
+			// Falha no store não pode derrubar a API: a requisição segue sem limite
+			l.log.WarnContext(request.Context(), "rate limit store failed", slog.Any("error", err))
+			next.ServeHTTP(response, request)
+			return
+		}
+
+		header := response.Header()
+		header.Set("RateLimit-Policy", policy.String())
+		header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+
+		if !result.Allowed {
+			header.Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			header.Set("Content-Type", "application/json")
+			response.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(response).Encode(map[string]string{"message": "rate limit exceeded"})
+			return
+		}
+
+		next.ServeHTTP(response, request)
+	})
+}
+
+func (l *Limiter) policyFor(request *http.Request) (Policy, bool) {
+	route := request.URL.Path
+	if routeContext := chi.RouteContext(request.Context()); routeContext != nil && routeContext.Routes != nil {
+		if pattern := routeContext.Routes.Find(chi.NewRouteContext(), request.Method, request.URL.Path); pattern != "" {
+			route = pattern
+		}
+	}
+
+	for _, policy := range l.policies {
+		if policy.Matches(request.Method, route) {
+			return policy, true
+		}
+	}
+	return Policy{}, false
+}
+
+// clientKey identifica o cliente conforme a política; sem usuário ou API key
+// válidos, o limite é contado pelo IP.
+func (l *Limiter) clientKey(policy Policy, request *http.Request) string {
+	switch policy.Key {
+	case KeyUser:
+		if l.tokenAuth != nil {
+			if token, err := jwtauth.VerifyRequest(l.tokenAuth, request, jwtauth.TokenFromHeader); err == nil && token.Subject() != "" {
+				return "user:" + token.Subject()
+			}
+		}
+	case KeyAPIKey:
+		if apiKey := request.Header.Get(APIKeyHeader); apiKey != "" {
+			if hash := hashAPIKey(apiKey); l.apiKeys[hash] {
+				return "api_key:" + hash
+			}
+		}
+	}
+	return "ip:" + clientIP(request)
+}
+
+func clientIP(request *http.Request) string {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		return request.RemoteAddr
+	}
+	return host
+}
+
+func ceilSeconds(duration time.Duration) int {
+	return int(math.Ceil(duration.Seconds()))
+}
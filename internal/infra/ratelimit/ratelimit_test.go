@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/jwtauth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePolicies(t *testing.T) {
+	policies, err := ParsePolicies("POST /users/login=ip:5/1m; /products=user:100/1m;*=api_key:300/1h")
+	assert.NoError(t, err)
+	assert.Equal(t, []Policy{
+		{Method: "POST", Pattern: "/users/login", Key: KeyIP, Limit: 5, Period: time.Minute},
+		{Pattern: "/products", Key: KeyUser, Limit: 100, Period: time.Minute},
+		{Pattern: "*", Key: KeyAPIKey, Limit: 300, Period: time.Hour},
+	}, policies)
+
+	for _, invalid := range []string{"/products", "/products=host:1/1m", "/products=ip:0/1m", "/products=ip:1/forever", "/products=ip:1"} {
+		_, err := ParsePolicies(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestPolicy_Matches(t *testing.T) {
+	policy := Policy{Method: "GET", Pattern: "/products"}
+	assert.True(t, policy.Matches("GET", "/products"))
+	assert.True(t, policy.Matches("GET", "/products/{id}"))
+	assert.False(t, policy.Matches("POST", "/products"))
+	assert.False(t, policy.Matches("GET", "/productsx"))
+	assert.True(t, Policy{Pattern: "*"}.Matches("DELETE", "/anything"))
+}
+
+func TestMemoryStore_TokenBucket(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		result, _ := store.Take(context.Background(), "k", 2, time.Minute)
+		assert.True(t, result.Allowed)
+	}
+
+	result, _ := store.Take(context.Background(), "k", 2, time.Minute)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, 30*time.Second, result.RetryAfter)
+
+	// Meio período depois um token foi reposto
+	now = now.Add(30 * time.Second)
+	result, _ = store.Take(context.Background(), "k", 2, time.Minute)
+	assert.True(t, result.Allowed)
+
+	now = now.Add(time.Hour)
+	store.Cleanup(time.Minute)
+	assert.Empty(t, store.buckets)
+}
+
+func TestLimiter_Middleware(t *testing.T) {
+	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	policies, _ := ParsePolicies("POST /users/login=ip:2/1m;/products=user:1/1m")
+	limiter := NewLimiter(NewMemoryStore(), policies, tokenAuth, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	route := chi.NewRouter()
+	route.Use(limiter.Middleware)
+	ok := func(response http.ResponseWriter, request *http.Request) {}
+	route.Post("/users/login", ok)
+	route.Get("/products/{id}", ok)
+	route.Get("/healthz", ok)
+
+	do := func(method, target, token string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, target, nil)
+		request.RemoteAddr = "10.0.0.1:1234"
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		route.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := do(http.MethodPost, "/users/login", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "2", recorder.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", recorder.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2;w=60", recorder.Header().Get("RateLimit-Policy"))
+
+	do(http.MethodPost, "/users/login", "")
+	recorder = do(http.MethodPost, "/users/login", "")
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "30", recorder.Header().Get("Retry-After"))
+
+	// Cada usuário tem o próprio bucket, mesmo vindo do mesmo IP
+	_, alice, _ := tokenAuth.Encode(map[string]interface{}{"sub": "alice"})
+	_, bob, _ := tokenAuth.Encode(map[string]interface{}{"sub": "bob"})
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/products/1", alice).Code)
+	assert.Equal(t, http.StatusTooManyRequests, do(http.MethodGet, "/products/2", alice).Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/products/1", bob).Code)
+
+	// Rotas sem política não são limitadas
+	recorder = do(http.MethodGet, "/healthz", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("RateLimit-Limit"))
+}
+
+func TestLimiter_APIKey(t *testing.T) {
+	policies, _ := ParsePolicies("*=api_key:1/1m")
+	limiter := NewLimiter(NewMemoryStore(), policies, nil, slog.New(slog.NewTextHandler(io.Discard, nil))).WithAPIKeys([]string{"alpha", "beta"})
+
+	route := chi.NewRouter()
+	route.Use(limiter.Middleware)
+	route.Get("/products", func(response http.ResponseWriter, request *http.Request) {})
+
+	do := func(apiKey string) int {
+		request := httptest.NewRequest(http.MethodGet, "/products", nil)
+		request.RemoteAddr = "10.0.0.1:1234"
+		request.Header.Set(APIKeyHeader, apiKey)
+		recorder := httptest.NewRecorder()
+		route.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	// Cada chave conhecida tem o próprio bucket
+	assert.Equal(t, http.StatusOK, do("alpha"))
+	assert.Equal(t, http.StatusTooManyRequests, do("alpha"))
+	assert.Equal(t, http.StatusOK, do("beta"))
+
+	// Chaves desconhecidas dividem o bucket do IP
+	assert.Equal(t, http.StatusOK, do("random-1"))
+	assert.Equal(t, http.StatusTooManyRequests, do("random-2"))
+}
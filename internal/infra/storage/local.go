@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Local guarda os objetos em um diretório do sistema de arquivos. Os arquivos
+// podem ser servidos pelo próprio servidor HTTP com Handler.
+type Local struct {
+	Root    string
+	BaseURL string
+}
+
+func NewLocal(root, baseURL string) *Local {
+	return &Local{
+		Root:    root,
+		BaseURL: baseURL,
+	}
+}
+
+func (l *Local) path(key string) (string, error) {
+	if !validKey(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(l.Root, filepath.FromSlash(key)), nil
+}
+
+// Put escreve num arquivo temporário e renomeia, para que leitores nunca vejam
+// um objeto pela metade.
+func (l *Local) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(file.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) URL(key string) string {
+	return joinURL(l.BaseURL, key)
+}
+
+// Handler serve os objetos pela chave relativa ao caminho da requisição. Deve
+// ser montado com http.StripPrefix no caminho de BaseURL. Diretórios não são
+// listados.
+func (l *Local) Handler() http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		path, err := l.path(strings.TrimPrefix(request.URL.Path, "/"))
+		if err != nil {
+			http.NotFound(response, request)
+			return
+		}
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			http.NotFound(response, request)
+			return
+		}
+		// Os objetos nunca mudam de conteúdo: uma nova imagem ganha uma nova chave
+		response.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		response.Header().Set("X-Content-Type-Options", "nosniff")
+		http.ServeFile(response, request, path)
+	})
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	local := NewLocal(t.TempDir(), "/media/")
+
+	assert.NoError(t, local.Put(ctx, "products/1/original.png", []byte("image"), "image/png"))
+	assert.Equal(t, "/media/products/1/original.png", local.URL("products/1/original.png"))
+
+	reader, err := local.Get(ctx, "products/1/original.png")
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	assert.Equal(t, "image", string(data))
+
+	recorder := httptest.NewRecorder()
+	http.StripPrefix("/media", local.Handler()).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/media/products/1/original.png", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "image", recorder.Body.String())
+
+	// Diretórios não são listados
+	recorder = httptest.NewRecorder()
+	http.StripPrefix("/media", local.Handler()).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/media/products/1", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	assert.NoError(t, local.Delete(ctx, "products/1/original.png"))
+	assert.NoError(t, local.Delete(ctx, "products/1/original.png"))
+	_, err = local.Get(ctx, "products/1/original.png")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	for _, key := range []string{"", "/etc/passwd", "../secret", "products/../../secret", "products//1"} {
+		assert.ErrorIs(t, local.Put(ctx, key, nil, "text/plain"), ErrInvalidKey, key)
+	}
+}
@@ -0,0 +1,45 @@
+// Package storage guarda arquivos binários (imagens e outros anexos) fora do
+// banco de dados. Cada implementação endereça os objetos por uma chave no
+// formato de caminho, como "products/{id}/images/{id}/original.jpg".
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("storage: object not found")
+	ErrInvalidKey = errors.New("storage: invalid key")
+)
+
+// Storage é o contrato dos repositórios de arquivos.
+type Storage interface {
+	// Put grava o objeto, substituindo o anterior com a mesma chave.
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get abre o objeto para leitura ou retorna ErrNotFound.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete remove o objeto. Remover uma chave inexistente não é erro.
+	Delete(ctx context.Context, key string) error
+	// URL retorna o endereço público do objeto.
+	URL(key string) string
+}
+
+// validKey rejeita chaves vazias, absolutas ou que saiam da raiz com "..".
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func joinURL(base, key string) string {
+	return strings.TrimRight(base, "/") + "/" + key
+}
@@ -0,0 +1,66 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const spanKey = "tracing:span"
+
+// InstrumentDB registra callbacks no gorm que criam um span por comando SQL,
+// filho do span presente no contexto da consulta (db.WithContext).
+func InstrumentDB(db *gorm.DB, system string) error {
+	register := func(operation string, before, after func(string, func(*gorm.DB)) error) error {
+		if err := before("tracing:before_"+operation, startSpan(operation, system)); err != nil {
+			return err
+		}
+		return after("tracing:after_"+operation, endSpan)
+	}
+
+	callback := db.Callback()
+	return errors.Join(
+		register("create", callback.Create().Before("gorm:create").Register, callback.Create().After("gorm:create").Register),
+		register("query", callback.Query().Before("gorm:query").Register, callback.Query().After("gorm:query").Register),
+		register("update", callback.Update().Before("gorm:update").Register, callback.Update().After("gorm:update").Register),
+		register("delete", callback.Delete().Before("gorm:delete").Register, callback.Delete().After("gorm:delete").Register),
+		register("row", callback.Row().Before("gorm:row").Register, callback.Row().After("gorm:row").Register),
+		register("raw", callback.Raw().Before("gorm:raw").Register, callback.Raw().After("gorm:raw").Register),
+	)
+}
+
+func startSpan(operation, system string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		_, span := Start(db.Statement.Context, "gorm."+operation,
+			semconv.DBSystemKey.String(system),
+			semconv.DBOperationName(operation),
+		)
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+func endSpan(db *gorm.DB) {
+	value, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+
+	span.SetAttributes(
+		semconv.DBQueryText(db.Statement.SQL.String()),
+		semconv.DBCollectionName(db.Statement.Table),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+
+	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	End(span, err)
+}
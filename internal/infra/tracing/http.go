@@ -0,0 +1,84 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type parentSpanKey struct{}
+
+// Middleware extrai o contexto W3C traceparent da requisição e cria um span
+// nomeado pelo padrão da rota do chi (ex.: "GET /products/{id}").
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(request.Context(), propagation.HeaderCarrier(request.Header))
+		ctx, span := otel.Tracer(instrumentationName).Start(ctx, request.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(request.Method),
+				semconv.URLPath(request.URL.Path),
+			),
+		)
+		defer span.End()
+
+		wrapped := middleware.NewWrapResponseWriter(response, request.ProtoMajor)
+		next.ServeHTTP(wrapped, request.WithContext(ctx))
+
+		if routeContext := chi.RouteContext(request.Context()); routeContext != nil {
+			if pattern := routeContext.RoutePattern(); pattern != "" {
+				span.SetName(request.Method + " " + pattern)
+				span.SetAttributes(semconv.HTTPRoute(pattern))
+			}
+		}
+
+		status := wrapped.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// Traced mede apenas o tempo gasto no middleware informado (ex.: verificação
+// do JWT). O span termina quando o middleware repassa a requisição adiante, e
+// os próximos handlers voltam a ser filhos do span original.
+func Traced(name string, mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		inner := mw(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			trace.SpanFromContext(request.Context()).End()
+			ctx := request.Context()
+			if parent, ok := ctx.Value(parentSpanKey{}).(trace.Span); ok {
+				ctx = trace.ContextWithSpan(ctx, parent)
+			}
+			next.ServeHTTP(response, request.WithContext(ctx))
+		}))
+
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			parent := trace.SpanFromContext(request.Context())
+			ctx := context.WithValue(request.Context(), parentSpanKey{}, parent)
+			ctx, span := Start(ctx, name)
+			defer span.End()
+			inner.ServeHTTP(response, request.WithContext(ctx))
+		})
+	}
+}
+
+// HandlerFunc envolve um handler em um span próprio, filho do span da rota.
+func HandlerFunc(name string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		ctx, span := Start(request.Context(), name)
+		defer span.End()
+		handler(response, request.WithContext(ctx))
+	}
+}
@@ -0,0 +1,100 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func spanByName(spans []sdktrace.ReadOnlySpan, name string) sdktrace.ReadOnlySpan {
+	for _, span := range spans {
+		if span.Name() == name {
+			return span
+		}
+	}
+	return nil
+}
+
+func TestMiddleware_PropagatesTraceparentAndNamesRoute(t *testing.T) {
+	recorder := newRecorder(t)
+	_, err := Setup(context.Background(), Options{Exporter: ExporterNone})
+	assert.NoError(t, err)
+
+	passThrough := func(next http.Handler) http.Handler { return next }
+
+	route := chi.NewRouter()
+	route.Use(Middleware)
+	route.Route("/products", func(chiRoute chi.Router) {
+		chiRoute.Use(Traced("jwt.verify", passThrough))
+		chiRoute.Get("/{id}", HandlerFunc("ProductHandler.GetProduct", func(response http.ResponseWriter, request *http.Request) {
+			response.WriteHeader(http.StatusNotFound)
+		}))
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/products/123", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	route.ServeHTTP(httptest.NewRecorder(), request)
+
+	spans := recorder.Ended()
+	server := spanByName(spans, "GET /products/{id}")
+	if !assert.NotNil(t, server) {
+		return
+	}
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+
+	verify := spanByName(spans, "jwt.verify")
+	handler := spanByName(spans, "ProductHandler.GetProduct")
+	if !assert.NotNil(t, verify) || !assert.NotNil(t, handler) {
+		return
+	}
+	assert.Equal(t, server.SpanContext().SpanID(), verify.Parent().SpanID())
+	// O handler é irmão do span do middleware e não filho dele
+	assert.Equal(t, server.SpanContext().SpanID(), handler.Parent().SpanID())
+}
+
+func TestSetup_StdoutExporterWritesToFile(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	file := filepath.Join(t.TempDir(), "traces.json")
+	shutdown, err := Setup(context.Background(), Options{
+		ServiceName: "go-products-test",
+		Exporter:    ExporterStdout,
+		File:        file,
+		SampleRatio: 1,
+	})
+	assert.NoError(t, err)
+
+	_, span := Start(context.Background(), "offline-span")
+	span.End()
+	assert.NoError(t, shutdown(context.Background()))
+
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "offline-span")
+	assert.Contains(t, string(content), "go-products-test")
+}
+
+func TestSetup_UnknownExporter(t *testing.T) {
+	_, err := Setup(context.Background(), Options{Exporter: "zipkin"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,410 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/otthonleao/go-products.git/internal/dto"
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/database"
+	"github.com/otthonleao/go-products.git/internal/infra/webserver/middlewares"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/otthonleao/go-products.git/pkg/validation"
+)
+
+// CartTokenHeader carrega o token de acesso de carrinhos anônimos.
+const CartTokenHeader = "X-Cart-Token"
+
+// CartHandler atende os carrinhos de compra. As rotas aceitam usuários
+// autenticados e anônimos; os preços são sempre lidos do catálogo no servidor.
+type CartHandler struct {
+	cartDB         database.CartInterface
+	productDB      database.ProductInterface
+	variantDB      database.ProductVariantInterface
+	organizationDB database.OrganizationInterface
+	// ttl é o tempo sem alterações depois do qual o carrinho é abandonado
+	ttl time.Duration
+}
+
+func NewCartHandler(cartDB database.CartInterface, productDB database.ProductInterface, variantDB database.ProductVariantInterface, organizationDB database.OrganizationInterface, ttl time.Duration) *CartHandler {
+	return &CartHandler{
+		cartDB:         cartDB,
+		productDB:      productDB,
+		variantDB:      variantDB,
+		organizationDB: organizationDB,
+		ttl:            ttl,
+	}
+}
+
+// routeCart carrega o carrinho da rota e confere o acesso: carrinhos de
+// usuário exigem o token do dono e os anônimos o X-Cart-Token. Carrinhos
+// vencidos, inexistentes ou de outra pessoa respondem 404.
+func (handler *CartHandler) routeCart(response http.ResponseWriter, request *http.Request) (*entity.Cart, bool) {
+	cart, err := handler.cartDB.WithContext(request.Context()).FindByID(chi.URLParam(request, "id"))
+	if err != nil || cart.Expired(time.Now()) {
+		response.WriteHeader(http.StatusNotFound)
+		return nil, false
+	}
+
+	if !canAccessCart(request, cart) {
+		response.WriteHeader(http.StatusNotFound)
+		return nil, false
+	}
+	return cart, true
+}
+
+// canAccessCart confere o dono do carrinho ou, nos anônimos, o X-Cart-Token.
+func canAccessCart(request *http.Request, cart *entity.Cart) bool {
+	if cart.UserID != nil {
+		identity, ok := middlewares.IdentityFromContext(request.Context())
+		return ok && identity.UserID == cart.UserID.String()
+	}
+	return cart.CheckToken(request.Header.Get(CartTokenHeader))
+}
+
+// availableProduct carrega o produto publicado da organização e, se
+// informada, a variante que vão para um carrinho ou pedido. Produtos com
+// variantes só entram com uma delas. Indisponibilidades voltam como
+// validation.Errors e falhas do banco como error.
+func availableProduct(request *http.Request, productDB database.ProductInterface, variantDB database.ProductVariantInterface, organizationID, productID, variantID string) (*entity.Product, *entity.ProductVariant, validation.Errors, error) {
+	product, err := productDB.WithContext(request.Context()).ForTenant(organizationID).FindByID(productID)
+	if err != nil || product.Status != entity.StatusPublished {
+		return nil, nil, validation.Errors{"product_id": {"product is not available"}}, nil
+	}
+
+	variants := variantDB.WithContext(request.Context())
+	if variantID == "" {
+		count, err := variants.Count(product.ID.String())
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if count > 0 {
+			return nil, nil, validation.Errors{"variant_id": {"is required for products with variants"}}, nil
+		}
+		return product, nil, nil, nil
+	}
+
+	variant, err := variants.FindByID(product.ID.String(), variantID)
+	if err != nil {
+		return nil, nil, validation.Errors{"variant_id": {"variant is not available"}}, nil
+	}
+	return product, variant, nil, nil
+}
+
+// checkStock confere se há estoque para quantity unidades do produto ou da variante.
+func checkStock(product *entity.Product, variant *entity.ProductVariant, quantity int) validation.Errors {
+	available := product.InStock(quantity)
+	if variant != nil {
+		available = variant.Stock >= quantity
+	}
+	if !available {
+		return validation.Errors{"quantity": {"exceeds the available stock"}}
+	}
+	return nil
+}
+
+// stockLimit retorna quantas unidades do produto ou da variante cabem numa
+// linha do carrinho.
+func stockLimit(product *entity.Product, variant *entity.ProductVariant) int {
+	if variant != nil {
+		return variant.Stock
+	}
+	if product.Stock == nil {
+		return entity.MaxCartItemQuantity
+	}
+	return *product.Stock
+}
+
+// cartItem retorna a linha do carrinho com o ID informado.
+func cartItem(cart *entity.Cart, id string) *entity.CartItem {
+	for i := range cart.Items {
+		if cart.Items[i].ID.String() == id {
+			return &cart.Items[i]
+		}
+	}
+	return nil
+}
+
+func writeCart(response http.ResponseWriter, status int, cart *entity.Cart) {
+	cart.Totals()
+	response.Header().Set("Content-Type", "application/json")
+	response.Header().Set("Cache-Control", "no-store")
+	response.WriteHeader(status)
+	json.NewEncoder(response).Encode(cart)
+}
+
+// Create Cart godoc
+// @Summary     Create a cart
+// @Description Create a cart in an organization. With a bearer token the cart belongs to the user; without one the cart is anonymous and the response carries the token to send in X-Cart-Token, returned only once. Carts expire after a period without changes.
+// @Tags        carts
+// @Accept      json
+// @Produce     json
+// @Param       request     body    dto.CreateCartInput     true    "Cart request"
+// @Success     201		{object}    entity.Cart
+// @Header      201		{string}    Location    "URL of the created cart"
+// @Failure     400		{object}    Error
+// @Failure     401		{object}    Error
+// @Failure     415		{object}    Error
+// @Failure     422		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /carts    [post]
+func (handler *CartHandler) Create(response http.ResponseWriter, request *http.Request) {
+	var input dto.CreateCartInput
+	err := decodeJSON(request, &input)
+	if err != nil {
+		writeDecodeError(response, err)
+		return
+	}
+	err = validation.Validate(input)
+	if err != nil {
+		writeValidationError(response, err)
+		return
+	}
+
+	organization, err := handler.organizationDB.WithContext(request.Context()).FindByID(input.OrganizationID)
+	if err != nil {
+		writeValidationError(response, validation.Errors{"organization_id": {"organization not found"}})
+		return
+	}
+
+	var userID *entityPkg.ID
+	if identity, ok := middlewares.IdentityFromContext(request.Context()); ok {
+		id, err := entityPkg.ParseID(identity.UserID)
+		if err != nil {
+			response.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		userID = &id
+	}
+
+	cart, err := entity.NewCart(organization.ID, userID, handler.ttl)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	err = handler.cartDB.WithContext(request.Context()).Create(cart)
+	if err != nil {
+		writeRepositoryError(response, err)
+		return
+	}
+
+	response.Header().Set("Location", "/carts/"+cart.ID.String())
+	writeCart(response, http.StatusCreated, cart)
+}
+
+// Get Cart godoc
+// @Summary     Get a cart
+// @Description Get a cart with its items and totals. Totals are computed in cents from the prices captured when each item was added.
+// @Tags        carts
+// @Accept      json
+// @Produce     json
+// @Param       id             path      string     true     "Cart ID"		Format(uuid)
+// @Param       X-Cart-Token   header    string     false    "Token of an anonymous cart"
+// @Success     200		{object}    entity.Cart
+// @Failure     401		{object}    Error
+// @Failure     404
+// @Router      /carts/{id}    [get]
+func (handler *CartHandler) GetCart(response http.ResponseWriter, request *http.Request) {
+	cart, ok := handler.routeCart(response, request)
+	if !ok {
+		return
+	}
+	writeCart(response, http.StatusOK, cart)
+}
+
+// Add Cart Item godoc
+// @Summary     Add an item to a cart
+// @Description Add a published product, or one of its variants, to the cart. The name and price are captured from the catalog and kept while the item stays in the cart. Adding a product already in the cart increases the quantity of its item. The quantity must fit the available stock.
+// @Tags        carts
+// @Accept      json
+// @Produce     json
+// @Param       id             path      string     true     "Cart ID"		Format(uuid)
+// @Param       X-Cart-Token   header    string     false    "Token of an anonymous cart"
+// @Param       request        body      dto.AddCartItemInput     true    "Item request"
+// @Success     200		{object}    entity.Cart
+// @Failure     400		{object}    Error
+// @Failure     401		{object}    Error
+// @Failure     404
+// @Failure     415		{object}    Error
+// @Failure     422		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /carts/{id}/items    [post]
+func (handler *CartHandler) AddItem(response http.ResponseWriter, request *http.Request) {
+	cart, ok := handler.routeCart(response, request)
+	if !ok {
+		return
+	}
+
+	var input dto.AddCartItemInput
+	err := decodeJSON(request, &input)
+	if err != nil {
+		writeDecodeError(response, err)
+		return
+	}
+	err = validation.Validate(input)
+	if err != nil {
+		writeValidationError(response, err)
+		return
+	}
+
+	product, variant, invalid, err := availableProduct(request, handler.productDB, handler.variantDB, cart.OrganizationID.String(), input.ProductID, input.VariantID)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if invalid != nil {
+		writeValidationError(response, invalid)
+		return
+	}
+
+	// A soma com a linha existente e os limites são conferidos pelo repositório,
+	// com as linhas relidas na transação
+	cart.Touch(handler.ttl)
+	err = handler.cartDB.WithContext(request.Context()).AddItem(cart, product, variant, input.Quantity, stockLimit(product, variant))
+	if errors.Is(err, entity.ErrInsufficientStock) {
+		writeValidationError(response, validation.Errors{"quantity": {"exceeds the available stock"}})
+		return
+	}
+	if errors.Is(err, entity.ErrInvalidQuantity) || errors.Is(err, entity.ErrTooManyCartItems) {
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		writeRepositoryError(response, err)
+		return
+	}
+	writeCart(response, http.StatusOK, cart)
+}
+
+// Update Cart Item godoc
+// @Summary     Change the quantity of a cart item
+// @Description Set the quantity of an item. The captured price does not change; the quantity must fit the current stock.
+// @Tags        carts
+// @Accept      json
+// @Produce     json
+// @Param       id             path      string     true     "Cart ID"		Format(uuid)
+// @Param       itemID         path      string     true     "Item ID"		Format(uuid)
+// @Param       X-Cart-Token   header    string     false    "Token of an anonymous cart"
+// @Param       request        body      dto.UpdateCartItemInput     true    "Quantity request"
+// @Success     200		{object}    entity.Cart
+// @Failure     400		{object}    Error
+// @Failure     401		{object}    Error
+// @Failure     404
+// @Failure     415		{object}    Error
+// @Failure     422		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /carts/{id}/items/{itemID}    [put]
+func (handler *CartHandler) UpdateItem(response http.ResponseWriter, request *http.Request) {
+	cart, ok := handler.routeCart(response, request)
+	if !ok {
+		return
+	}
+
+	item := cartItem(cart, chi.URLParam(request, "itemID"))
+	if item == nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var input dto.UpdateCartItemInput
+	err := decodeJSON(request, &input)
+	if err != nil {
+		writeDecodeError(response, err)
+		return
+	}
+	err = validation.Validate(input)
+	if err != nil {
+		writeValidationError(response, err)
+		return
+	}
+
+	variantID := ""
+	if item.VariantID != nil {
+		variantID = item.VariantID.String()
+	}
+	product, variant, invalid, err := availableProduct(request, handler.productDB, handler.variantDB, cart.OrganizationID.String(), item.ProductID.String(), variantID)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if invalid == nil {
+		invalid = checkStock(product, variant, input.Quantity)
+	}
+	if invalid != nil {
+		writeValidationError(response, invalid)
+		return
+	}
+
+	if err := item.SetQuantity(input.Quantity); err != nil {
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+	cart.Touch(handler.ttl)
+	err = handler.cartDB.WithContext(request.Context()).UpdateItem(cart, item)
+	if err != nil {
+		writeRepositoryError(response, err)
+		return
+	}
+	writeCart(response, http.StatusOK, cart)
+}
+
+// Delete Cart Item godoc
+// @Summary     Remove an item from a cart
+// @Tags        carts
+// @Param       id             path      string     true     "Cart ID"		Format(uuid)
+// @Param       itemID         path      string     true     "Item ID"		Format(uuid)
+// @Param       X-Cart-Token   header    string     false    "Token of an anonymous cart"
+// @Success     204
+// @Failure     401		{object}    Error
+// @Failure     404
+// @Failure     500
+// @Router      /carts/{id}/items/{itemID}    [delete]
+func (handler *CartHandler) DeleteItem(response http.ResponseWriter, request *http.Request) {
+	cart, ok := handler.routeCart(response, request)
+	if !ok {
+		return
+	}
+
+	itemID := chi.URLParam(request, "itemID")
+	if cartItem(cart, itemID) == nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	cart.Touch(handler.ttl)
+	if err := handler.cartDB.WithContext(request.Context()).DeleteItem(cart, itemID); err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+// Delete Cart godoc
+// @Summary     Delete a cart
+// @Tags        carts
+// @Param       id             path      string     true     "Cart ID"		Format(uuid)
+// @Param       X-Cart-Token   header    string     false    "Token of an anonymous cart"
+// @Success     204
+// @Failure     401		{object}    Error
+// @Failure     404
+// @Failure     500
+// @Router      /carts/{id}    [delete]
+func (handler *CartHandler) DeleteCart(response http.ResponseWriter, request *http.Request) {
+	cart, ok := handler.routeCart(response, request)
+	if !ok {
+		return
+	}
+
+	if err := handler.cartDB.WithContext(request.Context()).Delete(cart.ID.String()); err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/database"
+	"github.com/otthonleao/go-products.git/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+// doCartRequest é doRequest com o token de carrinho anônimo.
+func doCartRequest(handler http.Handler, method, target, cartToken, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(CartTokenHeader, cartToken)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestCartHandler(t *testing.T) {
+	router, productDB := newProductRouter(t)
+	organization, _ := entity.NewOrganization("Acme")
+	assert.NoError(t, database.NewOrganization(productDB.DB).Create(organization))
+	admin := tokenWithRole(t, organization.ID.String(), entity.RoleAdmin)
+
+	create := func(body string, publish bool) entity.Product {
+		recorder := doRequest(router, http.MethodPost, "/products", admin, body)
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+		var product entity.Product
+		json.Unmarshal(recorder.Body.Bytes(), &product)
+		for _, status := range []string{entity.StatusInReview, entity.StatusPublished} {
+			if publish {
+				doRequest(router, http.MethodPost, "/products/"+product.ID.String()+"/transitions", admin, `{"to": "`+status+`"}`)
+			}
+		}
+		return product
+	}
+	pen := create(`{"name": "Pen", "price": 0.1, "stock": 5}`, true)
+	shirt := create(`{"name": "Shirt", "price": 19.99, "option_axes": ["size"]}`, true)
+	draft := create(`{"name": "Draft", "price": 10}`, false)
+	recorder := doRequest(router, http.MethodPost, "/products/"+shirt.ID.String()+"/variants", admin, `{"options": {"size": "M"}, "price": 24.99, "stock": 2}`)
+	var variant entity.ProductVariant
+	json.Unmarshal(recorder.Body.Bytes(), &variant)
+
+	recorder = doCartRequest(router, http.MethodPost, "/carts", "", `{"organization_id": "`+pen.ID.String()+`"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+	// Sem token o carrinho é anônimo e o acesso é pelo X-Cart-Token
+	recorder = doCartRequest(router, http.MethodPost, "/carts", "", `{"organization_id": "`+organization.ID.String()+`"}`)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	var cart entity.Cart
+	json.Unmarshal(recorder.Body.Bytes(), &cart)
+	assert.NotEmpty(t, cart.Token)
+	cartURL := "/carts/" + cart.ID.String()
+	assert.Equal(t, cartURL, recorder.Header().Get("Location"))
+
+	assert.Equal(t, http.StatusNotFound, doCartRequest(router, http.MethodGet, cartURL, "wrong", "").Code)
+	recorder = doCartRequest(router, http.MethodGet, cartURL, cart.Token, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), cart.Token)
+
+	addItem := func(body string) *httptest.ResponseRecorder {
+		return doCartRequest(router, http.MethodPost, cartURL+"/items", cart.Token, body)
+	}
+	// O preço nunca vem do cliente
+	recorder = addItem(`{"product_id": "` + pen.ID.String() + `", "quantity": 3, "price": 0.01}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = addItem(`{"product_id": "` + pen.ID.String() + `", "quantity": 3}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = addItem(`{"product_id": "` + pen.ID.String() + `", "quantity": 2}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// A soma com a linha existente passa do estoque
+	recorder = addItem(`{"product_id": "` + pen.ID.String() + `", "quantity": 1}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "exceeds the available stock")
+
+	recorder = addItem(`{"product_id": "` + shirt.ID.String() + `", "quantity": 1}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "variant_id")
+	recorder = addItem(`{"product_id": "` + draft.ID.String() + `", "quantity": 1}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	recorder = addItem(`{"product_id": "` + pen.ID.String() + `", "quantity": 0}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+	recorder = addItem(`{"product_id": "` + shirt.ID.String() + `", "variant_id": "` + variant.ID.String() + `", "quantity": 2}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &cart)
+	assert.Len(t, cart.Items, 2)
+	assert.Equal(t, 5, cart.Items[0].Quantity)
+	assert.Equal(t, money.Cents(10), cart.Items[0].UnitPrice)
+	assert.Equal(t, money.Cents(2499), cart.Items[1].UnitPrice)
+	assert.Equal(t, money.Cents(5048), cart.Subtotal)
+	assert.Equal(t, 7, cart.ItemCount)
+
+	// Mudanças de preço não alteram as linhas já criadas
+	recorder = doRequest(router, http.MethodPut, "/products/"+pen.ID.String(), admin, `{"name": "Pen", "price": 1, "stock": 5}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	itemURL := cartURL + "/items/" + cart.Items[0].ID.String()
+	recorder = doCartRequest(router, http.MethodPut, itemURL, cart.Token, `{"quantity": 6}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	recorder = doCartRequest(router, http.MethodPut, itemURL, cart.Token, `{"quantity": 4}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &cart)
+	assert.Equal(t, money.Cents(10), cart.Items[0].UnitPrice)
+	assert.Equal(t, money.Cents(5038), cart.Subtotal)
+
+	assert.Equal(t, http.StatusNoContent, doCartRequest(router, http.MethodDelete, itemURL, cart.Token, "").Code)
+	assert.Equal(t, http.StatusNotFound, doCartRequest(router, http.MethodDelete, itemURL, cart.Token, "").Code)
+	assert.Equal(t, http.StatusNoContent, doCartRequest(router, http.MethodDelete, cartURL, cart.Token, "").Code)
+	assert.Equal(t, http.StatusNotFound, doCartRequest(router, http.MethodGet, cartURL, cart.Token, "").Code)
+}
+
+func TestCartHandler_UserCart(t *testing.T) {
+	router, productDB := newProductRouter(t)
+	organization, _ := entity.NewOrganization("Acme")
+	assert.NoError(t, database.NewOrganization(productDB.DB).Create(organization))
+	owner := tokenFor(t, "")
+
+	recorder := doRequest(router, http.MethodPost, "/carts", owner, `{"organization_id": "`+organization.ID.String()+`"}`)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	var cart entity.Cart
+	json.Unmarshal(recorder.Body.Bytes(), &cart)
+	assert.Empty(t, cart.Token)
+	assert.NotNil(t, cart.UserID)
+	cartURL := "/carts/" + cart.ID.String()
+
+	assert.Equal(t, http.StatusOK, doRequest(router, http.MethodGet, cartURL, owner, "").Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(router, http.MethodGet, cartURL, tokenFor(t, ""), "").Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(router, http.MethodGet, cartURL, "", "").Code)
+
+	// Tokens inválidos não viram acesso anônimo
+	assert.Equal(t, http.StatusUnauthorized, doRequest(router, http.MethodGet, cartURL, "invalid", "").Code)
+}
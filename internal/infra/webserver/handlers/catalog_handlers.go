@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/otthonleao/go-products.git/internal/dto"
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/database"
+	"github.com/otthonleao/go-products.git/internal/infra/storage"
+)
+
+// Paginação do catálogo: sem limite explícito as páginas têm catalogDefaultLimit
+// produtos e nunca mais que catalogMaxLimit, já que a rota é pública.
+const (
+	catalogDefaultLimit = 20
+	catalogMaxLimit     = 100
+)
+
+// CatalogHandler expõe, sem autenticação, os produtos publicados de uma
+// organização numa representação reduzida e cacheável por CDNs e proxies.
+type CatalogHandler struct {
+	productDB      database.ProductInterface
+	variantDB      database.ProductVariantInterface
+	imageDB        database.ProductImageInterface
+	organizationDB database.OrganizationInterface
+	blobs          storage.Storage
+	// cacheControl é o Cache-Control público das respostas
+	cacheControl string
+}
+
+func NewCatalogHandler(productDB database.ProductInterface, variantDB database.ProductVariantInterface, imageDB database.ProductImageInterface, organizationDB database.OrganizationInterface, blobs storage.Storage, maxAge, staleWhileRevalidate time.Duration) *CatalogHandler {
+	return &CatalogHandler{
+		productDB:      productDB,
+		variantDB:      variantDB,
+		imageDB:        imageDB,
+		organizationDB: organizationDB,
+		blobs:          blobs,
+		cacheControl: fmt.Sprintf("public, max-age=%d, stale-while-revalidate=%d",
+			int(maxAge.Seconds()), int(staleWhileRevalidate.Seconds())),
+	}
+}
+
+// products retorna o repositório restrito à organização da rota, respondendo
+// 404 quando ela não existe.
+func (handler *CatalogHandler) products(response http.ResponseWriter, request *http.Request) (database.ProductInterface, bool) {
+	organizationID := chi.URLParam(request, "organizationID")
+	if _, err := handler.organizationDB.WithContext(request.Context()).FindByID(organizationID); err != nil {
+		response.WriteHeader(http.StatusNotFound)
+		return nil, false
+	}
+	return handler.productDB.WithContext(request.Context()).ForTenant(organizationID), true
+}
+
+// write responde com o Cache-Control público e a validação condicional.
+func (handler *CatalogHandler) write(response http.ResponseWriter, request *http.Request, lastModified time.Time, useModifiedSince bool, payload interface{}) {
+	response.Header().Set("Cache-Control", handler.cacheControl)
+	writeConditional(response, request, lastModified, useModifiedSince, payload)
+}
+
+// catalogProducts carrega imagens e variantes e monta a representação pública
+// dos produtos. As variantes sempre são lidas, já que o estoque de um produto
+// com variantes vem delas, mas só aparecem na resposta quando pedidas. Retorna
+// também a alteração mais recente.
+func (handler *CatalogHandler) catalogProducts(request *http.Request, products []entity.Product) ([]dto.CatalogProduct, time.Time, error) {
+	pointers := make([]*entity.Product, 0, len(products))
+	ids := make([]string, 0, len(products))
+	for i := range products {
+		pointers = append(pointers, &products[i])
+		ids = append(ids, products[i].ID.String())
+	}
+	if err := loadImages(handler.imageDB.WithContext(request.Context()), handler.blobs, pointers...); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var variants map[string][]entity.ProductVariant
+	if len(ids) > 0 {
+		var err error
+		variants, err = handler.variantDB.WithContext(request.Context()).FindByProducts(ids)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+	}
+	withVariants := includeVariants(request)
+
+	var lastModified time.Time
+	catalog := make([]dto.CatalogProduct, 0, len(products))
+	for _, product := range pointers {
+		product.Variants = variants[product.ID.String()]
+		if product.LastModified().After(lastModified) {
+			lastModified = product.LastModified()
+		}
+		for _, variant := range product.Variants {
+			if variant.UpdatedAt.After(lastModified) {
+				lastModified = variant.UpdatedAt
+			}
+		}
+		catalog = append(catalog, catalogProduct(product, withVariants))
+	}
+	return catalog, lastModified, nil
+}
+
+// catalogProduct converte o produto, com as variantes já carregadas, para a
+// representação pública. Produtos com variantes estão em estoque quando alguma
+// delas está; withVariants inclui as variantes na resposta.
+func catalogProduct(product *entity.Product, withVariants bool) dto.CatalogProduct {
+	catalog := dto.CatalogProduct{
+		ID:          product.ID.String(),
+		Name:        product.Name,
+		Price:       product.Price,
+		Attributes:  product.Attributes,
+		Tags:        product.Tags,
+		InStock:     product.InStock(1) && len(product.Variants) == 0,
+		OptionAxes:  product.OptionAxes,
+		PublishedAt: product.PublishedAt,
+		UpdatedAt:   product.LastModified(),
+	}
+	if product.SKU != nil {
+		catalog.SKU = *product.SKU
+	}
+	if product.Barcode != nil {
+		catalog.Barcode = *product.Barcode
+	}
+	if product.CategoryID != nil {
+		catalog.CategoryID = product.CategoryID.String()
+	}
+
+	for _, variant := range product.Variants {
+		if variant.Stock > 0 {
+			catalog.InStock = true
+		}
+		if !withVariants {
+			continue
+		}
+		item := dto.CatalogVariant{
+			ID:      variant.ID.String(),
+			Options: variant.Options,
+			Price:   variant.EffectivePrice(product),
+			InStock: variant.Stock > 0,
+		}
+		if variant.SKU != nil {
+			item.SKU = *variant.SKU
+		}
+		catalog.Variants = append(catalog.Variants, item)
+	}
+
+	for _, image := range product.Images {
+		catalog.Images = append(catalog.Images, dto.CatalogImage{
+			URL:        image.URL,
+			Width:      image.Width,
+			Height:     image.Height,
+			Primary:    image.Primary,
+			Thumbnails: image.Thumbnails,
+		})
+	}
+	return catalog
+}
+
+// List Catalog Products godoc
+// @Summary     List published products
+// @Description Public, unauthenticated listing of the published products of an organization. Responses are cacheable by shared caches. Accepts the same filters and facets as GET /products; pages have at most 100 products.
+// @Tags        catalog
+// @Accept      json
+// @Produce     json
+// @Param       organizationID    path    string     true    "Organization ID"		Format(uuid)
+// @Param       page        query    int     false    "Page number"
+// @Param       limit       query    int     false    "Number of items per page (default 20, at most 100)"
+// @Param       include     query    string     false    "Set to variants to embed the variants of each product"	Enums(variants)
+// @Param       category_id query    string     false    "Only products of this category"	Format(uuid)
+// @Param       attr.name   query    string     false    "Filter by attribute, as attr.color=red or attr.weight_lt=2"
+// @Param       tags        query    string     false    "Comma-separated tags"
+// @Param       tags_match  query    string     false    "Match products with any (default) or all of the tags"	Enums(any, all)
+// @Param       facets      query    string     false    "Comma-separated counts to return with the products: tags, category, price. Changes the response to dto.CatalogListOutput"
+// @Param       price_buckets    query    string     false    "Comma-separated ascending limits of the price facet"
+// @Param       If-None-Match        header    string     false    "ETag of a cached representation"
+// @Success     200		{array}    dto.CatalogProduct
+// @Header      200		{string}    Cache-Control    "Public caching policy"
+// @Header      200		{string}    ETag             "Strong validator of the representation"
+// @Success     304
+// @Failure     400		{object}    Error
+// @Failure     404
+// @Failure     500		{object}    Error
+// @Router      /catalog/{organizationID}/products    [get]
+func (handler *CatalogHandler) GetProducts(response http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit < 1 {
+		limit = catalogDefaultLimit
+	}
+	limit = min(limit, catalogMaxLimit)
+
+	filter, err := parseProductFilter(query)
+	if err != nil {
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+	// Fora da organização só existem produtos publicados
+	filter.Statuses = []string{entity.StatusPublished}
+
+	facetOptions, withFacets, err := parseFacetOptions(query)
+	if err != nil {
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	products, ok := handler.products(response, request)
+	if !ok {
+		return
+	}
+	found, err := products.FindAllFiltered(page, limit, query.Get("sort"), filter)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	catalog, lastModified, err := handler.catalogProducts(request, found)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if withFacets {
+		facets, err := products.Facets(filter, facetOptions)
+		if err != nil {
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		handler.write(response, request, lastModified, false, dto.CatalogListOutput{Products: catalog, Facets: *facets})
+		return
+	}
+	handler.write(response, request, lastModified, false, catalog)
+}
+
+// Get Catalog Product godoc
+// @Summary     Get a published product
+// @Description Public, unauthenticated view of a published product. Products in any other status respond 404.
+// @Tags        catalog
+// @Accept      json
+// @Produce     json
+// @Param       organizationID    path    string     true    "Organization ID"		Format(uuid)
+// @Param       id          path    string     true    "Product ID"		Format(uuid)
+// @Param       include     query    string     false    "Set to variants to embed the product variants"	Enums(variants)
+// @Param       If-None-Match        header    string     false    "ETag of a cached representation"
+// @Param       If-Modified-Since    header    string     false    "Date of a cached representation"
+// @Success     200		{object}    dto.CatalogProduct
+// @Header      200		{string}    Cache-Control    "Public caching policy"
+// @Header      200		{string}    ETag             "Strong validator of the representation"
+// @Header      200		{string}    Last-Modified    "Last change of the product"
+// @Success     304
+// @Failure     404
+// @Failure     500		{object}    Error
+// @Router      /catalog/{organizationID}/products/{id}    [get]
+func (handler *CatalogHandler) GetProduct(response http.ResponseWriter, request *http.Request) {
+	products, ok := handler.products(response, request)
+	if !ok {
+		return
+	}
+
+	product, err := products.FindByID(chi.URLParam(request, "id"))
+	if err != nil || product.Status != entity.StatusPublished {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	catalog, lastModified, err := handler.catalogProducts(request, []entity.Product{*product})
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	handler.write(response, request, lastModified, true, catalog[0])
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/otthonleao/go-products.git/internal/dto"
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/database"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalogHandler(t *testing.T) {
+	router, productDB := newProductRouter(t)
+	organization, _ := entity.NewOrganization("Acme")
+	assert.NoError(t, database.NewOrganization(productDB.DB).Create(organization))
+	admin := tokenWithRole(t, organization.ID.String(), entity.RoleAdmin)
+
+	create := func(body string) entity.Product {
+		recorder := doRequest(router, http.MethodPost, "/products", admin, body)
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+		var product entity.Product
+		json.Unmarshal(recorder.Body.Bytes(), &product)
+		return product
+	}
+	publish := func(product entity.Product) {
+		for _, status := range []string{entity.StatusInReview, entity.StatusPublished} {
+			recorder := doRequest(router, http.MethodPost, "/products/"+product.ID.String()+"/transitions", admin, `{"to": "`+status+`"}`)
+			assert.Equal(t, http.StatusCreated, recorder.Code)
+		}
+	}
+	tent := create(`{"name": "Tent", "price": 120, "sku": "TENT-1", "tags": ["outdoor"], "option_axes": ["size"]}`)
+	publish(tent)
+	draft := create(`{"name": "Draft", "price": 10, "tags": ["outdoor"]}`)
+
+	recorder := doRequest(router, http.MethodPost, "/products/"+tent.ID.String()+"/variants", admin, `{"options": {"size": "L"}, "stock": 0}`)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	catalogURL := "/catalog/" + organization.ID.String() + "/products"
+
+	// Sem token e só com produtos publicados
+	recorder = doRequest(router, http.MethodGet, catalogURL+"?include=variants", "", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "public, max-age=60, stale-while-revalidate=300", recorder.Header().Get("Cache-Control"))
+	assert.NotEmpty(t, recorder.Header().Get("ETag"))
+	var products []dto.CatalogProduct
+	json.Unmarshal(recorder.Body.Bytes(), &products)
+	assert.Len(t, products, 1)
+	assert.Equal(t, "TENT-1", products[0].SKU)
+	assert.NotNil(t, products[0].PublishedAt)
+	assert.Len(t, products[0].Variants, 1)
+	assert.Equal(t, 120.0, products[0].Variants[0].Price)
+	assert.False(t, products[0].Variants[0].InStock)
+	// Sem estoque em nenhuma variante o produto também está sem estoque
+	assert.False(t, products[0].InStock)
+
+	// A representação pública não expõe dados internos
+	var raw []map[string]interface{}
+	json.Unmarshal(recorder.Body.Bytes(), &raw)
+	assert.NotContains(t, raw[0], "organization_id")
+	assert.NotContains(t, raw[0], "status")
+	assert.NotContains(t, raw[0]["variants"].([]interface{})[0], "stock")
+
+	request := httptest.NewRequest(http.MethodGet, catalogURL+"?include=variants", nil)
+	request.Header.Set("If-None-Match", recorder.Header().Get("ETag"))
+	conditional := httptest.NewRecorder()
+	router.ServeHTTP(conditional, request)
+	assert.Equal(t, http.StatusNotModified, conditional.Code)
+
+	recorder = doRequest(router, http.MethodGet, catalogURL, "", "")
+	var withoutVariants []dto.CatalogProduct
+	json.Unmarshal(recorder.Body.Bytes(), &withoutVariants)
+	assert.Empty(t, withoutVariants[0].Variants)
+	assert.False(t, withoutVariants[0].InStock)
+
+	recorder = doRequest(router, http.MethodPost, "/products/"+tent.ID.String()+"/variants", admin, `{"options": {"size": "M"}, "stock": 2}`)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	recorder = doRequest(router, http.MethodGet, catalogURL, "", "")
+	var restocked []dto.CatalogProduct
+	json.Unmarshal(recorder.Body.Bytes(), &restocked)
+	assert.True(t, restocked[0].InStock)
+
+	recorder = doRequest(router, http.MethodGet, catalogURL+"?facets=tags", "", "")
+	var list dto.CatalogListOutput
+	json.Unmarshal(recorder.Body.Bytes(), &list)
+	assert.Equal(t, []entity.FacetCount{{Value: "outdoor", Count: 1}}, list.Facets.Tags)
+
+	recorder = doRequest(router, http.MethodGet, catalogURL+"/"+tent.ID.String(), "", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NotEmpty(t, recorder.Header().Get("Last-Modified"))
+
+	recorder = doRequest(router, http.MethodGet, catalogURL+"/"+draft.ID.String(), "", "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	recorder = doRequest(router, http.MethodGet, "/catalog/"+entityPkg.NewID().String()+"/products", "", "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// As rotas de escrita continuam exigindo autenticação
+	recorder = doRequest(router, http.MethodPost, "/products", "", `{"name": "Anonymous", "price": 1}`)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
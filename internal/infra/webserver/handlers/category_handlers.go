@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/otthonleao/go-products.git/internal/dto"
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/database"
+	"github.com/otthonleao/go-products.git/internal/infra/webserver/middlewares"
+	"github.com/otthonleao/go-products.git/pkg/validation"
+)
+
+type CategoryHandler struct {
+	categoryDB database.CategoryInterface
+	productDB  database.ProductInterface
+}
+
+func NewCategoryHandler(categoryDB database.CategoryInterface, productDB database.ProductInterface) *CategoryHandler {
+	return &CategoryHandler{
+		categoryDB: categoryDB,
+		productDB:  productDB,
+	}
+}
+
+// categories retorna o repositório restrito à organização do usuário autenticado.
+func (handler *CategoryHandler) categories(request *http.Request) database.CategoryInterface {
+	identity, _ := middlewares.IdentityFromContext(request.Context())
+	return handler.categoryDB.WithContext(request.Context()).ForTenant(identity.OrganizationID)
+}
+
+// attributeDefinitions converte as definições recebidas para a entidade.
+func attributeDefinitions(input []dto.AttributeDefinitionInput) []entity.AttributeDefinition {
+	attributes := make([]entity.AttributeDefinition, 0, len(input))
+	for _, item := range input {
+		attributes = append(attributes, entity.AttributeDefinition{
+			Name:     item.Name,
+			Type:     item.Type,
+			Required: item.Required,
+			Unit:     item.Unit,
+			Options:  item.Options,
+		})
+	}
+	return attributes
+}
+
+// Create Category godoc
+// @Summary     Create a category
+// @Description Create a category with the schema of the attributes its products may have
+// @Tags        categories
+// @Accept      json
+// @Produce     json
+// @Param       request     body    dto.CreateCategoryInput     true    "Category request"
+// @Param       Idempotency-Key    header    string     false    "Key to safely retry the request"
+// @Success     201		{object}    entity.Category
+// @Header      201		{string}    Location    "URL of the created category"
+// @Failure     400		{object}    Error
+// @Failure     409		{object}    Error
+// @Failure     413		{object}    Error
+// @Failure     415		{object}    Error
+// @Failure     422		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /categories    [post]
+// @Security    ApiKeyAuth
+func (handler *CategoryHandler) Create(response http.ResponseWriter, request *http.Request) {
+	var input dto.CreateCategoryInput
+	err := decodeJSON(request, &input)
+	if err != nil {
+		writeDecodeError(response, err)
+		return
+	}
+	err = validation.Validate(input)
+	if err != nil {
+		writeValidationError(response, err)
+		return
+	}
+
+	category, err := entity.NewCategory(input.Name, attributeDefinitions(input.Attributes))
+	if err != nil {
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	err = handler.categories(request).Create(category)
+	if err != nil {
+		writeRepositoryError(response, err)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.Header().Set("Location", "/categories/"+category.ID.String())
+	response.WriteHeader(http.StatusCreated)
+	json.NewEncoder(response).Encode(category)
+}
+
+// List Categories godoc
+// @Summary     List categories
+// @Description Get all categories of the organization, ordered by name
+// @Tags        categories
+// @Accept      json
+// @Produce     json
+// @Success     200		{array}     entity.Category
+// @Success     304
+// @Failure     500		{object}    Error
+// @Router      /categories    [get]
+// @Security    ApiKeyAuth
+func (handler *CategoryHandler) GetCategories(response http.ResponseWriter, request *http.Request) {
+	categories, err := handler.categories(request).FindAll()
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if categories == nil {
+		categories = []entity.Category{}
+	}
+
+	var lastModified time.Time
+	for _, category := range categories {
+		if category.UpdatedAt.After(lastModified) {
+			lastModified = category.UpdatedAt
+		}
+	}
+	writeCacheable(response, request, lastModified, false, categories)
+}
+
+// Get Category godoc
+// @Summary     Get a category
+// @Description Get a category and its attribute schema by ID
+// @Tags        categories
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Category ID"		Format(uuid)
+// @Success     200		{object}    entity.Category
+// @Success     304
+// @Failure     404
+// @Router      /categories/{id}    [get]
+// @Security    ApiKeyAuth
+func (handler *CategoryHandler) GetCategory(response http.ResponseWriter, request *http.Request) {
+	category, err := handler.categories(request).FindByID(chi.URLParam(request, "id"))
+	if err != nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writeCacheable(response, request, category.UpdatedAt, true, category)
+}
+
+// Update Category godoc
+// @Summary     Update a category
+// @Description Replace the name and the attribute schema of a category. Products already in the category are validated against the new schema on their next update.
+// @Tags        categories
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Category ID"		Format(uuid)
+// @Param       request     body    dto.CreateCategoryInput     true    "Category request"
+// @Success     200		{object}    entity.Category
+// @Failure     400		{object}    Error
+// @Failure     404
+// @Failure     409		{object}    Error
+// @Failure     413		{object}    Error
+// @Failure     415		{object}    Error
+// @Failure     422		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /categories/{id}    [put]
+// @Security    ApiKeyAuth
+func (handler *CategoryHandler) UpdateCategory(response http.ResponseWriter, request *http.Request) {
+	var input dto.CreateCategoryInput
+	err := decodeJSON(request, &input)
+	if err != nil {
+		writeDecodeError(response, err)
+		return
+	}
+	err = validation.Validate(input)
+	if err != nil {
+		writeValidationError(response, err)
+		return
+	}
+
+	categories := handler.categories(request)
+	category, err := categories.FindByID(chi.URLParam(request, "id"))
+	if err != nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	category.Name = input.Name
+	category.Attributes = attributeDefinitions(input.Attributes)
+	category.UpdatedAt = time.Now()
+	if err := category.Validate(); err != nil {
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	err = categories.Update(category)
+	if err != nil {
+		writeRepositoryError(response, err)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+	json.NewEncoder(response).Encode(category)
+}
+
+// Delete Category godoc
+// @Summary     Delete a category
+// @Description Delete a category by ID. Categories still used by products cannot be deleted.
+// @Tags        categories
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Category ID"		Format(uuid)
+// @Success     204
+// @Failure     404
+// @Failure     409		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /categories/{id}    [delete]
+// @Security    ApiKeyAuth
+func (handler *CategoryHandler) DeleteCategory(response http.ResponseWriter, request *http.Request) {
+	id := chi.URLParam(request, "id")
+
+	categories := handler.categories(request)
+	if _, err := categories.FindByID(id); err != nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	identity, _ := middlewares.IdentityFromContext(request.Context())
+	products, err := handler.productDB.WithContext(request.Context()).ForTenant(identity.OrganizationID).
+		FindAllFiltered(1, 1, "", database.ProductFilter{CategoryID: id})
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if len(products) > 0 {
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(http.StatusConflict)
+		json.NewEncoder(response).Encode(Error{Message: "category is still used by products"})
+		return
+	}
+
+	if err := categories.Delete(id); err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
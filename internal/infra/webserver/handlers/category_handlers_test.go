@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+const batteriesCategory = `{"name": "Batteries", "attributes": [
+	{"name": "voltage", "type": "number", "required": true, "unit": "V"},
+	{"name": "chemistry", "type": "enum", "options": ["lithium", "alkaline"]},
+	{"name": "rechargeable", "type": "bool"}
+]}`
+
+func TestCategoryHandler_CRUD(t *testing.T) {
+	router, _ := newProductRouter(t)
+	token := tokenFor(t, entityPkg.NewID().String())
+
+	recorder := doRequest(router, http.MethodPost, "/categories", token, batteriesCategory)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	var category entity.Category
+	json.Unmarshal(recorder.Body.Bytes(), &category)
+	assert.Equal(t, "/categories/"+category.ID.String(), recorder.Header().Get("Location"))
+	assert.Len(t, category.Attributes, 3)
+
+	recorder = doRequest(router, http.MethodPost, "/categories", token, `{"name": "Batteries"}`)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	recorder = doRequest(router, http.MethodPost, "/categories", token, `{"name": "Tools", "attributes": [{"name": "size", "type": "text"}]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "attributes[0].type")
+
+	recorder = doRequest(router, http.MethodPost, "/categories", token, `{"name": "Tools", "attributes": [{"name": "size", "type": "enum"}]}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = doRequest(router, http.MethodPut, "/categories/"+category.ID.String(), token, `{"name": "Cells", "attributes": []}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doRequest(router, http.MethodGet, "/categories", token, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var categories []entity.Category
+	json.Unmarshal(recorder.Body.Bytes(), &categories)
+	assert.Len(t, categories, 1)
+	assert.Equal(t, "Cells", categories[0].Name)
+
+	// Categorias de outra organização não aparecem
+	recorder = doRequest(router, http.MethodGet, "/categories/"+category.ID.String(), tokenFor(t, entityPkg.NewID().String()), "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = doRequest(router, http.MethodDelete, "/categories/"+category.ID.String(), token, "")
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	recorder = doRequest(router, http.MethodGet, "/categories/"+category.ID.String(), token, "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestProductHandler_Attributes(t *testing.T) {
+	router, _ := newProductRouter(t)
+	token := tokenFor(t, entityPkg.NewID().String())
+
+	recorder := doRequest(router, http.MethodPost, "/categories", token, batteriesCategory)
+	var category entity.Category
+	json.Unmarshal(recorder.Body.Bytes(), &category)
+	categoryID := category.ID.String()
+
+	recorder = doRequest(router, http.MethodPost, "/products", token, `{"name": "AA", "price": 5, "category_id": "`+categoryID+`", "attributes": {"voltage": 1.5, "chemistry": "alkaline"}}`)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	var aa entity.Product
+	json.Unmarshal(recorder.Body.Bytes(), &aa)
+	assert.Equal(t, 1.5, aa.Attributes["voltage"])
+
+	recorder = doRequest(router, http.MethodPost, "/products", token, `{"name": "9V", "price": 9, "category_id": "`+categoryID+`", "attributes": {"voltage": "9", "color": "red"}}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	var body Error
+	json.Unmarshal(recorder.Body.Bytes(), &body)
+	assert.Equal(t, []string{"must be a number"}, body.Errors["attributes.voltage"])
+	assert.Equal(t, []string{`is not defined in category "Batteries"`}, body.Errors["attributes.color"])
+
+	recorder = doRequest(router, http.MethodPost, "/products", token, `{"name": "9V", "price": 9, "attributes": {"voltage": 9}}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "require a category_id")
+
+	recorder = doRequest(router, http.MethodPost, "/products", token, `{"name": "9V", "price": 9, "category_id": "`+entityPkg.NewID().String()+`"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "category_id")
+
+	recorder = doRequest(router, http.MethodPost, "/products/bulk", token, `[
+		{"name": "9V", "price": 9, "category_id": "`+categoryID+`", "attributes": {"voltage": 9, "rechargeable": true}},
+		{"name": "D", "price": 7, "category_id": "`+categoryID+`", "attributes": {"chemistry": "zinc"}}
+	]`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	body = Error{}
+	json.Unmarshal(recorder.Body.Bytes(), &body)
+	assert.Equal(t, []string{"is required"}, body.Errors["[1].attributes.voltage"])
+	assert.Contains(t, body.Errors, "[1].attributes.chemistry")
+
+	recorder = doRequest(router, http.MethodPost, "/products/bulk", token, `[
+		{"name": "9V", "price": 9, "category_id": "`+categoryID+`", "attributes": {"voltage": 9, "rechargeable": true}},
+		{"name": "Plain", "price": 1}
+	]`)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	names := func(target string) []string {
+		recorder := doRequest(router, http.MethodGet, target, token, "")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var products []entity.Product
+		json.Unmarshal(recorder.Body.Bytes(), &products)
+		names := []string{}
+		for _, product := range products {
+			names = append(names, product.Name)
+		}
+		return names
+	}
+	assert.ElementsMatch(t, []string{"AA", "9V"}, names("/products?category_id="+categoryID))
+	assert.Equal(t, []string{"AA"}, names("/products?attr.chemistry=alkaline"))
+	assert.Equal(t, []string{"AA"}, names("/products?attr.voltage_lt=2"))
+	assert.Equal(t, []string{"9V"}, names("/products?attr.voltage=9&attr.rechargeable=true"))
+	assert.Empty(t, names("/products?attr.voltage_gt=9"))
+
+	recorder = doRequest(router, http.MethodGet, "/products?attr.voltage_lt=low", token, "")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = doRequest(router, http.MethodGet, "/products?category_id=batteries", token, "")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// O PUT revalida os atributos e atualiza os filtros
+	recorder = doRequest(router, http.MethodPut, "/products/"+aa.ID.String(), token, `{"name": "AA", "price": 5, "category_id": "`+categoryID+`", "attributes": {"chemistry": "lithium"}}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	recorder = doRequest(router, http.MethodPut, "/products/"+aa.ID.String(), token, `{"name": "AA", "price": 5, "category_id": "`+categoryID+`", "attributes": {"voltage": 1.5, "chemistry": "lithium"}}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, names("/products?attr.chemistry=alkaline"))
+
+	// Categorias em uso não podem ser removidas
+	recorder = doRequest(router, http.MethodDelete, "/categories/"+categoryID, token, "")
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// writeCacheable responde payload em JSON com ETag forte (hash do corpo) e
+// Last-Modified, respondendo 304 quando a validação condicional do cliente
+// confere. If-None-Match tem precedência sobre If-Modified-Since, como manda a
+// RFC 9110. Com useModifiedSince falso o If-Modified-Since é ignorado: numa
+// listagem a data mais recente não muda quando um item é removido.
+func writeCacheable(response http.ResponseWriter, request *http.Request, lastModified time.Time, useModifiedSince bool, payload interface{}) {
+	// Os dados dependem do token, então só o cliente pode guardar e sempre revalidando
+	response.Header().Set("Cache-Control", "private, no-cache")
+	response.Header().Add("Vary", "Authorization")
+	writeConditional(response, request, lastModified, useModifiedSince, payload)
+}
+
+// writeConditional é writeCacheable sem Cache-Control, para respostas que
+// definem a própria política de cache.
+func writeConditional(response http.ResponseWriter, request *http.Request, lastModified time.Time, useModifiedSince bool, payload interface{}) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(payload); err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	hash := sha256.Sum256(body.Bytes())
+	etag := `"` + hex.EncodeToString(hash[:16]) + `"`
+
+	header := response.Header()
+	header.Set("ETag", etag)
+	if !lastModified.IsZero() {
+		header.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(request, etag, lastModified, useModifiedSince) {
+		response.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	header.Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+	response.Write(body.Bytes())
+}
+
+func notModified(request *http.Request, etag string, lastModified time.Time, useModifiedSince bool) bool {
+	if ifNoneMatch := request.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, etag)
+	}
+
+	if !useModifiedSince || lastModified.IsZero() {
+		return false
+	}
+	ifModifiedSince, err := http.ParseTime(request.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	// Last-Modified tem precisão de segundos
+	return !lastModified.Truncate(time.Second).After(ifModifiedSince)
+}
+
+// etagMatches aplica a comparação fraca exigida para If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
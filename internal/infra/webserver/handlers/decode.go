@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/otthonleao/go-products.git/internal/infra/tracing"
+)
+
+// DecodeError descreve por que o corpo da requisição não pôde ser decodificado.
+// Status é o código HTTP da resposta; Field e Offset apontam onde está o problema
+// quando isso é conhecido.
+type DecodeError struct {
+	Status  int
+	Message string
+	Field   string
+	Offset  int64
+}
+
+func (e *DecodeError) Error() string {
+	return e.Message
+}
+
+// decodeJSON decodifica o corpo da requisição em dst, registrando um span próprio
+// para separar o tempo de decodificação do resto do handler. Exige Content-Type
+// application/json, rejeita campos desconhecidos e qualquer dado depois do valor
+// JSON. O tamanho máximo do corpo é aplicado pelo middleware MaxBodySize. Os
+// erros retornados são sempre *DecodeError.
+func decodeJSON(request *http.Request, dst interface{}) error {
+	_, span := tracing.Start(request.Context(), "json.decode")
+	err := decodeStrictJSON(request, dst)
+	tracing.End(span, err)
+	return err
+}
+
+func decodeStrictJSON(request *http.Request, dst interface{}) error {
+	mediaType, _, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return &DecodeError{Status: http.StatusUnsupportedMediaType, Message: "Content-Type must be application/json"}
+	}
+
+	decoder := json.NewDecoder(request.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil {
+		return decodeError(err, decoder)
+	}
+
+	// Depois do valor só pode vir espaço em branco
+	end := decoder.InputOffset()
+	if err := decoder.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			return decodeError(err, decoder)
+		}
+		return &DecodeError{
+			Status:  http.StatusBadRequest,
+			Message: "request body must contain a single JSON value",
+			Offset:  end,
+		}
+	}
+	return nil
+}
+
+func decodeError(err error, decoder *json.Decoder) *DecodeError {
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+	var maxBytesError *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &maxBytesError):
+		return &DecodeError{
+			Status:  http.StatusRequestEntityTooLarge,
+			Message: fmt.Sprintf("request body must not exceed %d bytes", maxBytesError.Limit),
+		}
+	case errors.As(err, &syntaxError):
+		return &DecodeError{
+			Status:  http.StatusBadRequest,
+			Message: fmt.Sprintf("malformed JSON at offset %d: %s", syntaxError.Offset, syntaxError.Error()),
+			Offset:  syntaxError.Offset,
+		}
+	case errors.As(err, &typeError):
+		return &DecodeError{
+			Status:  http.StatusBadRequest,
+			Message: fmt.Sprintf("field %q must be of type %s", typeError.Field, typeError.Type),
+			Field:   typeError.Field,
+			Offset:  typeError.Offset,
+		}
+	case errors.Is(err, io.EOF):
+		return &DecodeError{Status: http.StatusBadRequest, Message: "request body must not be empty"}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &DecodeError{
+			Status:  http.StatusBadRequest,
+			Message: "malformed JSON: unexpected end of body",
+			Offset:  decoder.InputOffset(),
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json não exporta um tipo para este erro
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &DecodeError{
+			Status:  http.StatusBadRequest,
+			Message: fmt.Sprintf("unknown field %q", field),
+			Field:   field,
+			Offset:  decoder.InputOffset(),
+		}
+	default:
+		return &DecodeError{Status: http.StatusBadRequest, Message: err.Error()}
+	}
+}
+
+// writeDecodeError responde o erro de decodificação com o status adequado.
+func writeDecodeError(response http.ResponseWriter, err error) {
+	var decodeError *DecodeError
+	if !errors.As(err, &decodeError) {
+		decodeError = &DecodeError{Status: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(decodeError.Status)
+	json.NewEncoder(response).Encode(Error{
+		Message: decodeError.Message,
+		Field:   decodeError.Field,
+		Offset:  decodeError.Offset,
+	})
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/otthonleao/go-products.git/internal/dto"
+	"github.com/otthonleao/go-products.git/internal/infra/webserver/middlewares"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	handler := middlewares.MaxBodySize(64)(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		var input dto.CreateProductInput
+		if err := decodeJSON(request, &input); err != nil {
+			writeDecodeError(response, err)
+			return
+		}
+		response.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		expected    Error
+	}{
+		{"valid", "application/json", `{"name": "Product", "price": 10}`, http.StatusNoContent, Error{}},
+		{"charset", "application/json; charset=utf-8", `{"name": "Product", "price": 10}` + "\n", http.StatusNoContent, Error{}},
+		{"missing content type", "", `{"name": "Product"}`, http.StatusUnsupportedMediaType, Error{Message: "Content-Type must be application/json"}},
+		{"wrong content type", "text/plain", `{"name": "Product"}`, http.StatusUnsupportedMediaType, Error{Message: "Content-Type must be application/json"}},
+		{"empty", "application/json", ``, http.StatusBadRequest, Error{Message: "request body must not be empty"}},
+		{"unknown field", "application/json", `{"name": "Product", "color": "red"}`, http.StatusBadRequest, Error{Message: `unknown field "color"`, Field: "color", Offset: 35}},
+		{"wrong type", "application/json", `{"name": "Product", "price": "10"}`, http.StatusBadRequest, Error{Message: `field "price" must be of type float64`, Field: "price", Offset: 33}},
+		{"syntax", "application/json", `{"name": "Product",}`, http.StatusBadRequest, Error{Message: "malformed JSON at offset 20: invalid character '}' looking for beginning of object key string", Offset: 20}},
+		{"truncated", "application/json", `{"name": "Prod`, http.StatusBadRequest, Error{Message: "malformed JSON: unexpected end of body", Offset: 0}},
+		{"trailing data", "application/json", `{"name": "Product"} {"name": "Other"}`, http.StatusBadRequest, Error{Message: "request body must contain a single JSON value", Offset: 19}},
+		{"trailing garbage", "application/json", `{"name": "Product"}garbage`, http.StatusBadRequest, Error{Message: "request body must contain a single JSON value", Offset: 19}},
+		{"too large", "application/json", `{"name": "` + strings.Repeat("a", 100) + `"}`, http.StatusRequestEntityTooLarge, Error{Message: "request body is too large"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(test.body))
+			if test.contentType != "" {
+				request.Header.Set("Content-Type", test.contentType)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			assert.Equal(t, test.status, recorder.Code)
+			if test.status == http.StatusNoContent {
+				return
+			}
+			var body Error
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+			assert.Equal(t, test.expected, body)
+		})
+	}
+}
+
+func TestDecodeJSON_BodyLargerThanDeclared(t *testing.T) {
+	handler := http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		var input dto.CreateProductInput
+		if err := decodeJSON(request, &input); err != nil {
+			writeDecodeError(response, err)
+			return
+		}
+		response.WriteHeader(http.StatusNoContent)
+	})
+
+	// Sem Content-Length o limite é aplicado durante a leitura
+	request := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(`{"name": "`+strings.Repeat("a", 100)+`"}`))
+	request.ContentLength = -1
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	middlewares.MaxBodySize(64)(handler).ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.JSONEq(t, `{"message": "request body must not exceed 64 bytes"}`, recorder.Body.String())
+}
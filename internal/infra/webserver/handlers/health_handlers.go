@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/otthonleao/go-products.git/internal/infra/webserver"
+)
+
+const healthCheckTimeout = 2 * time.Second
+
+// HealthCheck verifica uma dependência do serviço para a sonda de prontidão.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type HealthHandler struct {
+	state  *webserver.State
+	checks []HealthCheck
+}
+
+type HealthCheckStatus struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type HealthStatus struct {
+	Status string                       `json:"status"`
+	State  string                       `json:"state,omitempty"`
+	Checks map[string]HealthCheckStatus `json:"checks,omitempty"`
+}
+
+func NewHealthHandler(state *webserver.State, checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{
+		state:  state,
+		checks: checks,
+	}
+}
+
+// Liveness godoc
+// @Summary     Liveness probe
+// @Description Report that the process is alive
+// @Tags        health
+// @Produce     json
+// @Success     200		{object}    HealthStatus
+// @Router      /healthz    [get]
+func (handler *HealthHandler) Liveness(response http.ResponseWriter, request *http.Request) {
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+	json.NewEncoder(response).Encode(HealthStatus{Status: "ok"})
+}
+
+// Readiness godoc
+// @Summary     Readiness probe
+// @Description Check every dependency and report its status and latency. Returns 503 while the server is starting, draining or a dependency fails.
+// @Tags        health
+// @Produce     json
+// @Success     200		{object}    HealthStatus
+// @Failure     503		{object}    HealthStatus
+// @Router      /readyz    [get]
+func (handler *HealthHandler) Readiness(response http.ResponseWriter, request *http.Request) {
+	status := HealthStatus{
+		Status: "ok",
+		State:  handler.state.String(),
+		Checks: map[string]HealthCheckStatus{},
+	}
+
+	for _, check := range handler.checks {
+		ctx, cancel := context.WithTimeout(request.Context(), healthCheckTimeout)
+		start := time.Now()
+		err := check.Check(ctx)
+		cancel()
+
+		result := HealthCheckStatus{
+			Status:    "ok",
+			LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if err != nil {
+			result.Status = "fail"
+			result.Error = err.Error()
+			status.Status = "unavailable"
+		}
+		status.Checks[check.Name] = result
+	}
+
+	code := http.StatusOK
+	if !handler.state.IsReady() {
+		status.Status = "unavailable"
+	}
+	if status.Status != "ok" {
+		code = http.StatusServiceUnavailable
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.Header().Set("Cache-Control", "no-store")
+	response.WriteHeader(code)
+	json.NewEncoder(response).Encode(status)
+}
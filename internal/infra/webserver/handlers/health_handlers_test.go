@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/otthonleao/go-products.git/internal/infra/webserver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthHandler_Liveness(t *testing.T) {
+	handler := NewHealthHandler(webserver.NewState())
+
+	recorder := httptest.NewRecorder()
+	handler.Liveness(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestHealthHandler_Readiness(t *testing.T) {
+	state := webserver.NewState()
+	var databaseErr error
+	handler := NewHealthHandler(state, HealthCheck{
+		Name:  "database",
+		Check: func(ctx context.Context) error { return databaseErr },
+	})
+
+	readiness := func() (int, HealthStatus) {
+		recorder := httptest.NewRecorder()
+		handler.Readiness(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var status HealthStatus
+		json.NewDecoder(recorder.Body).Decode(&status)
+		return recorder.Code, status
+	}
+
+	code, status := readiness()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "starting", status.State)
+
+	state.SetReady()
+	code, status = readiness()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", status.Checks["database"].Status)
+
+	databaseErr = errors.New("connection refused")
+	code, status = readiness()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "fail", status.Checks["database"].Status)
+	assert.Equal(t, "connection refused", status.Checks["database"].Error)
+
+	databaseErr = nil
+	state.SetDraining()
+	code, status = readiness()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "draining", status.State)
+}
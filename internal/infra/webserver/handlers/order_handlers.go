@@ -0,0 +1,491 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/otthonleao/go-products.git/internal/dto"
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/database"
+	"github.com/otthonleao/go-products.git/internal/infra/webserver/middlewares"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/otthonleao/go-products.git/pkg/validation"
+)
+
+// Paginação das listagens de pedidos.
+const (
+	ordersDefaultLimit = 20
+	ordersMaxLimit     = 100
+)
+
+// OrderHandler atende os pedidos: as rotas em /users/me/orders são do
+// comprador e as de /orders da organização que vende.
+type OrderHandler struct {
+	orderDB        database.OrderInterface
+	cartDB         database.CartInterface
+	productDB      database.ProductInterface
+	variantDB      database.ProductVariantInterface
+	organizationDB database.OrganizationInterface
+	promotionDB    database.PromotionInterface
+}
+
+func NewOrderHandler(orderDB database.OrderInterface, cartDB database.CartInterface, productDB database.ProductInterface, variantDB database.ProductVariantInterface, organizationDB database.OrganizationInterface, promotionDB database.PromotionInterface) *OrderHandler {
+	return &OrderHandler{
+		orderDB:        orderDB,
+		cartDB:         cartDB,
+		productDB:      productDB,
+		variantDB:      variantDB,
+		organizationDB: organizationDB,
+		promotionDB:    promotionDB,
+	}
+}
+
+// parseOrderStatuses lê status=paid,fulfilled da consulta; sem status lista todos.
+func parseOrderStatuses(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	statuses := strings.Split(value, ",")
+	for i, status := range statuses {
+		statuses[i] = strings.TrimSpace(status)
+		if !entity.IsValidOrderStatus(statuses[i]) {
+			return nil, fmt.Errorf("unknown status %q", statuses[i])
+		}
+	}
+	return statuses, nil
+}
+
+// parseOrderTime aceita datas (2024-01-31) e instantes RFC 3339.
+func parseOrderTime(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return &parsed, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid %s: use a date (2006-01-02) or an RFC 3339 timestamp", name)
+}
+
+// parseOrderFilter lê status, from e to da consulta.
+func parseOrderFilter(query url.Values) (database.OrderFilter, error) {
+	var filter database.OrderFilter
+	var err error
+	if filter.Statuses, err = parseOrderStatuses(query.Get("status")); err != nil {
+		return filter, err
+	}
+	if filter.From, err = parseOrderTime("from", query.Get("from")); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseOrderTime("to", query.Get("to")); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// pagination lê page e limit com os limites das listagens de pedidos.
+func pagination(query url.Values) (int, int) {
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit < 1 {
+		limit = ordersDefaultLimit
+	}
+	return page, min(limit, ordersMaxLimit)
+}
+
+// listOrders responde a página de pedidos do filtro.
+func listOrders(response http.ResponseWriter, request *http.Request, orders database.OrderInterface, filter database.OrderFilter) {
+	page, limit := pagination(request.URL.Query())
+	found, err := orders.FindAll(page, limit, filter)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if found == nil {
+		found = []entity.Order{}
+	}
+
+	var lastModified time.Time
+	for _, order := range found {
+		if order.UpdatedAt.After(lastModified) {
+			lastModified = order.UpdatedAt
+		}
+	}
+	writeCacheable(response, request, lastModified, false, found)
+}
+
+// transitionOrder aplica a mudança de estado e responde com o pedido. Papéis
+// sem permissão recebem 403 e mudanças fora do fluxo 409.
+func transitionOrder(response http.ResponseWriter, request *http.Request, orders database.OrderInterface, order *entity.Order, to, role string) {
+	from, err := order.Transition(to, role)
+	if err != nil {
+		status := http.StatusConflict
+		switch {
+		case errors.Is(err, entity.ErrOrderTransitionForbidden):
+			status = http.StatusForbidden
+		case errors.Is(err, entity.ErrOrderTransitionNotAllowed):
+			err = fmt.Errorf("cannot move an order from %s to %s", order.Status, to)
+		}
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(status)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	if err := orders.Transition(order, from); err != nil {
+		writeRepositoryError(response, err)
+		return
+	}
+	response.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(response).Encode(order)
+}
+
+// orderDraft reúne as linhas do pedido e a origem delas.
+type orderDraft struct {
+	organizationID string
+	items          []entity.OrderItem
+	// lines são as linhas para a precificação, na ordem de items
+	lines []entity.PricingLine
+	cart  *entity.Cart
+}
+
+// orderItems monta as linhas do pedido a partir do carrinho ou da lista
+// informada, conferindo que os produtos continuam publicados. Linhas do
+// carrinho mantêm o preço capturado nele.
+func (handler *OrderHandler) orderItems(request *http.Request, input dto.CreateOrderInput) (*orderDraft, validation.Errors, error) {
+	if input.CartID != "" {
+		cart, err := handler.cartDB.WithContext(request.Context()).FindByID(input.CartID)
+		if err != nil || cart.Expired(time.Now()) || !canAccessCart(request, cart) {
+			return nil, validation.Errors{"cart_id": {"cart not found"}}, nil
+		}
+		if len(cart.Items) == 0 {
+			return nil, validation.Errors{"cart_id": {"cart is empty"}}, nil
+		}
+
+		draft := &orderDraft{organizationID: cart.OrganizationID.String(), cart: cart}
+		for i, item := range cart.Items {
+			variantID := ""
+			if item.VariantID != nil {
+				variantID = item.VariantID.String()
+			}
+			product, _, invalid, err := availableProduct(request, handler.productDB, handler.variantDB, cart.OrganizationID.String(), item.ProductID.String(), variantID)
+			if err != nil || invalid != nil {
+				return nil, prefixErrors(invalid, fmt.Sprintf("items[%d].", i)), err
+			}
+			orderItem := entity.NewOrderItemFromCart(item)
+			draft.items = append(draft.items, orderItem)
+			draft.lines = append(draft.lines, orderItem.PricingLine(product.CategoryID))
+		}
+		return draft, nil, nil
+	}
+
+	if _, err := handler.organizationDB.WithContext(request.Context()).FindByID(input.OrganizationID); err != nil {
+		return nil, validation.Errors{"organization_id": {"organization not found"}}, nil
+	}
+	draft := &orderDraft{organizationID: input.OrganizationID}
+	for i, line := range input.Items {
+		product, variant, invalid, err := availableProduct(request, handler.productDB, handler.variantDB, input.OrganizationID, line.ProductID, line.VariantID)
+		if err != nil || invalid != nil {
+			return nil, prefixErrors(invalid, fmt.Sprintf("items[%d].", i)), err
+		}
+		item, err := entity.NewOrderItem(product, variant, line.Quantity)
+		if err != nil {
+			return nil, validation.Errors{fmt.Sprintf("items[%d].quantity", i): {err.Error()}}, nil
+		}
+		draft.items = append(draft.items, *item)
+		draft.lines = append(draft.lines, item.PricingLine(product.CategoryID))
+	}
+	return draft, nil, nil
+}
+
+// Place Order godoc
+// @Summary     Place an order
+// @Description Place an order from a cart (cart_id, with X-Cart-Token for anonymous carts) or from a list of items of an organization. Lines keep the name and price at purchase time; cart lines keep the price captured in the cart. The current automatic promotions and the optional coupon (coupon_code) are applied as in /pricing/quote. The stock of every line and one use of the coupon are reserved in a single transaction and the cart is removed.
+// @Tags        orders
+// @Accept      json
+// @Produce     json
+// @Param       request        body      dto.CreateOrderInput     true    "Order request"
+// @Param       X-Cart-Token   header    string     false    "Token of an anonymous cart"
+// @Param       Idempotency-Key    header    string     false    "Key to safely retry the request"
+// @Success     201		{object}    entity.Order
+// @Header      201		{string}    Location    "URL of the created order"
+// @Failure     400		{object}    Error
+// @Failure     401		{object}    Error
+// @Failure     409		{object}    Error
+// @Failure     415		{object}    Error
+// @Failure     422		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /users/me/orders    [post]
+// @Security    ApiKeyAuth
+func (handler *OrderHandler) PlaceOrder(response http.ResponseWriter, request *http.Request) {
+	identity, _ := middlewares.IdentityFromContext(request.Context())
+	userID, err := entityPkg.ParseID(identity.UserID)
+	if err != nil {
+		response.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var input dto.CreateOrderInput
+	err = decodeJSON(request, &input)
+	if err != nil {
+		writeDecodeError(response, err)
+		return
+	}
+	err = validation.Validate(input)
+	if err != nil {
+		writeValidationError(response, err)
+		return
+	}
+	if input.CartID != "" && (input.OrganizationID != "" || len(input.Items) > 0) {
+		writeValidationError(response, validation.Errors{"cart_id": {"cannot be combined with organization_id and items"}})
+		return
+	}
+	if input.CartID == "" && (input.OrganizationID == "" || len(input.Items) == 0) {
+		writeValidationError(response, validation.Errors{"items": {"organization_id and items are required without cart_id"}})
+		return
+	}
+
+	draft, invalid, err := handler.orderItems(request, input)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if invalid != nil {
+		writeValidationError(response, invalid)
+		return
+	}
+
+	organization, _ := entityPkg.ParseID(draft.organizationID)
+	order, err := entity.NewOrder(organization, userID, draft.items)
+	if err != nil {
+		writeValidationError(response, validation.Errors{"items": {err.Error()}})
+		return
+	}
+
+	quote, coupon, invalid, err := priceLines(request, handler.promotionDB, draft.organizationID, draft.lines, input.CouponCode)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if invalid != nil {
+		writeValidationError(response, invalid)
+		return
+	}
+	order.ApplyQuote(quote, coupon)
+
+	cartID := ""
+	if draft.cart != nil {
+		cartID = draft.cart.ID.String()
+	}
+	err = handler.orderDB.WithContext(request.Context()).Place(order, cartID)
+	if errors.Is(err, entity.ErrCouponExhausted) {
+		// Mesma mensagem da validação do cupom em priceLines
+		err = entity.ErrCouponUnavailable
+	}
+	if errors.Is(err, entity.ErrInsufficientStock) || errors.Is(err, entity.ErrCouponUnavailable) {
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(http.StatusConflict)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		writeRepositoryError(response, err)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.Header().Set("Location", "/users/me/orders/"+order.ID.String())
+	response.WriteHeader(http.StatusCreated)
+	json.NewEncoder(response).Encode(order)
+}
+
+// userOrder carrega o pedido da rota, respondendo 404 quando ele não é do usuário.
+func (handler *OrderHandler) userOrder(response http.ResponseWriter, request *http.Request) (*entity.Order, bool) {
+	identity, _ := middlewares.IdentityFromContext(request.Context())
+	order, err := handler.orderDB.WithContext(request.Context()).FindByID(chi.URLParam(request, "id"))
+	if err != nil || order.UserID.String() != identity.UserID {
+		response.WriteHeader(http.StatusNotFound)
+		return nil, false
+	}
+	return order, true
+}
+
+// List My Orders godoc
+// @Summary     List my orders
+// @Description List the orders of the authenticated user, newest first
+// @Tags        orders
+// @Accept      json
+// @Produce     json
+// @Param       page        query    int        false    "Page number"
+// @Param       limit       query    int        false    "Number of items per page (default 20, at most 100)"
+// @Param       status      query    string     false    "Comma-separated statuses: pending, paid, fulfilled, cancelled, refunded"
+// @Success     200		{array}     entity.Order
+// @Failure     400		{object}    Error
+// @Failure     401		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /users/me/orders    [get]
+// @Security    ApiKeyAuth
+func (handler *OrderHandler) GetMyOrders(response http.ResponseWriter, request *http.Request) {
+	identity, _ := middlewares.IdentityFromContext(request.Context())
+	statuses, err := parseOrderStatuses(request.URL.Query().Get("status"))
+	if err != nil {
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+	listOrders(response, request, handler.orderDB.WithContext(request.Context()), database.OrderFilter{Statuses: statuses, UserID: identity.UserID})
+}
+
+// Get My Order godoc
+// @Summary     Get one of my orders
+// @Tags        orders
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Order ID"		Format(uuid)
+// @Success     200		{object}    entity.Order
+// @Success     304
+// @Failure     401		{object}    Error
+// @Failure     404
+// @Router      /users/me/orders/{id}    [get]
+// @Security    ApiKeyAuth
+func (handler *OrderHandler) GetMyOrder(response http.ResponseWriter, request *http.Request) {
+	order, ok := handler.userOrder(response, request)
+	if !ok {
+		return
+	}
+	writeCacheable(response, request, order.UpdatedAt, true, order)
+}
+
+// Cancel My Order godoc
+// @Summary     Cancel one of my orders
+// @Description Cancel a pending order of the authenticated user and release its stock and coupon. Paid orders can only be cancelled by the organization.
+// @Tags        orders
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Order ID"		Format(uuid)
+// @Success     200		{object}    entity.Order
+// @Failure     401		{object}    Error
+// @Failure     403		{object}    Error
+// @Failure     404
+// @Failure     409		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /users/me/orders/{id}/cancel    [post]
+// @Security    ApiKeyAuth
+func (handler *OrderHandler) CancelMyOrder(response http.ResponseWriter, request *http.Request) {
+	order, ok := handler.userOrder(response, request)
+	if !ok {
+		return
+	}
+	transitionOrder(response, request, handler.orderDB.WithContext(request.Context()), order, entity.OrderCancelled, entity.RoleBuyer)
+}
+
+// orders retorna o repositório restrito à organização do token.
+func (handler *OrderHandler) orders(request *http.Request) database.OrderInterface {
+	identity, _ := middlewares.IdentityFromContext(request.Context())
+	return handler.orderDB.WithContext(request.Context()).ForTenant(identity.OrganizationID)
+}
+
+// List Orders godoc
+// @Summary     List the orders of the organization
+// @Description List the orders placed to the organization of the token, newest first. Only owners and admins.
+// @Tags        orders
+// @Accept      json
+// @Produce     json
+// @Param       page        query    int        false    "Page number"
+// @Param       limit       query    int        false    "Number of items per page (default 20, at most 100)"
+// @Param       status      query    string     false    "Comma-separated statuses: pending, paid, fulfilled, cancelled, refunded"
+// @Param       user_id     query    string     false    "Only orders of this user"	Format(uuid)
+// @Param       from        query    string     false    "Orders created at or after this date or RFC 3339 timestamp"
+// @Param       to          query    string     false    "Orders created before this date or RFC 3339 timestamp"
+// @Success     200		{array}     entity.Order
+// @Failure     400		{object}    Error
+// @Failure     403		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /orders    [get]
+// @Security    ApiKeyAuth
+func (handler *OrderHandler) GetOrders(response http.ResponseWriter, request *http.Request) {
+	filter, err := parseOrderFilter(request.URL.Query())
+	if err != nil {
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+	filter.UserID = request.URL.Query().Get("user_id")
+	listOrders(response, request, handler.orders(request), filter)
+}
+
+// Get Order godoc
+// @Summary     Get an order of the organization
+// @Tags        orders
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Order ID"		Format(uuid)
+// @Success     200		{object}    entity.Order
+// @Success     304
+// @Failure     403		{object}    Error
+// @Failure     404
+// @Router      /orders/{id}    [get]
+// @Security    ApiKeyAuth
+func (handler *OrderHandler) GetOrder(response http.ResponseWriter, request *http.Request) {
+	order, err := handler.orders(request).FindByID(chi.URLParam(request, "id"))
+	if err != nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+	writeCacheable(response, request, order.UpdatedAt, true, order)
+}
+
+// Create Order Transition godoc
+// @Summary     Change the order status
+// @Description Move an order through pending → paid → fulfilled, or to cancelled (from pending or paid) and refunded (from paid or fulfilled). Cancelling releases the reserved stock and coupon. Only owners and admins.
+// @Tags        orders
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Order ID"		Format(uuid)
+// @Param       request     body    dto.OrderTransitionInput     true    "Transition request"
+// @Param       Idempotency-Key    header    string     false    "Key to safely retry the request"
+// @Success     200		{object}    entity.Order
+// @Failure     400		{object}    Error
+// @Failure     403		{object}    Error
+// @Failure     404
+// @Failure     409		{object}    Error
+// @Failure     415		{object}    Error
+// @Failure     422		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /orders/{id}/transitions    [post]
+// @Security    ApiKeyAuth
+func (handler *OrderHandler) CreateTransition(response http.ResponseWriter, request *http.Request) {
+	var input dto.OrderTransitionInput
+	err := decodeJSON(request, &input)
+	if err != nil {
+		writeDecodeError(response, err)
+		return
+	}
+	err = validation.Validate(input)
+	if err != nil {
+		writeValidationError(response, err)
+		return
+	}
+
+	orders := handler.orders(request)
+	order, err := orders.FindByID(chi.URLParam(request, "id"))
+	if err != nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+	identity, _ := middlewares.IdentityFromContext(request.Context())
+	transitionOrder(response, request, orders, order, input.To, identity.Role)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/database"
+	"github.com/otthonleao/go-products.git/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderHandler(t *testing.T) {
+	router, productDB := newProductRouter(t)
+	organization, _ := entity.NewOrganization("Acme")
+	assert.NoError(t, database.NewOrganization(productDB.DB).Create(organization))
+	admin := tokenWithRole(t, organization.ID.String(), entity.RoleAdmin)
+	member := tokenWithRole(t, organization.ID.String(), entity.RoleMember)
+	buyer := tokenFor(t, "")
+
+	create := func(body string) entity.Product {
+		recorder := doRequest(router, http.MethodPost, "/products", admin, body)
+		var product entity.Product
+		json.Unmarshal(recorder.Body.Bytes(), &product)
+		for _, status := range []string{entity.StatusInReview, entity.StatusPublished} {
+			doRequest(router, http.MethodPost, "/products/"+product.ID.String()+"/transitions", admin, `{"to": "`+status+`"}`)
+		}
+		return product
+	}
+	pen := create(`{"name": "Pen", "price": 2.5, "stock": 4}`)
+	stock := func() int {
+		recorder := doRequest(router, http.MethodGet, "/products/"+pen.ID.String(), admin, "")
+		var product entity.Product
+		json.Unmarshal(recorder.Body.Bytes(), &product)
+		return *product.Stock
+	}
+
+	// Pedido a partir de um carrinho anônimo
+	recorder := doCartRequest(router, http.MethodPost, "/carts", "", `{"organization_id": "`+organization.ID.String()+`"}`)
+	var cart entity.Cart
+	json.Unmarshal(recorder.Body.Bytes(), &cart)
+	doCartRequest(router, http.MethodPost, "/carts/"+cart.ID.String()+"/items", cart.Token, `{"product_id": "`+pen.ID.String()+`", "quantity": 3}`)
+	doRequest(router, http.MethodPut, "/products/"+pen.ID.String(), admin, `{"name": "Pen", "price": 5, "stock": 4}`)
+
+	placeFromCart := func(token string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/users/me/orders", strings.NewReader(`{"cart_id": "`+cart.ID.String()+`"}`))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Authorization", "Bearer "+buyer)
+		request.Header.Set(CartTokenHeader, token)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+	assert.Equal(t, http.StatusUnprocessableEntity, placeFromCart("wrong").Code)
+	recorder = placeFromCart(cart.Token)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	var order entity.Order
+	json.Unmarshal(recorder.Body.Bytes(), &order)
+	assert.Equal(t, entity.OrderPending, order.Status)
+	assert.Equal(t, money.Cents(750), order.Total)
+	assert.Equal(t, "/users/me/orders/"+order.ID.String(), recorder.Header().Get("Location"))
+	assert.Equal(t, 1, stock())
+	assert.Equal(t, http.StatusNotFound, doCartRequest(router, http.MethodGet, "/carts/"+cart.ID.String(), cart.Token, "").Code)
+
+	// Pedido direto, com o preço atual; o estoque não comporta
+	direct := `{"organization_id": "` + organization.ID.String() + `", "items": [{"product_id": "` + pen.ID.String() + `", "quantity": 2}]}`
+	recorder = doRequest(router, http.MethodPost, "/users/me/orders", buyer, direct)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "insufficient stock")
+
+	recorder = doRequest(router, http.MethodPost, "/users/me/orders", buyer, `{"organization_id": "`+organization.ID.String()+`"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	recorder = doRequest(router, http.MethodPost, "/users/me/orders", buyer, `{"organization_id": "`+organization.ID.String()+`", "items": [{"product_id": "`+organization.ID.String()+`", "quantity": 1}]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "items[0].product_id")
+	assert.Equal(t, http.StatusUnauthorized, doRequest(router, http.MethodPost, "/users/me/orders", "", direct).Code)
+
+	direct = `{"organization_id": "` + organization.ID.String() + `", "items": [{"product_id": "` + pen.ID.String() + `", "quantity": 1}]}`
+	recorder = doRequest(router, http.MethodPost, "/users/me/orders", buyer, direct)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	var second entity.Order
+	json.Unmarshal(recorder.Body.Bytes(), &second)
+	assert.Equal(t, money.Cents(500), second.Total)
+	assert.Equal(t, 0, stock())
+
+	// Listagem do comprador
+	recorder = doRequest(router, http.MethodGet, "/users/me/orders", buyer, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var orders []entity.Order
+	json.Unmarshal(recorder.Body.Bytes(), &orders)
+	assert.Len(t, orders, 2)
+	assert.Equal(t, second.ID, orders[0].ID)
+	assert.Equal(t, http.StatusNotFound, doRequest(router, http.MethodGet, "/users/me/orders/"+order.ID.String(), tokenFor(t, ""), "").Code)
+
+	// O comprador cancela o pedido pendente e o estoque volta
+	recorder = doRequest(router, http.MethodPost, "/users/me/orders/"+second.ID.String()+"/cancel", buyer, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, 1, stock())
+	recorder = doRequest(router, http.MethodPost, "/users/me/orders/"+second.ID.String()+"/cancel", buyer, "")
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	// Fluxo da organização
+	transitionsURL := "/orders/" + order.ID.String() + "/transitions"
+	assert.Equal(t, http.StatusForbidden, doRequest(router, http.MethodGet, "/orders", member, "").Code)
+	assert.Equal(t, http.StatusConflict, doRequest(router, http.MethodPost, transitionsURL, admin, `{"to": "fulfilled"}`).Code)
+	assert.Equal(t, http.StatusOK, doRequest(router, http.MethodPost, transitionsURL, admin, `{"to": "paid"}`).Code)
+	assert.Equal(t, http.StatusForbidden, doRequest(router, http.MethodPost, "/users/me/orders/"+order.ID.String()+"/cancel", buyer, "").Code)
+	recorder = doRequest(router, http.MethodPost, transitionsURL, admin, `{"to": "fulfilled"}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &order)
+	assert.Equal(t, entity.OrderFulfilled, order.Status)
+	assert.NotNil(t, order.FulfilledAt)
+
+	count := func(query string) int {
+		recorder := doRequest(router, http.MethodGet, "/orders"+query, admin, "")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var orders []entity.Order
+		json.Unmarshal(recorder.Body.Bytes(), &orders)
+		return len(orders)
+	}
+	assert.Equal(t, 2, count(""))
+	assert.Equal(t, 1, count("?status=fulfilled"))
+	assert.Equal(t, 2, count("?status=fulfilled,cancelled&user_id="+order.UserID.String()))
+	assert.Equal(t, 0, count("?from=2999-01-01"))
+	assert.Equal(t, http.StatusBadRequest, doRequest(router, http.MethodGet, "/orders?status=shipped", admin, "").Code)
+	assert.Equal(t, http.StatusBadRequest, doRequest(router, http.MethodGet, "/orders?from=yesterday", admin, "").Code)
+
+	// Outra organização não vê os pedidos
+	other := tokenWithRole(t, pen.ID.String(), entity.RoleOwner)
+	assert.Equal(t, http.StatusNotFound, doRequest(router, http.MethodGet, "/orders/"+order.ID.String(), other, "").Code)
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/otthonleao/go-products.git/internal/dto"
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/database"
+	"github.com/otthonleao/go-products.git/internal/infra/webserver/middlewares"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/otthonleao/go-products.git/pkg/validation"
+)
+
+type OrganizationHandler struct {
+	OrganizationDB database.OrganizationInterface
+	UserDB         database.UserInterface
+}
+
+func NewOrganizationHandler(organizationDB database.OrganizationInterface, userDB database.UserInterface) *OrganizationHandler {
+	return &OrganizationHandler{
+		OrganizationDB: organizationDB,
+		UserDB:         userDB,
+	}
+}
+
+// Create Organization godoc
+// @Summary     Create an organization
+// @Description Create a new organization owned by the authenticated user
+// @Tags        organizations
+// @Accept      json
+// @Produce     json
+// @Param       request     body    dto.CreateOrganizationInput     true    "Organization request"
+// @Param       Idempotency-Key    header    string     false    "Key to safely retry the request"
+// @Success     201		{object}    entity.Organization
+// @Failure     400		{object}    Error
+// @Failure     413		{object}    Error
+// @Failure     415		{object}    Error
+// @Failure     422		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /organizations    [post]
+// @Security    ApiKeyAuth
+func (handler *OrganizationHandler) Create(response http.ResponseWriter, request *http.Request) {
+	identity, _ := middlewares.IdentityFromContext(request.Context())
+
+	var input dto.CreateOrganizationInput
+	err := decodeJSON(request, &input)
+	if err != nil {
+		writeDecodeError(response, err)
+		return
+	}
+	err = validation.Validate(input)
+	if err != nil {
+		writeValidationError(response, err)
+		return
+	}
+
+	userID, err := entityPkg.ParseID(identity.UserID)
+	if err != nil {
+		response.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	organization, err := entity.NewOrganization(input.Name)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	owner, err := entity.NewMembership(userID, organization.ID, entity.RoleOwner)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	err = handler.OrganizationDB.WithContext(request.Context()).CreateWithOwner(organization, owner)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusCreated)
+	json.NewEncoder(response).Encode(organization)
+}
+
+// Add Member godoc
+// @Summary     Add a member to an organization
+// @Description Add an existing user to the organization. Only owners and admins can add members, and nobody can grant a role higher than their own.
+// @Tags        organizations
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Organization ID"		Format(uuid)
+// @Param       request     body    dto.AddMemberInput     true    "Member request"
+// @Param       Idempotency-Key    header    string     false    "Key to safely retry the request"
+// @Success     201		{object}    entity.Membership
+// @Failure     400		{object}    Error
+// @Failure     403		{object}    Error
+// @Failure     404		{object}    Error
+// @Failure     409		{object}    Error
+// @Failure     413		{object}    Error
+// @Failure     415		{object}    Error
+// @Failure     422		{object}    Error
+// @Router      /organizations/{id}/members    [post]
+// @Security    ApiKeyAuth
+func (handler *OrganizationHandler) AddMember(response http.ResponseWriter, request *http.Request) {
+	identity, _ := middlewares.IdentityFromContext(request.Context())
+	id := chi.URLParam(request, "id")
+
+	organization, err := handler.OrganizationDB.WithContext(request.Context()).FindByID(id)
+	if err != nil {
+		response.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(response).Encode(Error{Message: "organization not found"})
+		return
+	}
+
+	requester, err := handler.OrganizationDB.WithContext(request.Context()).FindMembership(identity.UserID, organization.ID.String())
+	if err != nil {
+		// Quem não participa da organização não deve nem saber que ela existe
+		response.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(response).Encode(Error{Message: "organization not found"})
+		return
+	}
+
+	if !requester.CanManageMembers() {
+		response.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(response).Encode(Error{Message: "only owners and admins can add members"})
+		return
+	}
+
+	var input dto.AddMemberInput
+	err = decodeJSON(request, &input)
+	if err != nil {
+		writeDecodeError(response, err)
+		return
+	}
+	err = validation.Validate(input)
+	if err != nil {
+		writeValidationError(response, err)
+		return
+	}
+
+	if input.Role == "" {
+		input.Role = entity.RoleMember
+	}
+	if !requester.CanGrantRole(input.Role) {
+		response.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(response).Encode(Error{Message: "cannot grant a role higher than your own"})
+		return
+	}
+
+	user, err := handler.UserDB.WithContext(request.Context()).FindByEmail(input.Email)
+	if err != nil {
+		response.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(response).Encode(Error{Message: "user not found"})
+		return
+	}
+
+	membership, err := entity.NewMembership(user.ID, organization.ID, input.Role)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	if _, err := handler.OrganizationDB.WithContext(request.Context()).FindMembership(user.ID.String(), organization.ID.String()); err == nil {
+		response.WriteHeader(http.StatusConflict)
+		json.NewEncoder(response).Encode(Error{Message: "user is already a member"})
+		return
+	}
+
+	err = handler.OrganizationDB.WithContext(request.Context()).AddMember(membership)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusCreated)
+	json.NewEncoder(response).Encode(membership)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/database"
+	"github.com/otthonleao/go-products.git/internal/infra/webserver/middlewares"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/otthonleao/go-products.git/pkg/validation"
+)
+
+// attributeFilterPrefix marca os parâmetros de consulta que filtram atributos,
+// como attr.color=red ou attr.weight_lt=2.
+const attributeFilterPrefix = "attr."
+
+// categories retorna o repositório de categorias restrito à organização do usuário.
+func (handler *ProductHandler) categories(request *http.Request) database.CategoryInterface {
+	identity, _ := middlewares.IdentityFromContext(request.Context())
+	return handler.categoryDB.WithContext(request.Context()).ForTenant(identity.OrganizationID)
+}
+
+// setCategory define a categoria e os atributos do produto a partir da entrada.
+func setCategory(product *entity.Product, categoryID string, attributes map[string]interface{}) {
+	product.CategoryID = nil
+	if categoryID != "" {
+		id, err := entityPkg.ParseID(categoryID)
+		if err == nil {
+			product.CategoryID = &id
+		}
+	}
+	product.Attributes = attributes
+}
+
+// validateAttributes confere os atributos do produto contra o esquema da
+// categoria dele. As categorias já carregadas ficam em cache, para que a
+// criação em lote consulte cada uma só uma vez.
+func (handler *ProductHandler) validateAttributes(request *http.Request, product *entity.Product, cache map[entityPkg.ID]*entity.Category) validation.Errors {
+	if product.CategoryID == nil {
+		if len(product.Attributes) > 0 {
+			return validation.Errors{"attributes": {"require a category_id"}}
+		}
+		return nil
+	}
+
+	category, ok := cache[*product.CategoryID]
+	if !ok {
+		found, err := handler.categories(request).FindByID(product.CategoryID.String())
+		if err == nil {
+			category = found
+		}
+		cache[*product.CategoryID] = category
+	}
+	if category == nil {
+		return validation.Errors{"category_id": {"does not exist"}}
+	}
+
+	return category.ValidateAttributes(product.Attributes)
+}
+
+// prefixErrors acrescenta prefix às chaves dos erros, como "[3]." no lote.
+func prefixErrors(errors validation.Errors, prefix string) validation.Errors {
+	prefixed := make(validation.Errors, len(errors))
+	for key, messages := range errors {
+		prefixed[prefix+key] = messages
+	}
+	return prefixed
+}
+
+// parseProductFilter lê da consulta category_id, tags, tags_match e os
+// filtros attr.<nome>[_<op>].
+func parseProductFilter(query url.Values) (database.ProductFilter, error) {
+	filter := database.ProductFilter{CategoryID: query.Get("category_id")}
+	if filter.CategoryID != "" {
+		if _, err := entityPkg.ParseID(filter.CategoryID); err != nil {
+			return filter, fmt.Errorf("category_id must be a valid id")
+		}
+	}
+
+	var tags []string
+	for _, value := range query["tags"] {
+		tags = append(tags, strings.Split(value, ",")...)
+	}
+	var err error
+	filter.Tags, err = entity.NormalizeTags(tags)
+	if err != nil {
+		return filter, err
+	}
+	filter.TagMatch = query.Get("tags_match")
+	switch filter.TagMatch {
+	case "":
+		filter.TagMatch = database.TagMatchAny
+	case database.TagMatchAny, database.TagMatchAll:
+	default:
+		return filter, fmt.Errorf("tags_match must be %s or %s", database.TagMatchAny, database.TagMatchAll)
+	}
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if strings.HasPrefix(key, attributeFilterPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name, operator := attributeFilterName(strings.TrimPrefix(key, attributeFilterPrefix))
+		if !validation.IsIdentifier(name) {
+			return filter, fmt.Errorf("%s is not a valid attribute filter", key)
+		}
+
+		for _, value := range query[key] {
+			attribute := database.AttributeFilter{Name: name, Operator: operator, Value: value}
+			if number, err := strconv.ParseFloat(value, 64); err == nil {
+				attribute.Number = &number
+			} else if operator != database.OperatorEqual {
+				return filter, fmt.Errorf("%s must be a number", key)
+			}
+			filter.Attributes = append(filter.Attributes, attribute)
+		}
+	}
+
+	return filter, nil
+}
+
+// attributeFilterName separa o sufixo do operador do nome do atributo.
+func attributeFilterName(key string) (string, string) {
+	for _, operator := range []string{
+		database.OperatorLessOrEqual,
+		database.OperatorGreaterOrEqual,
+		database.OperatorLess,
+		database.OperatorGreater,
+	} {
+		if name, ok := strings.CutSuffix(key, "_"+operator); ok {
+			return name, operator
+		}
+	}
+	return key, database.OperatorEqual
+}
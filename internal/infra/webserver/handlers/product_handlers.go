@@ -2,26 +2,51 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/otthonleao/go-products.git/internal/dto"
 	"github.com/otthonleao/go-products.git/internal/entity"
 	"github.com/otthonleao/go-products.git/internal/infra/database"
+	"github.com/otthonleao/go-products.git/internal/infra/metrics"
+	"github.com/otthonleao/go-products.git/internal/infra/storage"
+	"github.com/otthonleao/go-products.git/internal/infra/webserver/middlewares"
 	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/otthonleao/go-products.git/pkg/validation"
 )
 
 type ProductHandler struct {
-	productDB database.ProductInterface
+	productDB  database.ProductInterface
+	categoryDB database.CategoryInterface
+	variantDB  database.ProductVariantInterface
+	imageDB    database.ProductImageInterface
+	blobs      storage.Storage
+	// maxImageBytes limita o tamanho de cada imagem enviada
+	maxImageBytes int64
 }
 
-func NewProductHandler(db database.ProductInterface) *ProductHandler {
+func NewProductHandler(db database.ProductInterface, categoryDB database.CategoryInterface, variantDB database.ProductVariantInterface, imageDB database.ProductImageInterface, blobs storage.Storage, maxImageBytes int64) *ProductHandler {
 	return &ProductHandler{
-		productDB: db,
+		productDB:     db,
+		categoryDB:    categoryDB,
+		variantDB:     variantDB,
+		imageDB:       imageDB,
+		blobs:         blobs,
+		maxImageBytes: maxImageBytes,
 	}
 }
 
+// products retorna o repositório restrito à organização do usuário autenticado.
+func (handler *ProductHandler) products(request *http.Request) database.ProductInterface {
+	identity, _ := middlewares.IdentityFromContext(request.Context())
+	return handler.productDB.WithContext(request.Context()).ForTenant(identity.OrganizationID)
+}
+
 // Create Product godoc
 // @Summary     Create a product
 // @Description Create a new product
@@ -29,31 +54,154 @@ func NewProductHandler(db database.ProductInterface) *ProductHandler {
 // @Accept      json
 // @Produce     json
 // @Param       request     body    dto.CreateProductInput     true    "Product request"
-// @Success     201
-// @Failure     500	 {object}    Error
+// @Param       Idempotency-Key    header    string     false    "Key to safely retry the request"
+// @Success     201		{object}    entity.Product
+// @Header      201		{string}    Location    "URL of the created product"
+// @Failure     400		{object}    Error
+// @Failure     401		{object}    Error
+// @Failure     403		{object}    Error
+// @Failure     409		{object}    Error
+// @Failure     413		{object}    Error
+// @Failure     415		{object}    Error
+// @Failure     422		{object}    Error
+// @Failure     500		{object}    Error
 // @Router      /products    [post]
 // @Security    ApiKeyAuth
 func (handler *ProductHandler) Create(response http.ResponseWriter, request *http.Request) {
 	
 	var product dto.CreateProductInput
 
-	err := json.NewDecoder(request.Body).Decode(&product)
+	err := decodeJSON(request, &product)
 	if err != nil {
-		response.WriteHeader(http.StatusBadRequest)
+		writeDecodeError(response, err)
 		return
 	} 
+	err = validation.Validate(product)
+	if err != nil {
+		writeValidationError(response, err)
+		return
+	}
 
 	p, err := entity.NewProduct(product.Name, product.Price)
+	if err == nil {
+		p.OptionAxes = product.OptionAxes
+		p.Stock = product.Stock
+		setCategory(p, product.CategoryID, product.Attributes)
+		err = p.SetTags(product.Tags)
+	}
+	if err == nil {
+		err = p.SetIdentifiers(product.SKU, product.Barcode)
+	}
 	if err != nil {
 		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+	if errs := handler.validateAttributes(request, p, map[entityPkg.ID]*entity.Category{}); errs != nil {
+		writeValidationError(response, errs)
 		return
 	}
 
-	err = handler.productDB.Create(p)
+	err = handler.products(request).Create(p)
 	if err != nil {
-		response.WriteHeader(http.StatusInternalServerError)
+		writeRepositoryError(response, err)
+		return
+	}
+	metrics.RecordProductCreated()
+
+	response.Header().Set("Content-Type", "application/json")
+	response.Header().Set("Location", "/products/"+p.ID.String())
+	response.WriteHeader(http.StatusCreated)
+	json.NewEncoder(response).Encode(p)
+}
+
+// maxBulkProducts limita a quantidade de produtos criados numa única requisição.
+const maxBulkProducts = 1000
+
+// Bulk Create Products godoc
+// @Summary     Create products in bulk
+// @Description Create up to 1000 products in a single transaction. The body may be sent with Content-Encoding gzip.
+// @Tags        products
+// @Accept      json
+// @Produce     json
+// @Param       request     body    []dto.CreateProductInput     true    "Products request"
+// @Param       Content-Encoding    header    string     false    "gzip to send a compressed body"
+// @Param       Idempotency-Key    header    string     false    "Key to safely retry the request"
+// @Success     201		{array}     entity.Product
+// @Failure     400		{object}    Error
+// @Failure     409		{object}    Error
+// @Failure     413		{object}    Error
+// @Failure     415		{object}    Error
+// @Failure     422		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /products/bulk    [post]
+// @Security    ApiKeyAuth
+func (handler *ProductHandler) BulkCreate(response http.ResponseWriter, request *http.Request) {
+	var input []dto.CreateProductInput
+
+	err := decodeJSON(request, &input)
+	if err != nil {
+		writeDecodeError(response, err)
+		return
+	}
+	err = validation.Validate(input)
+	if err != nil {
+		writeValidationError(response, err)
+		return
+	}
+
+	if len(input) == 0 {
+		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: "no products to create"})
+		return
+	}
+	if len(input) > maxBulkProducts {
+		response.WriteHeader(http.StatusRequestEntityTooLarge)
+		json.NewEncoder(response).Encode(Error{Message: fmt.Sprintf("at most %d products per request", maxBulkProducts)})
+		return
+	}
+
+	products := make([]*entity.Product, 0, len(input))
+	categories := map[entityPkg.ID]*entity.Category{}
+	attributeErrors := validation.Errors{}
+	for i, item := range input {
+		product, err := entity.NewProduct(item.Name, item.Price)
+		if err == nil {
+			product.OptionAxes = item.OptionAxes
+			product.Stock = item.Stock
+			setCategory(product, item.CategoryID, item.Attributes)
+			err = product.SetTags(item.Tags)
+		}
+		if err == nil {
+			err = product.SetIdentifiers(item.SKU, item.Barcode)
+		}
+		if err != nil {
+			response.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(response).Encode(Error{Message: fmt.Sprintf("products[%d]: %s", i, err.Error())})
+			return
+		}
+		for key, messages := range prefixErrors(handler.validateAttributes(request, product, categories), fmt.Sprintf("[%d].", i)) {
+			attributeErrors[key] = messages
+		}
+		products = append(products, product)
+	}
+	if len(attributeErrors) > 0 {
+		writeValidationError(response, attributeErrors)
+		return
+	}
+
+	err = handler.products(request).CreateMany(products)
+	if err != nil {
+		writeRepositoryError(response, err)
 		return
 	}
+	for range products {
+		metrics.RecordProductCreated()
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusCreated)
+	json.NewEncoder(response).Encode(products)
 }
 
 // Get Product godoc
@@ -63,7 +211,13 @@ func (handler *ProductHandler) Create(response http.ResponseWriter, request *htt
 // @Accept      json
 // @Produce     json
 // @Param       id          path    string     true    "Product ID"		Format(uuid)
+// @Param       include     query    string     false    "Set to variants to embed the product variants"	Enums(variants)
+// @Param       If-None-Match        header    string     false    "ETag of a cached representation"
+// @Param       If-Modified-Since    header    string     false    "Date of a cached representation"
 // @Success     200		{object}    entity.Product
+// @Header      200		{string}    ETag             "Strong validator of the representation"
+// @Header      200		{string}    Last-Modified    "Last change of the product"
+// @Success     304
 // @Failure     404
 // @Failure     500		{object}    Error
 // @Router      /products/{id}    [get]
@@ -76,15 +230,93 @@ func (handler *ProductHandler) GetProduct(response http.ResponseWriter, request
 		return
 	}
 
-	product, err := handler.productDB.FindByID(id)
+	product, err := handler.products(request).FindByID(id)
 	if err != nil {
 		response.WriteHeader(http.StatusNotFound)
 		return
 	}
 
-	response.Header().Set("Content-Type", "application/json")
-	response.WriteHeader(http.StatusOK)
-	json.NewEncoder(response).Encode(product)
+	if err := handler.attachImages(request, product); err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	lastModified := product.LastModified()
+	if includeVariants(request) {
+		product.Variants, err = handler.variantDB.WithContext(request.Context()).FindByProduct(product.ID.String())
+		if err != nil {
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		// Alterar uma variante também muda a representação do produto
+		for _, variant := range product.Variants {
+			if variant.UpdatedAt.After(lastModified) {
+				lastModified = variant.UpdatedAt
+			}
+		}
+	}
+
+	writeCacheable(response, request, lastModified, true, product)
+}
+
+// Get Product by SKU godoc
+// @Summary     Get a product by SKU
+// @Description Get a product of the organization by its SKU
+// @Tags        products
+// @Accept      json
+// @Produce     json
+// @Param       sku         path    string     true    "Product SKU"
+// @Success     200		{object}    entity.Product
+// @Success     304
+// @Failure     404
+// @Router      /products/by-sku/{sku}    [get]
+// @Security    ApiKeyAuth
+func (handler *ProductHandler) GetProductBySKU(response http.ResponseWriter, request *http.Request) {
+	product, err := handler.products(request).FindBySKU(chi.URLParam(request, "sku"))
+	if err != nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err := handler.attachImages(request, product); err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeCacheable(response, request, product.LastModified(), true, product)
+}
+
+// Get Product by Barcode godoc
+// @Summary     Get a product by barcode
+// @Description Get a product of the organization by its EAN-13, UPC-A or GTIN-14 barcode
+// @Tags        products
+// @Accept      json
+// @Produce     json
+// @Param       code        path    string     true    "Product barcode"
+// @Success     200		{object}    entity.Product
+// @Success     304
+// @Failure     400		{object}    Error
+// @Failure     404
+// @Router      /products/by-barcode/{code}    [get]
+// @Security    ApiKeyAuth
+func (handler *ProductHandler) GetProductByBarcode(response http.ResponseWriter, request *http.Request) {
+	code := chi.URLParam(request, "code")
+	if !validation.IsGTIN(code) {
+		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: entity.ErrInvalidBarcode.Error()})
+		return
+	}
+
+	product, err := handler.products(request).FindByBarcode(code)
+	if err != nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err := handler.attachImages(request, product); err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeCacheable(response, request, product.LastModified(), true, product)
 }
 
 // List Products godoc
@@ -95,7 +327,20 @@ func (handler *ProductHandler) GetProduct(response http.ResponseWriter, request
 // @Produce     json
 // @Param       page        query    int     false    "Page number"
 // @Param       limit       query    int     false    "Number of items per page"
+// @Param       include     query    string     false    "Set to variants to embed the variants of each product"	Enums(variants)
+// @Param       status      query    string     false    "Comma-separated statuses, or all. By default archived products are not listed"
+// @Param       category_id query    string     false    "Only products of this category"	Format(uuid)
+// @Param       attr.name   query    string     false    "Filter by attribute, as attr.color=red. Numeric attributes accept the suffixes _lt, _lte, _gt and _gte, as attr.weight_lt=2"
+// @Param       tags        query    string     false    "Comma-separated tags"
+// @Param       tags_match  query    string     false    "Match products with any (default) or all of the tags"	Enums(any, all)
+// @Param       facets      query    string     false    "Comma-separated counts to return with the products: tags, category, price. Changes the response to dto.ProductListOutput"
+// @Param       price_buckets    query    string     false    "Comma-separated ascending limits of the price facet, as 10,50,100"
+// @Param       If-None-Match        header    string     false    "ETag of a cached representation"
 // @Success     200		{array}    entity.Product
+// @Header      200		{string}    ETag             "Strong validator of the representation"
+// @Header      200		{string}    Last-Modified    "Last change among the listed products"
+// @Success     304
+// @Failure     400		{object}    Error
 // @Failure     500		{object}    Error
 // @Router      /products    [get]
 // @Security    ApiKeyAuth
@@ -116,15 +361,75 @@ func (handler *ProductHandler) GetProducts(response http.ResponseWriter, request
 
 	sort := request.URL.Query().Get("sort")
 
-	products, err := handler.productDB.FindAll(pageInt, limitInt, sort)
+	filter, err := parseProductFilter(request.URL.Query())
+	if err == nil {
+		filter.Statuses, err = parseStatuses(request.URL.Query().Get("status"))
+	}
+	if err != nil {
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	facetOptions, withFacets, err := parseFacetOptions(request.URL.Query())
+	if err != nil {
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	products, err := handler.products(request).FindAllFiltered(pageInt, limitInt, sort, filter)
 	if err != nil {
 		response.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	response.Header().Set("Content-Type", "application/json")
-	response.WriteHeader(http.StatusOK)
-	json.NewEncoder(response).Encode(products)
+	pointers := make([]*entity.Product, 0, len(products))
+	for i := range products {
+		pointers = append(pointers, &products[i])
+	}
+	if err := handler.attachImages(request, pointers...); err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if includeVariants(request) && len(products) > 0 {
+		ids := make([]string, 0, len(products))
+		for _, product := range products {
+			ids = append(ids, product.ID.String())
+		}
+		variants, err := handler.variantDB.WithContext(request.Context()).FindByProducts(ids)
+		if err != nil {
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		for i := range products {
+			products[i].Variants = variants[products[i].ID.String()]
+		}
+	}
+
+	var lastModified time.Time
+	for _, product := range products {
+		if product.LastModified().After(lastModified) {
+			lastModified = product.LastModified()
+		}
+	}
+
+	if withFacets {
+		facets, err := handler.products(request).Facets(filter, facetOptions)
+		if err != nil {
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if products == nil {
+			products = []entity.Product{}
+		}
+		writeCacheable(response, request, lastModified, false, dto.ProductListOutput{Products: products, Facets: *facets})
+		return
+	}
+	writeCacheable(response, request, lastModified, false, products)
 }
 
 // Update Product godoc
@@ -135,8 +440,13 @@ func (handler *ProductHandler) GetProducts(response http.ResponseWriter, request
 // @Produce     json
 // @Param       id          path    string     true    "Product ID"		Format(uuid)
 // @Param       request     body    dto.CreateProductInput     true    "Product request"
-// @Success     200
+// @Success     200		{object}    entity.Product
+// @Failure     400		{object}    Error
 // @Failure     404
+// @Failure     409		{object}    Error
+// @Failure     413		{object}    Error
+// @Failure     415		{object}    Error
+// @Failure     422		{object}    Error
 // @Failure     500		{object}    Error
 // @Router      /products/{id}    [put]
 // @Security    ApiKeyAuth
@@ -150,29 +460,75 @@ func (handler *ProductHandler) UpdateProduct(response http.ResponseWriter, reque
 
 	var product entity.Product
 
-	err := json.NewDecoder(request.Body).Decode(&product)
+	err := decodeJSON(request, &product)
 	if err != nil {
-		response.WriteHeader(http.StatusBadRequest)
+		writeDecodeError(response, err)
+		return
+	}
+	err = validation.Validate(updateInput(product))
+	if err != nil {
+		writeValidationError(response, err)
 		return
 	}
 
 	product.ID, err = entityPkg.ParseID(id)
 	if err != nil {
 		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
 		return
 	}
 
-	_, err = handler.productDB.FindByID(id)
+	products := handler.products(request)
+
+	existing, err := products.FindByID(id)
 	if err != nil {
 		response.WriteHeader(http.StatusNotFound)
 		return
 	}
 
-	err = handler.productDB.Update(&product)
+	// A data de criação não é alterada pelo cliente e variantes, imagens, tags e
+	// estado têm endpoints próprios
+	product.CreatedAt = existing.CreatedAt
+	product.Tags = existing.Tags
+	product.Status = existing.Status
+	product.StatusChangedAt = existing.StatusChangedAt
+	product.PublishedAt = existing.PublishedAt
+	product.Variants = nil
+	product.Images = nil
+	if err := product.Validate(); err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	if errs := handler.validateAttributes(request, &product, map[entityPkg.ID]*entity.Category{}); errs != nil {
+		writeValidationError(response, errs)
+		return
+	}
+
+	if !slices.Equal(product.OptionAxes, existing.OptionAxes) {
+		count, err := handler.variantDB.WithContext(request.Context()).Count(id)
+		if err != nil {
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if count > 0 {
+			response.Header().Set("Content-Type", "application/json")
+			response.WriteHeader(http.StatusConflict)
+			json.NewEncoder(response).Encode(Error{Message: "option axes cannot change while the product has variants"})
+			return
+		}
+	}
+
+	err = products.Update(&product)
 	if err != nil {
-		response.WriteHeader(http.StatusInternalServerError)
+		writeRepositoryError(response, err)
 		return
 	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+	json.NewEncoder(response).Encode(product)
 }
 
 // Delete Product godoc
@@ -196,16 +552,54 @@ func (handler *ProductHandler) DeleteProduct(response http.ResponseWriter, reque
 		return
 	}
 
-	_, err := handler.productDB.FindByID(id)
+	products := handler.products(request)
+
+	_, err := products.FindByID(id)
 	if err != nil {
 		response.WriteHeader(http.StatusNotFound)
 		return
 	}
 
-	err = handler.productDB.Delete(id)
+	// Os arquivos das imagens são removidos depois que o produto sai do banco
+	images, err := handler.images(request).FindByProduct(id)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	err = products.Delete(id)
 	if err != nil {
 		response.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	for _, image := range images {
+		handler.deleteBlobs(request.Context(), image.BlobKeys())
+	}
+	metrics.RecordProductDeleted()
 	response.WriteHeader(http.StatusNoContent)
-}
\ No newline at end of file
+}
+// updateInput monta o DTO validado no PUT, que recebe o produto completo.
+func updateInput(product entity.Product) dto.CreateProductInput {
+	input := dto.CreateProductInput{Name: product.Name, Price: product.Price, OptionAxes: product.OptionAxes, Stock: product.Stock}
+	if product.SKU != nil {
+		input.SKU = *product.SKU
+	}
+	if product.Barcode != nil {
+		input.Barcode = *product.Barcode
+	}
+	if product.CategoryID != nil {
+		input.CategoryID = product.CategoryID.String()
+	}
+	input.Attributes = product.Attributes
+	return input
+}
+
+// includeVariants indica se a requisição pediu as variantes com ?include=variants.
+func includeVariants(request *http.Request) bool {
+	for _, include := range strings.Split(request.URL.Query().Get("include"), ",") {
+		if strings.TrimSpace(include) == "variants" {
+			return true
+		}
+	}
+	return false
+}
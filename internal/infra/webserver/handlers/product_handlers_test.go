@@ -0,0 +1,541 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/jwtauth"
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/database"
+	"github.com/otthonleao/go-products.git/internal/infra/storage"
+	"github.com/otthonleao/go-products.git/internal/infra/webserver/middlewares"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var testTokenAuth = jwtauth.New("HS256", []byte("secret"), nil)
+
+func newProductRouter(t *testing.T) (http.Handler, *database.Product) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	db.AutoMigrate(database.Models()...)
+
+	productDB := database.NewProduct(db)
+	categoryDB := database.NewCategory(db)
+	blobs := storage.NewLocal(t.TempDir(), "/media")
+	productHandler := NewProductHandler(productDB, categoryDB, database.NewProductVariant(db), database.NewProductImage(db), blobs, 64<<10)
+	categoryHandler := NewCategoryHandler(categoryDB, productDB)
+	catalogHandler := NewCatalogHandler(productDB, database.NewProductVariant(db), database.NewProductImage(db), database.NewOrganization(db), blobs, time.Minute, 5*time.Minute)
+	cartHandler := NewCartHandler(database.NewCart(db), productDB, database.NewProductVariant(db), database.NewOrganization(db), time.Hour)
+	promotionHandler := NewPromotionHandler(database.NewPromotion(db), productDB, database.NewProductVariant(db), categoryDB, database.NewOrganization(db))
+	orderHandler := NewOrderHandler(database.NewOrder(db), database.NewCart(db), productDB, database.NewProductVariant(db), database.NewOrganization(db), database.NewPromotion(db))
+
+	route := chi.NewRouter()
+	route.Route("/catalog/{organizationID}/products", func(chiRoute chi.Router) {
+		chiRoute.Get("/", catalogHandler.GetProducts)
+		chiRoute.Get("/{id}", catalogHandler.GetProduct)
+	})
+	route.Route("/carts", func(chiRoute chi.Router) {
+		chiRoute.Use(jwtauth.Verifier(testTokenAuth))
+		chiRoute.Use(middlewares.OptionalIdentify)
+		chiRoute.Post("/", cartHandler.Create)
+		chiRoute.Get("/{id}", cartHandler.GetCart)
+		chiRoute.Delete("/{id}", cartHandler.DeleteCart)
+		chiRoute.Post("/{id}/items", cartHandler.AddItem)
+		chiRoute.Put("/{id}/items/{itemID}", cartHandler.UpdateItem)
+		chiRoute.Delete("/{id}/items/{itemID}", cartHandler.DeleteItem)
+	})
+	route.Route("/orders", func(chiRoute chi.Router) {
+		chiRoute.Use(jwtauth.Verifier(testTokenAuth))
+		chiRoute.Use(middlewares.Authenticate)
+		chiRoute.Use(middlewares.RequireTenant)
+		chiRoute.Use(middlewares.RequireRole(entity.RoleOwner, entity.RoleAdmin))
+		chiRoute.Get("/", orderHandler.GetOrders)
+		chiRoute.Get("/{id}", orderHandler.GetOrder)
+		chiRoute.Post("/{id}/transitions", orderHandler.CreateTransition)
+	})
+	route.Route("/users/me/orders", func(chiRoute chi.Router) {
+		chiRoute.Use(jwtauth.Verifier(testTokenAuth))
+		chiRoute.Use(middlewares.Identify)
+		chiRoute.Post("/", orderHandler.PlaceOrder)
+		chiRoute.Get("/", orderHandler.GetMyOrders)
+		chiRoute.Get("/{id}", orderHandler.GetMyOrder)
+		chiRoute.Post("/{id}/cancel", orderHandler.CancelMyOrder)
+	})
+	route.Route("/promotions", func(chiRoute chi.Router) {
+		chiRoute.Use(jwtauth.Verifier(testTokenAuth))
+		chiRoute.Use(middlewares.Authenticate)
+		chiRoute.Use(middlewares.RequireTenant)
+		chiRoute.Use(middlewares.RequireRole(entity.RoleOwner, entity.RoleAdmin))
+		chiRoute.Post("/", promotionHandler.Create)
+		chiRoute.Get("/", promotionHandler.GetPromotions)
+		chiRoute.Get("/{id}", promotionHandler.GetPromotion)
+		chiRoute.Put("/{id}", promotionHandler.UpdatePromotion)
+		chiRoute.Delete("/{id}", promotionHandler.DeletePromotion)
+	})
+	route.Post("/pricing/quote", promotionHandler.CreateQuote)
+	route.Route("/categories", func(chiRoute chi.Router) {
+		chiRoute.Use(jwtauth.Verifier(testTokenAuth))
+		chiRoute.Use(middlewares.Authenticate)
+		chiRoute.Use(middlewares.RequireTenant)
+		chiRoute.Post("/", categoryHandler.Create)
+		chiRoute.Get("/", categoryHandler.GetCategories)
+		chiRoute.Get("/{id}", categoryHandler.GetCategory)
+		chiRoute.Put("/{id}", categoryHandler.UpdateCategory)
+		chiRoute.Delete("/{id}", categoryHandler.DeleteCategory)
+	})
+	route.Route("/products", func(chiRoute chi.Router) {
+		chiRoute.Use(jwtauth.Verifier(testTokenAuth))
+		chiRoute.Use(middlewares.Authenticate)
+		chiRoute.Use(middlewares.RequireTenant)
+		chiRoute.Post("/", productHandler.Create)
+		chiRoute.With(middlewares.DecompressRequest(1024)).Post("/bulk", productHandler.BulkCreate)
+		chiRoute.Get("/by-sku/{sku}", productHandler.GetProductBySKU)
+		chiRoute.Get("/by-barcode/{code}", productHandler.GetProductByBarcode)
+		chiRoute.Get("/{id}", productHandler.GetProduct)
+		chiRoute.Get("/", productHandler.GetProducts)
+		chiRoute.Put("/{id}", productHandler.UpdateProduct)
+		chiRoute.Delete("/{id}", productHandler.DeleteProduct)
+		chiRoute.Put("/{id}/tags", productHandler.SetTags)
+		chiRoute.Post("/{id}/transitions", productHandler.CreateTransition)
+		chiRoute.Get("/{id}/transitions", productHandler.GetTransitions)
+		chiRoute.Post("/{id}/variants", productHandler.CreateVariant)
+		chiRoute.Get("/{id}/variants", productHandler.GetVariants)
+		chiRoute.Get("/{id}/variants/{variantID}", productHandler.GetVariant)
+		chiRoute.Put("/{id}/variants/{variantID}", productHandler.UpdateVariant)
+		chiRoute.Delete("/{id}/variants/{variantID}", productHandler.DeleteVariant)
+		chiRoute.Post("/{id}/images", productHandler.UploadImage)
+		chiRoute.Get("/{id}/images", productHandler.GetImages)
+		chiRoute.Put("/{id}/images/order", productHandler.ReorderImages)
+		chiRoute.Put("/{id}/images/{imageID}/primary", productHandler.SetPrimaryImage)
+		chiRoute.Delete("/{id}/images/{imageID}", productHandler.DeleteImage)
+	})
+	return route, productDB
+}
+
+func tokenFor(t *testing.T, organizationID string) string {
+	claims := map[string]interface{}{"sub": entityPkg.NewID().String()}
+	if organizationID != "" {
+		claims["org"] = organizationID
+		claims["role"] = entity.RoleMember
+	}
+	_, token, err := testTokenAuth.Encode(claims)
+	assert.NoError(t, err)
+	return token
+}
+
+func doRequest(handler http.Handler, method, target, token, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestProductHandler_CrossTenantAccessReturnsNotFound(t *testing.T) {
+	router, productDB := newProductRouter(t)
+
+	acmeID := entityPkg.NewID().String()
+	globexToken := tokenFor(t, entityPkg.NewID().String())
+
+	product, _ := entity.NewProduct("Acme Product", 10)
+	assert.NoError(t, productDB.ForTenant(acmeID).Create(product))
+	target := "/products/" + product.ID.String()
+
+	recorder := doRequest(router, http.MethodGet, target, tokenFor(t, acmeID), "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doRequest(router, http.MethodGet, target, globexToken, "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = doRequest(router, http.MethodPut, target, globexToken, `{"name": "Hijacked", "price": 1}`)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = doRequest(router, http.MethodDelete, target, globexToken, "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = doRequest(router, http.MethodGet, "/products", globexToken, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, "[]", recorder.Body.String())
+
+	productFound, err := productDB.ForTenant(acmeID).FindByID(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "Acme Product", productFound.Name)
+}
+
+func TestProductHandler_TokenWithoutOrganizationIsForbidden(t *testing.T) {
+	router, _ := newProductRouter(t)
+
+	recorder := doRequest(router, http.MethodGet, "/products", tokenFor(t, ""), "")
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = doRequest(router, http.MethodGet, "/products", "", "")
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestProductHandler_Create(t *testing.T) {
+	router, productDB := newProductRouter(t)
+	organizationID := entityPkg.NewID().String()
+	token := tokenFor(t, organizationID)
+
+	recorder := doRequest(router, http.MethodPost, "/products", token, `{"name": "Product 1", "price": 10}`)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var product entity.Product
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &product))
+	assert.Equal(t, "Product 1", product.Name)
+	assert.Equal(t, 10.0, product.Price)
+	assert.Equal(t, organizationID, product.OrganizationID.String())
+	assert.Equal(t, "/products/"+product.ID.String(), recorder.Header().Get("Location"))
+
+	_, err := productDB.ForTenant(organizationID).FindByID(product.ID.String())
+	assert.NoError(t, err)
+
+	recorder = doRequest(router, http.MethodGet, recorder.Header().Get("Location"), token, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestProductHandler_CreateErrors(t *testing.T) {
+	router, productDB := newProductRouter(t)
+	token := tokenFor(t, entityPkg.NewID().String())
+
+	recorder := doRequest(router, http.MethodPost, "/products", token, `{"name": `)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = doRequest(router, http.MethodPost, "/products", token, `{"name": "", "price": -10.555}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.JSONEq(t, `{"message": "validation failed", "errors": {"name": ["is required"], "price": ["must be greater than 0", "must have at most 2 decimal places"]}}`, recorder.Body.String())
+
+	recorder = doRequest(router, http.MethodPost, "/products", "", `{"name": "Product 1", "price": 10}`)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = doRequest(router, http.MethodPost, "/products", tokenFor(t, ""), `{"name": "Product 1", "price": 10}`)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	sqlDB, _ := productDB.DB.DB()
+	sqlDB.Close()
+	recorder = doRequest(router, http.MethodPost, "/products", token, `{"name": "Product 1", "price": 10}`)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
+
+func TestProductHandler_UpdateReturnsUpdatedProduct(t *testing.T) {
+	router, productDB := newProductRouter(t)
+	organizationID := entityPkg.NewID().String()
+	token := tokenFor(t, organizationID)
+
+	product, _ := entity.NewProduct("Product 1", 10)
+	assert.NoError(t, productDB.ForTenant(organizationID).Create(product))
+	target := "/products/" + product.ID.String()
+
+	recorder := doRequest(router, http.MethodPut, target, token, `{"name": "Product 2", "price": 20}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var updated entity.Product
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &updated))
+	assert.Equal(t, product.ID, updated.ID)
+	assert.Equal(t, "Product 2", updated.Name)
+	assert.Equal(t, 20.0, updated.Price)
+	assert.Equal(t, organizationID, updated.OrganizationID.String())
+	assert.True(t, product.CreatedAt.Equal(updated.CreatedAt))
+
+	recorder = doRequest(router, http.MethodPut, target, token, `{"name": "", "price": 20}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+	recorder = doRequest(router, http.MethodPut, target, token, `{"name": `)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = doRequest(router, http.MethodPut, "/products/not-an-id", token, `{"name": "Product 2", "price": 20}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = doRequest(router, http.MethodPut, "/products/"+entityPkg.NewID().String(), token, `{"name": "Product 2", "price": 20}`)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestProductHandler_GetAndDelete(t *testing.T) {
+	router, productDB := newProductRouter(t)
+	organizationID := entityPkg.NewID().String()
+	token := tokenFor(t, organizationID)
+
+	product, _ := entity.NewProduct("Product 1", 10)
+	assert.NoError(t, productDB.ForTenant(organizationID).Create(product))
+	target := "/products/" + product.ID.String()
+
+	recorder := doRequest(router, http.MethodGet, "/products", token, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var products []entity.Product
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &products))
+	assert.Len(t, products, 1)
+
+	recorder = doRequest(router, http.MethodDelete, target, token, "")
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	recorder = doRequest(router, http.MethodGet, target, token, "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = doRequest(router, http.MethodDelete, target, token, "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestProductHandler_ConditionalGet(t *testing.T) {
+	router, productDB := newProductRouter(t)
+	organizationID := entityPkg.NewID().String()
+	token := tokenFor(t, organizationID)
+
+	product, _ := entity.NewProduct("Product 1", 10)
+	assert.NoError(t, productDB.ForTenant(organizationID).Create(product))
+	target := "/products/" + product.ID.String()
+
+	recorder := doRequest(router, http.MethodGet, target, token, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	etag := recorder.Header().Get("ETag")
+	lastModified := recorder.Header().Get("Last-Modified")
+	assert.NotEmpty(t, etag)
+	assert.NotEmpty(t, lastModified)
+
+	recorder = doConditionalRequest(router, target, token, "If-None-Match", etag)
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+	assert.Empty(t, recorder.Body.String())
+	assert.Equal(t, etag, recorder.Header().Get("ETag"))
+
+	recorder = doConditionalRequest(router, target, token, "If-None-Match", `"other", W/`+etag)
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+
+	recorder = doConditionalRequest(router, target, token, "If-Modified-Since", lastModified)
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+
+	recorder = doConditionalRequest(router, target, token, "If-Modified-Since", product.CreatedAt.Add(-time.Hour).UTC().Format(http.TimeFormat))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// Depois de uma alteração o ETag antigo não confere mais
+	time.Sleep(10 * time.Millisecond)
+	recorder = doRequest(router, http.MethodPut, target, token, `{"name": "Product 2", "price": 20}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doConditionalRequest(router, target, token, "If-None-Match", etag)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NotEqual(t, etag, recorder.Header().Get("ETag"))
+}
+
+func TestProductHandler_ConditionalList(t *testing.T) {
+	router, productDB := newProductRouter(t)
+	organizationID := entityPkg.NewID().String()
+	token := tokenFor(t, organizationID)
+
+	product, _ := entity.NewProduct("Product 1", 10)
+	assert.NoError(t, productDB.ForTenant(organizationID).Create(product))
+	other, _ := entity.NewProduct("Product 2", 20)
+	assert.NoError(t, productDB.ForTenant(organizationID).Create(other))
+
+	recorder := doRequest(router, http.MethodGet, "/products", token, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	etag := recorder.Header().Get("ETag")
+	lastModified := recorder.Header().Get("Last-Modified")
+	assert.NotEmpty(t, lastModified)
+
+	recorder = doConditionalRequest(router, "/products", token, "If-None-Match", etag)
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+
+	// Remover um item não muda a data mais recente, mas muda o ETag
+	assert.NoError(t, productDB.ForTenant(organizationID).Delete(product.ID.String()))
+	recorder = doConditionalRequest(router, "/products", token, "If-None-Match", etag)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doConditionalRequest(router, "/products", token, "If-Modified-Since", lastModified)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func doConditionalRequest(handler http.Handler, target, token, header, value string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, target, nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set(header, value)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestProductHandler_BulkCreate(t *testing.T) {
+	router, productDB := newProductRouter(t)
+	organizationID := entityPkg.NewID().String()
+	token := tokenFor(t, organizationID)
+
+	recorder := doRequest(router, http.MethodPost, "/products/bulk", token, `[{"name": "Product 1", "price": 10}, {"name": "Product 2", "price": 20}]`)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	var products []entity.Product
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &products))
+	assert.Len(t, products, 2)
+
+	productsFound, err := productDB.ForTenant(organizationID).FindAll(0, 0, "asc")
+	assert.NoError(t, err)
+	assert.Len(t, productsFound, 2)
+
+	recorder = doRequest(router, http.MethodPost, "/products/bulk", token, `[{"name": "Product 3", "price": 10}, {"name": "", "price": 20}]`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.JSONEq(t, `{"message": "validation failed", "errors": {"[1].name": ["is required"]}}`, recorder.Body.String())
+
+	recorder = doRequest(router, http.MethodPost, "/products/bulk", token, `[]`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	productsFound, _ = productDB.ForTenant(organizationID).FindAll(0, 0, "asc")
+	assert.Len(t, productsFound, 2)
+}
+
+func TestProductHandler_BulkCreateGzip(t *testing.T) {
+	router, productDB := newProductRouter(t)
+	organizationID := entityPkg.NewID().String()
+	token := tokenFor(t, organizationID)
+
+	gzipRequest := func(body string) *httptest.ResponseRecorder {
+		var compressed bytes.Buffer
+		writer := gzip.NewWriter(&compressed)
+		writer.Write([]byte(body))
+		writer.Close()
+
+		request := httptest.NewRequest(http.MethodPost, "/products/bulk", &compressed)
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Content-Encoding", "gzip")
+		request.Header.Set("Authorization", "Bearer "+token)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := gzipRequest(`[{"name": "Product 1", "price": 10}]`)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	// Poucos bytes comprimidos que passam do limite descomprimido
+	recorder = gzipRequest(`[{"name": "` + strings.Repeat("a", 4096) + `", "price": 10}]`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+
+	productsFound, _ := productDB.ForTenant(organizationID).FindAll(0, 0, "asc")
+	assert.Len(t, productsFound, 1)
+}
+
+func TestProductHandler_SKUAndBarcode(t *testing.T) {
+	router, _ := newProductRouter(t)
+	token := tokenFor(t, entityPkg.NewID().String())
+
+	recorder := doRequest(router, http.MethodPost, "/products", token, `{"name": "Product 1", "price": 10, "sku": "SKU-1", "barcode": "4006381333931"}`)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	var product entity.Product
+	json.Unmarshal(recorder.Body.Bytes(), &product)
+
+	recorder = doRequest(router, http.MethodGet, "/products/by-sku/SKU-1", token, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), product.ID.String())
+
+	recorder = doRequest(router, http.MethodGet, "/products/by-barcode/4006381333931", token, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), product.ID.String())
+
+	recorder = doRequest(router, http.MethodGet, "/products/by-barcode/4006381333932", token, "")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = doRequest(router, http.MethodGet, "/products/by-sku/SKU-404", token, "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// Outra organização não enxerga o produto
+	recorder = doRequest(router, http.MethodGet, "/products/by-sku/SKU-1", tokenFor(t, entityPkg.NewID().String()), "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = doRequest(router, http.MethodPost, "/products", token, `{"name": "Product 2", "price": 10, "sku": "SKU-1"}`)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	assert.JSONEq(t, `{"message": "conflict: sku is already in use"}`, recorder.Body.String())
+
+	recorder = doRequest(router, http.MethodPost, "/products/bulk", token, `[{"name": "Product 3", "price": 10, "barcode": "036000291452"}, {"name": "Product 4", "price": 10, "barcode": "036000291452"}]`)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	recorder = doRequest(router, http.MethodPost, "/products", token, `{"name": "Product 5", "price": 10, "sku": "SKU 5", "barcode": "123"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"sku"`)
+	assert.Contains(t, recorder.Body.String(), `"barcode"`)
+}
+
+func TestProductHandler_Variants(t *testing.T) {
+	router, productDB := newProductRouter(t)
+	token := tokenFor(t, entityPkg.NewID().String())
+
+	recorder := doRequest(router, http.MethodPost, "/products", token, `{"name": "T-Shirt", "price": 50, "option_axes": ["size", "color"]}`)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	var product entity.Product
+	json.Unmarshal(recorder.Body.Bytes(), &product)
+	assert.Equal(t, []string{"size", "color"}, product.OptionAxes)
+	variantsURL := "/products/" + product.ID.String() + "/variants"
+
+	recorder = doRequest(router, http.MethodPost, variantsURL, token, `{"options": {"size": "M", "color": "red"}, "sku": "SHIRT-M-RED", "price": 55, "stock": 10}`)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	var variant entity.ProductVariant
+	json.Unmarshal(recorder.Body.Bytes(), &variant)
+	assert.Equal(t, variantsURL+"/"+variant.ID.String(), recorder.Header().Get("Location"))
+	assert.Equal(t, 10, variant.Stock)
+
+	// As variantes fazem parte do produto, então o updated_at dele avança junto
+	touched, err := productDB.FindByID(product.ID.String())
+	assert.NoError(t, err)
+	assert.True(t, touched.UpdatedAt.After(product.UpdatedAt))
+	assert.Equal(t, product.Name, touched.Name)
+
+	recorder = doRequest(router, http.MethodPost, variantsURL, token, `{"options": {"size": "m", "color": "Red"}, "stock": 1}`)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	assert.JSONEq(t, `{"message": "conflict: combination is already in use"}`, recorder.Body.String())
+
+	recorder = doRequest(router, http.MethodPost, variantsURL, token, `{"options": {"size": "L"}, "stock": 1}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = doRequest(router, http.MethodPost, variantsURL, token, `{"options": {}, "price": -1, "stock": -1}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"options"`)
+	assert.Contains(t, recorder.Body.String(), `"price"`)
+	assert.Contains(t, recorder.Body.String(), `"stock"`)
+
+	recorder = doRequest(router, http.MethodPut, variantsURL+"/"+variant.ID.String(), token, `{"options": {"size": "M", "color": "red"}, "stock": 7}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"stock":7`)
+	assert.NotContains(t, recorder.Body.String(), `"price"`)
+
+	recorder = doRequest(router, http.MethodGet, variantsURL, token, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), variant.ID.String())
+
+	// Por padrão o produto vem sem as variantes
+	recorder = doRequest(router, http.MethodGet, "/products/"+product.ID.String(), token, "")
+	assert.NotContains(t, recorder.Body.String(), `"variants"`)
+	recorder = doRequest(router, http.MethodGet, "/products/"+product.ID.String()+"?include=variants", token, "")
+	assert.Contains(t, recorder.Body.String(), variant.ID.String())
+	recorder = doRequest(router, http.MethodGet, "/products?include=variants", token, "")
+	assert.Contains(t, recorder.Body.String(), variant.ID.String())
+
+	// Os eixos não mudam enquanto houver variantes
+	recorder = doRequest(router, http.MethodPut, "/products/"+product.ID.String(), token, `{"name": "T-Shirt", "price": 50, "option_axes": ["size"]}`)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	// Outra organização não enxerga as variantes
+	otherToken := tokenFor(t, entityPkg.NewID().String())
+	recorder = doRequest(router, http.MethodGet, variantsURL, otherToken, "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	recorder = doRequest(router, http.MethodDelete, variantsURL+"/"+variant.ID.String(), otherToken, "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = doRequest(router, http.MethodDelete, variantsURL+"/"+variant.ID.String(), token, "")
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	recorder = doRequest(router, http.MethodGet, variantsURL+"/"+variant.ID.String(), token, "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = doRequest(router, http.MethodPut, "/products/"+product.ID.String(), token, `{"name": "T-Shirt", "price": 50, "option_axes": ["size"]}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/otthonleao/go-products.git/internal/dto"
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/database"
+	"github.com/otthonleao/go-products.git/internal/infra/storage"
+	"github.com/otthonleao/go-products.git/pkg/imaging"
+	"github.com/otthonleao/go-products.git/pkg/validation"
+)
+
+// imageFormField é o campo do formulário multipart com o arquivo da imagem.
+const imageFormField = "image"
+
+// images retorna o repositório de imagens com o contexto da requisição.
+func (handler *ProductHandler) images(request *http.Request) database.ProductImageInterface {
+	return handler.imageDB.WithContext(request.Context())
+}
+
+// attachImages preenche Images, com as URLs resolvidas, em cada produto.
+func (handler *ProductHandler) attachImages(request *http.Request, products ...*entity.Product) error {
+	return loadImages(handler.images(request), handler.blobs, products...)
+}
+
+// loadImages busca as imagens dos produtos numa única consulta e resolve as
+// URLs pelo storage.
+func loadImages(imageDB database.ProductImageInterface, blobs storage.Storage, products ...*entity.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID.String())
+	}
+	images, err := imageDB.FindByProducts(ids)
+	if err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		product.Images = images[product.ID.String()]
+		for i := range product.Images {
+			product.Images[i].ResolveURLs(blobs.URL)
+		}
+	}
+	return nil
+}
+
+// touch avança o updated_at do produto, já que as imagens e as variantes fazem
+// parte da representação dele e o Last-Modified precisa mudar junto.
+func (handler *ProductHandler) touch(request *http.Request, product *entity.Product) {
+	product.UpdatedAt = time.Now()
+	if err := handler.products(request).Touch(product.ID.String(), product.UpdatedAt); err != nil {
+		slog.WarnContext(request.Context(), "Erro ao atualizar a data de alteração do produto", slog.Any("error", err))
+	}
+}
+
+// deleteBlobs remove os arquivos sem interromper a requisição: um arquivo órfão
+// no storage não afeta a API.
+func (handler *ProductHandler) deleteBlobs(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := handler.blobs.Delete(ctx, key); err != nil {
+			slog.WarnContext(ctx, "Erro ao remover arquivo do storage", slog.String("key", key), slog.Any("error", err))
+		}
+	}
+}
+
+// Upload Image godoc
+// @Summary     Upload a product image
+// @Description Upload a JPEG, PNG, GIF or WebP image as multipart/form-data. The type is detected from the content. Thumbnails (small, medium and large) are generated automatically and the first image of a product becomes its primary image.
+// @Tags        images
+// @Accept      multipart/form-data
+// @Produce     json
+// @Param       id          path    string     true    "Product ID"		Format(uuid)
+// @Param       image       formData    file       true    "Image file"
+// @Param       Idempotency-Key    header    string     false    "Key to safely retry the request"
+// @Success     201		{object}    entity.ProductImage
+// @Header      201		{string}    Location    "URL of the created image"
+// @Failure     400		{object}    Error
+// @Failure     404
+// @Failure     413		{object}    Error
+// @Failure     415		{object}    Error
+// @Failure     422		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /products/{id}/images    [post]
+// @Security    ApiKeyAuth
+func (handler *ProductHandler) UploadImage(response http.ResponseWriter, request *http.Request) {
+	product, ok := handler.routeProduct(response, request)
+	if !ok {
+		return
+	}
+
+	data, status, err := handler.readImage(request)
+	if err != nil {
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(status)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	decoded, err := imaging.Decode(data)
+	if err != nil {
+		status := http.StatusUnprocessableEntity
+		message := "invalid image: " + err.Error()
+		if errors.Is(err, imaging.ErrUnsupportedType) {
+			status = http.StatusUnsupportedMediaType
+			contentType, _ := imaging.Sniff(data)
+			message = fmt.Sprintf("unsupported image type %s", contentType)
+		}
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(status)
+		json.NewEncoder(response).Encode(Error{Message: message})
+		return
+	}
+
+	thumbnails, err := decoded.Thumbnails(imaging.ThumbnailSizes)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	image, err := entity.NewProductImage(product, decoded.ContentType, int64(len(data)), decoded.Width, decoded.Height)
+	if err != nil {
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	// Os arquivos vão primeiro para o storage: o registro só aparece quando
+	// todos eles existem
+	image.Key = image.BlobKey("original", imaging.Extension(decoded.ContentType))
+	err = handler.blobs.Put(request.Context(), image.Key, data, decoded.ContentType)
+	for _, thumbnail := range thumbnails {
+		if err != nil {
+			break
+		}
+		key := image.BlobKey(thumbnail.Size.Name, imaging.Extension(thumbnail.ContentType))
+		image.ThumbnailKeys[thumbnail.Size.Name] = key
+		err = handler.blobs.Put(request.Context(), key, thumbnail.Data, thumbnail.ContentType)
+	}
+	if err == nil {
+		err = handler.images(request).Create(image)
+	}
+	if err != nil {
+		handler.deleteBlobs(context.WithoutCancel(request.Context()), image.BlobKeys())
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	handler.touch(request, product)
+
+	image.ResolveURLs(handler.blobs.URL)
+	response.Header().Set("Content-Type", "application/json")
+	response.Header().Set("Location", "/products/"+product.ID.String()+"/images/"+image.ID.String())
+	response.WriteHeader(http.StatusCreated)
+	json.NewEncoder(response).Encode(image)
+}
+
+// readImage lê o arquivo do campo "image" do formulário multipart, respeitando
+// o tamanho máximo da imagem. Retorna o status HTTP adequado junto com o erro.
+func (handler *ProductHandler) readImage(request *http.Request) ([]byte, int, error) {
+	reader, err := request.MultipartReader()
+	if err != nil {
+		return nil, http.StatusUnsupportedMediaType, errors.New("Content-Type must be multipart/form-data")
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, http.StatusBadRequest, fmt.Errorf("form field %q with the image file is required", imageFormField)
+		}
+		if err != nil {
+			return nil, readErrorStatus(err), err
+		}
+		if part.FormName() != imageFormField {
+			part.Close()
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(part, handler.maxImageBytes+1))
+		part.Close()
+		if err != nil {
+			return nil, readErrorStatus(err), err
+		}
+		if int64(len(data)) > handler.maxImageBytes {
+			return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("image must not exceed %d bytes", handler.maxImageBytes)
+		}
+		if len(data) == 0 {
+			return nil, http.StatusBadRequest, errors.New("image file is empty")
+		}
+		return data, http.StatusOK, nil
+	}
+}
+
+func readErrorStatus(err error) int {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// List Images godoc
+// @Summary     List product images
+// @Description Get the images of a product in display order
+// @Tags        images
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Product ID"		Format(uuid)
+// @Success     200		{array}     entity.ProductImage
+// @Failure     404
+// @Failure     500		{object}    Error
+// @Router      /products/{id}/images    [get]
+// @Security    ApiKeyAuth
+func (handler *ProductHandler) GetImages(response http.ResponseWriter, request *http.Request) {
+	product, ok := handler.routeProduct(response, request)
+	if !ok {
+		return
+	}
+
+	if err := handler.attachImages(request, product); err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	images := product.Images
+	if images == nil {
+		images = []entity.ProductImage{}
+	}
+
+	writeCacheable(response, request, product.LastModified(), false, images)
+}
+
+// Reorder Images godoc
+// @Summary     Reorder product images
+// @Description Set the display order of the product images. The list must contain every image of the product exactly once.
+// @Tags        images
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Product ID"		Format(uuid)
+// @Param       request     body    dto.ReorderImagesInput     true    "Image IDs in the new order"
+// @Success     200		{array}     entity.ProductImage
+// @Failure     400		{object}    Error
+// @Failure     404
+// @Failure     413		{object}    Error
+// @Failure     415		{object}    Error
+// @Failure     422		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /products/{id}/images/order    [put]
+// @Security    ApiKeyAuth
+func (handler *ProductHandler) ReorderImages(response http.ResponseWriter, request *http.Request) {
+	product, ok := handler.routeProduct(response, request)
+	if !ok {
+		return
+	}
+
+	var input dto.ReorderImagesInput
+	err := decodeJSON(request, &input)
+	if err != nil {
+		writeDecodeError(response, err)
+		return
+	}
+	err = validation.Validate(input)
+	if err != nil {
+		writeValidationError(response, err)
+		return
+	}
+
+	err = handler.images(request).Reorder(product.ID.String(), input.ImageIDs)
+	if errors.Is(err, database.ErrImagesMismatch) {
+		writeValidationError(response, validation.Errors{"image_ids": {err.Error()}})
+		return
+	}
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	handler.touch(request, product)
+
+	if err := handler.attachImages(request, product); err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+	json.NewEncoder(response).Encode(product.Images)
+}
+
+// Set Primary Image godoc
+// @Summary     Set the primary image
+// @Description Make the image the primary image of the product
+// @Tags        images
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Product ID"		Format(uuid)
+// @Param       imageID     path    string     true    "Image ID"		Format(uuid)
+// @Success     204
+// @Failure     404
+// @Failure     500		{object}    Error
+// @Router      /products/{id}/images/{imageID}/primary    [put]
+// @Security    ApiKeyAuth
+func (handler *ProductHandler) SetPrimaryImage(response http.ResponseWriter, request *http.Request) {
+	product, ok := handler.routeProduct(response, request)
+	if !ok {
+		return
+	}
+
+	images := handler.images(request)
+	image, err := images.FindByID(product.ID.String(), chi.URLParam(request, "imageID"))
+	if err != nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if err := images.SetPrimary(product.ID.String(), image.ID.String()); err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	handler.touch(request, product)
+	response.WriteHeader(http.StatusNoContent)
+}
+
+// Delete Image godoc
+// @Summary     Delete a product image
+// @Description Delete an image and its thumbnails. If it was the primary image, the first remaining image becomes the primary one.
+// @Tags        images
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Product ID"		Format(uuid)
+// @Param       imageID     path    string     true    "Image ID"		Format(uuid)
+// @Success     204
+// @Failure     404
+// @Failure     500		{object}    Error
+// @Router      /products/{id}/images/{imageID}    [delete]
+// @Security    ApiKeyAuth
+func (handler *ProductHandler) DeleteImage(response http.ResponseWriter, request *http.Request) {
+	product, ok := handler.routeProduct(response, request)
+	if !ok {
+		return
+	}
+
+	images := handler.images(request)
+	image, err := images.FindByID(product.ID.String(), chi.URLParam(request, "imageID"))
+	if err != nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if err := images.Delete(product.ID.String(), image.ID.String()); err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	handler.deleteBlobs(request.Context(), image.BlobKeys())
+	handler.touch(request, product)
+	response.WriteHeader(http.StatusNoContent)
+}
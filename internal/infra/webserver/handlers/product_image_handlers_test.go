@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func pngBytes(t *testing.T, width, height int) []byte {
+	var buffer bytes.Buffer
+	assert.NoError(t, png.Encode(&buffer, image.NewRGBA(image.Rect(0, 0, width, height))))
+	return buffer.Bytes()
+}
+
+func uploadImage(handler http.Handler, target, token, field string, data []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile(field, "photo.jpg")
+	part.Write(data)
+	writer.Close()
+
+	request := httptest.NewRequest(http.MethodPost, target, &body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	request.Header.Set("Authorization", "Bearer "+token)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestProductHandler_Images(t *testing.T) {
+	router, _ := newProductRouter(t)
+	token := tokenFor(t, entityPkg.NewID().String())
+
+	recorder := doRequest(router, http.MethodPost, "/products", token, `{"name": "Product 1", "price": 10}`)
+	var product entity.Product
+	json.Unmarshal(recorder.Body.Bytes(), &product)
+	imagesURL := "/products/" + product.ID.String() + "/images"
+
+	// O tipo vem do conteúdo, não do nome do arquivo
+	recorder = uploadImage(router, imagesURL, token, "image", pngBytes(t, 1200, 600))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	var first entity.ProductImage
+	json.Unmarshal(recorder.Body.Bytes(), &first)
+	assert.Equal(t, imagesURL+"/"+first.ID.String(), recorder.Header().Get("Location"))
+	assert.Equal(t, "image/png", first.ContentType)
+	assert.Equal(t, 1200, first.Width)
+	assert.True(t, first.Primary)
+	assert.Regexp(t, `^/media/products/.+/original\.png$`, first.URL)
+	assert.Len(t, first.Thumbnails, 3)
+
+	recorder = uploadImage(router, imagesURL, token, "image", pngBytes(t, 10, 10))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	var second entity.ProductImage
+	json.Unmarshal(recorder.Body.Bytes(), &second)
+	assert.False(t, second.Primary)
+	assert.Equal(t, 1, second.Position)
+
+	recorder = uploadImage(router, imagesURL, token, "image", []byte("<html>not an image</html>"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "unsupported image type text/html")
+
+	recorder = uploadImage(router, imagesURL, token, "image", append(pngBytes(t, 10, 10)[:20], make([]byte, 64<<10)...))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+
+	recorder = uploadImage(router, imagesURL, token, "file", pngBytes(t, 10, 10))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = doRequest(router, http.MethodPost, imagesURL, token, `{}`)
+	assert.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+
+	// As imagens fazem parte do produto
+	recorder = doRequest(router, http.MethodGet, "/products/"+product.ID.String(), token, "")
+	json.Unmarshal(recorder.Body.Bytes(), &product)
+	assert.Len(t, product.Images, 2)
+	assert.Equal(t, first.URL, product.Images[0].URL)
+
+	recorder = doRequest(router, http.MethodPut, imagesURL+"/order", token, `{"image_ids": ["`+second.ID.String()+`", "`+first.ID.String()+`"]}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = doRequest(router, http.MethodPut, imagesURL+"/order", token, `{"image_ids": ["`+second.ID.String()+`"]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+	recorder = doRequest(router, http.MethodPut, imagesURL+"/"+second.ID.String()+"/primary", token, "")
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	var images []entity.ProductImage
+	recorder = doRequest(router, http.MethodGet, imagesURL, token, "")
+	json.Unmarshal(recorder.Body.Bytes(), &images)
+	assert.Equal(t, second.ID, images[0].ID)
+	assert.True(t, images[0].Primary)
+	assert.False(t, images[1].Primary)
+
+	// Outra organização não enxerga nem altera as imagens
+	otherToken := tokenFor(t, entityPkg.NewID().String())
+	recorder = uploadImage(router, imagesURL, otherToken, "image", pngBytes(t, 10, 10))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	recorder = doRequest(router, http.MethodDelete, imagesURL+"/"+first.ID.String(), otherToken, "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = doRequest(router, http.MethodDelete, imagesURL+"/"+second.ID.String(), token, "")
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	recorder = doRequest(router, http.MethodGet, imagesURL, token, "")
+	json.Unmarshal(recorder.Body.Bytes(), &images)
+	assert.Len(t, images, 1)
+	assert.True(t, images[0].Primary)
+}
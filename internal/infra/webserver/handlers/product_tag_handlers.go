@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/otthonleao/go-products.git/internal/dto"
+	"github.com/otthonleao/go-products.git/internal/infra/database"
+	"github.com/otthonleao/go-products.git/pkg/validation"
+)
+
+// defaultPriceBuckets são os limites das faixas de preço quando a consulta não
+// informa price_buckets.
+var defaultPriceBuckets = []float64{10, 50, 100, 500, 1000}
+
+// maxPriceBuckets limita a quantidade de limites em price_buckets.
+const maxPriceBuckets = 20
+
+// parseFacetOptions lê facets=tags,category,price e price_buckets da consulta.
+// O segundo retorno indica se alguma contagem foi pedida.
+func parseFacetOptions(query url.Values) (database.FacetOptions, bool, error) {
+	var options database.FacetOptions
+	if query.Get("facets") == "" {
+		return options, false, nil
+	}
+
+	for _, facet := range strings.Split(query.Get("facets"), ",") {
+		switch strings.TrimSpace(facet) {
+		case "tags":
+			options.Tags = true
+		case "category":
+			options.Categories = true
+		case "price":
+			options.PriceBuckets = defaultPriceBuckets
+		default:
+			return options, false, fmt.Errorf("unknown facet %q, use tags, category or price", facet)
+		}
+	}
+
+	if buckets := query.Get("price_buckets"); buckets != "" && options.PriceBuckets != nil {
+		limits := strings.Split(buckets, ",")
+		if len(limits) > maxPriceBuckets {
+			return options, false, fmt.Errorf("price_buckets accepts at most %d limits", maxPriceBuckets)
+		}
+		options.PriceBuckets = make([]float64, 0, len(limits))
+		for _, limit := range limits {
+			value, err := strconv.ParseFloat(strings.TrimSpace(limit), 64)
+			if err != nil || value <= 0 || (len(options.PriceBuckets) > 0 && value <= options.PriceBuckets[len(options.PriceBuckets)-1]) {
+				return options, false, fmt.Errorf("price_buckets must be positive numbers in ascending order")
+			}
+			options.PriceBuckets = append(options.PriceBuckets, value)
+		}
+	}
+
+	return options, true, nil
+}
+
+// Set Product Tags godoc
+// @Summary     Set product tags
+// @Description Replace all tags of a product. Tags are trimmed, lowercased, deduplicated and sorted; an empty list removes them.
+// @Tags        products
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Product ID"		Format(uuid)
+// @Param       request     body    dto.SetTagsInput     true    "Tags request"
+// @Success     200		{object}    entity.Product
+// @Failure     400		{object}    Error
+// @Failure     404
+// @Failure     413		{object}    Error
+// @Failure     415		{object}    Error
+// @Failure     422		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /products/{id}/tags    [put]
+// @Security    ApiKeyAuth
+func (handler *ProductHandler) SetTags(response http.ResponseWriter, request *http.Request) {
+	var input dto.SetTagsInput
+	err := decodeJSON(request, &input)
+	if err != nil {
+		writeDecodeError(response, err)
+		return
+	}
+	err = validation.Validate(input)
+	if err != nil {
+		writeValidationError(response, err)
+		return
+	}
+
+	product, ok := handler.routeProduct(response, request)
+	if !ok {
+		return
+	}
+
+	if err := product.SetTags(input.Tags); err != nil {
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+	product.UpdatedAt = time.Now()
+
+	err = handler.products(request).UpdateTags(product)
+	if err != nil {
+		writeRepositoryError(response, err)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+	json.NewEncoder(response).Encode(product)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/otthonleao/go-products.git/internal/dto"
+	"github.com/otthonleao/go-products.git/internal/entity"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductHandler_Tags(t *testing.T) {
+	router, _ := newProductRouter(t)
+	token := tokenFor(t, entityPkg.NewID().String())
+
+	recorder := doRequest(router, http.MethodPost, "/products", token, `{"name": "Tent", "price": 120, "tags": ["Outdoor", "sale"]}`)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	var tent entity.Product
+	json.Unmarshal(recorder.Body.Bytes(), &tent)
+	assert.Equal(t, []string{"outdoor", "sale"}, tent.Tags)
+
+	recorder = doRequest(router, http.MethodPost, "/products", token, `{"name": "Lamp", "price": 8}`)
+	var lamp entity.Product
+	json.Unmarshal(recorder.Body.Bytes(), &lamp)
+
+	recorder = doRequest(router, http.MethodPut, "/products/"+lamp.ID.String()+"/tags", token, `{"tags": [" outdoor ", "Outdoor", "electric"]}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &lamp)
+	assert.Equal(t, []string{"electric", "outdoor"}, lamp.Tags)
+
+	recorder = doRequest(router, http.MethodPut, "/products/"+lamp.ID.String()+"/tags", token, `{"tags": [""]}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = doRequest(router, http.MethodPut, "/products/"+entityPkg.NewID().String()+"/tags", token, `{"tags": ["sale"]}`)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// O PUT do produto mantém as tags
+	recorder = doRequest(router, http.MethodPut, "/products/"+tent.ID.String(), token, `{"name": "Tent", "price": 100}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	json.Unmarshal(recorder.Body.Bytes(), &tent)
+	assert.Equal(t, []string{"outdoor", "sale"}, tent.Tags)
+
+	names := func(target string) []string {
+		recorder := doRequest(router, http.MethodGet, target, token, "")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var products []entity.Product
+		json.Unmarshal(recorder.Body.Bytes(), &products)
+		names := []string{}
+		for _, product := range products {
+			names = append(names, product.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"Tent", "Lamp"}, names("/products?tags=sale,electric"))
+	assert.Equal(t, []string{"Lamp"}, names("/products?tags=OUTDOOR&tags=electric&tags_match=all"))
+	assert.Empty(t, names("/products?tags=sale,electric&tags_match=all"))
+
+	recorder = doRequest(router, http.MethodGet, "/products?tags=sale&tags_match=some", token, "")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestProductHandler_Facets(t *testing.T) {
+	router, _ := newProductRouter(t)
+	token := tokenFor(t, entityPkg.NewID().String())
+
+	doRequest(router, http.MethodPost, "/products/bulk", token, `[
+		{"name": "Tent", "price": 120, "tags": ["outdoor", "sale"]},
+		{"name": "Lamp", "price": 8, "tags": ["outdoor"]},
+		{"name": "Chair", "price": 45}
+	]`)
+
+	recorder := doRequest(router, http.MethodGet, "/products?facets=tags,price&price_buckets=10,100&page=1&limit=1", token, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var list dto.ProductListOutput
+	json.Unmarshal(recorder.Body.Bytes(), &list)
+	// As contagens cobrem a listagem inteira, não só a página
+	assert.Len(t, list.Products, 1)
+	assert.Equal(t, []entity.FacetCount{{Value: "outdoor", Count: 2}, {Value: "sale", Count: 1}}, list.Facets.Tags)
+	assert.Len(t, list.Facets.Prices, 3)
+	assert.Equal(t, []int64{1, 1, 1}, []int64{list.Facets.Prices[0].Count, list.Facets.Prices[1].Count, list.Facets.Prices[2].Count})
+	assert.Nil(t, list.Facets.Categories)
+
+	recorder = doRequest(router, http.MethodGet, "/products?facets=price&tags=outdoor", token, "")
+	json.Unmarshal(recorder.Body.Bytes(), &list)
+	assert.Len(t, list.Products, 2)
+	assert.Len(t, list.Facets.Prices, len(defaultPriceBuckets)+1)
+
+	recorder = doRequest(router, http.MethodGet, "/products?facets=brand", token, "")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = doRequest(router, http.MethodGet, "/products?facets=price&price_buckets=100,10", token, "")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
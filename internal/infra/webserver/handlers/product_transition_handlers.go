@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/otthonleao/go-products.git/internal/dto"
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/webserver/middlewares"
+	"github.com/otthonleao/go-products.git/pkg/validation"
+)
+
+// defaultListStatuses são os estados listados quando a consulta não informa
+// status: produtos arquivados só aparecem quando pedidos.
+var defaultListStatuses = []string{entity.StatusDraft, entity.StatusInReview, entity.StatusPublished}
+
+// parseStatuses lê status=draft,published da consulta; "all" lista todos os estados.
+func parseStatuses(value string) ([]string, error) {
+	switch value {
+	case "":
+		return defaultListStatuses, nil
+	case "all":
+		return nil, nil
+	}
+
+	statuses := strings.Split(value, ",")
+	for i, status := range statuses {
+		statuses[i] = strings.TrimSpace(status)
+		if !entity.IsValidStatus(statuses[i]) {
+			return nil, fmt.Errorf("unknown status %q", statuses[i])
+		}
+	}
+	return statuses, nil
+}
+
+// actor identifica no histórico quem fez a requisição.
+func actor(identity middlewares.Identity) string {
+	if identity.ServiceName != "" {
+		return "service:" + identity.ServiceName
+	}
+	return identity.UserID
+}
+
+// Create Product Transition godoc
+// @Summary     Change the product status
+// @Description Move a product through the publishing workflow: draft → in_review → published → archived. Members submit products for review and withdraw them; owners and admins also publish, unpublish, archive and restore.
+// @Tags        products
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Product ID"		Format(uuid)
+// @Param       request     body    dto.TransitionInput     true    "Transition request"
+// @Param       Idempotency-Key    header    string     false    "Key to safely retry the request"
+// @Success     201		{object}    entity.ProductTransition
+// @Failure     400		{object}    Error
+// @Failure     403		{object}    Error
+// @Failure     404
+// @Failure     409		{object}    Error
+// @Failure     413		{object}    Error
+// @Failure     415		{object}    Error
+// @Failure     422		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /products/{id}/transitions    [post]
+// @Security    ApiKeyAuth
+func (handler *ProductHandler) CreateTransition(response http.ResponseWriter, request *http.Request) {
+	var input dto.TransitionInput
+	err := decodeJSON(request, &input)
+	if err != nil {
+		writeDecodeError(response, err)
+		return
+	}
+	err = validation.Validate(input)
+	if err != nil {
+		writeValidationError(response, err)
+		return
+	}
+
+	product, ok := handler.routeProduct(response, request)
+	if !ok {
+		return
+	}
+
+	identity, _ := middlewares.IdentityFromContext(request.Context())
+	from := product.Status
+	transition, err := product.Transition(input.To, identity.Role, actor(identity), input.Comment)
+	switch {
+	case errors.Is(err, entity.ErrTransitionForbidden):
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(response).Encode(Error{Message: fmt.Sprintf("role %q cannot move a product from %s to %s", identity.Role, from, input.To)})
+		return
+	case errors.Is(err, entity.ErrTransitionNotAllowed):
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(http.StatusConflict)
+		json.NewEncoder(response).Encode(Error{Message: fmt.Sprintf("cannot move a product from %s to %s", from, input.To)})
+		return
+	case err != nil:
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	err = handler.products(request).Transition(product, transition)
+	if err != nil {
+		writeRepositoryError(response, err)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusCreated)
+	json.NewEncoder(response).Encode(transition)
+}
+
+// List Product Transitions godoc
+// @Summary     List product status changes
+// @Description Get the status history of a product, oldest first
+// @Tags        products
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Product ID"		Format(uuid)
+// @Success     200		{array}     entity.ProductTransition
+// @Failure     404
+// @Failure     500		{object}    Error
+// @Router      /products/{id}/transitions    [get]
+// @Security    ApiKeyAuth
+func (handler *ProductHandler) GetTransitions(response http.ResponseWriter, request *http.Request) {
+	product, ok := handler.routeProduct(response, request)
+	if !ok {
+		return
+	}
+
+	transitions, err := handler.products(request).FindTransitions(product.ID.String())
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if transitions == nil {
+		transitions = []entity.ProductTransition{}
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+	json.NewEncoder(response).Encode(transitions)
+}
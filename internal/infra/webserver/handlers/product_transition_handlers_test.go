@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func tokenWithRole(t *testing.T, organizationID, role string) string {
+	_, token, err := testTokenAuth.Encode(map[string]interface{}{
+		"sub":  entityPkg.NewID().String(),
+		"org":  organizationID,
+		"role": role,
+	})
+	assert.NoError(t, err)
+	return token
+}
+
+func TestProductHandler_Transitions(t *testing.T) {
+	router, _ := newProductRouter(t)
+	organizationID := entityPkg.NewID().String()
+	member := tokenWithRole(t, organizationID, entity.RoleMember)
+	admin := tokenWithRole(t, organizationID, entity.RoleAdmin)
+
+	recorder := doRequest(router, http.MethodPost, "/products", member, `{"name": "Product 1", "price": 10}`)
+	var product entity.Product
+	json.Unmarshal(recorder.Body.Bytes(), &product)
+	assert.Equal(t, entity.StatusDraft, product.Status)
+	transitionsURL := "/products/" + product.ID.String() + "/transitions"
+
+	recorder = doRequest(router, http.MethodPost, transitionsURL, member, `{"to": "published"}`)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "cannot move a product from draft to published")
+
+	recorder = doRequest(router, http.MethodPost, transitionsURL, member, `{"to": "in_review", "comment": "ready"}`)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	var transition entity.ProductTransition
+	json.Unmarshal(recorder.Body.Bytes(), &transition)
+	assert.Equal(t, entity.StatusDraft, transition.From)
+	assert.Equal(t, entity.StatusInReview, transition.To)
+
+	recorder = doRequest(router, http.MethodPost, transitionsURL, member, `{"to": "published"}`)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = doRequest(router, http.MethodPost, transitionsURL, admin, `{"to": "published"}`)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	recorder = doRequest(router, http.MethodPost, transitionsURL, admin, `{"to": "live"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+	// O PUT do produto não muda o estado
+	recorder = doRequest(router, http.MethodPut, "/products/"+product.ID.String(), admin, `{"name": "Product 1", "price": 12, "status": "draft"}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = doRequest(router, http.MethodGet, "/products/"+product.ID.String(), member, "")
+	json.Unmarshal(recorder.Body.Bytes(), &product)
+	assert.Equal(t, entity.StatusPublished, product.Status)
+	assert.NotNil(t, product.PublishedAt)
+
+	recorder = doRequest(router, http.MethodGet, transitionsURL, member, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var transitions []entity.ProductTransition
+	json.Unmarshal(recorder.Body.Bytes(), &transitions)
+	assert.Len(t, transitions, 2)
+	assert.Equal(t, "ready", transitions[0].Comment)
+
+	// Arquivados saem da listagem padrão
+	doRequest(router, http.MethodPost, "/products", member, `{"name": "Product 2", "price": 10}`)
+	recorder = doRequest(router, http.MethodPost, transitionsURL, admin, `{"to": "archived"}`)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	count := func(target string) int {
+		recorder := doRequest(router, http.MethodGet, target, member, "")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var products []entity.Product
+		json.Unmarshal(recorder.Body.Bytes(), &products)
+		return len(products)
+	}
+	assert.Equal(t, 1, count("/products"))
+	assert.Equal(t, 2, count("/products?status=all"))
+	assert.Equal(t, 1, count("/products?status=archived"))
+	assert.Equal(t, 1, count("/products?status=draft,in_review"))
+
+	recorder = doRequest(router, http.MethodGet, "/products?status=live", member, "")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/otthonleao/go-products.git/internal/dto"
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/database"
+	"github.com/otthonleao/go-products.git/pkg/validation"
+)
+
+// variants retorna o repositório de variantes com o contexto da requisição.
+func (handler *ProductHandler) variants(request *http.Request) database.ProductVariantInterface {
+	return handler.variantDB.WithContext(request.Context())
+}
+
+// routeProduct carrega o produto da rota pelo repositório restrito ao tenant,
+// respondendo 404 quando ele não existe ou é de outra organização.
+func (handler *ProductHandler) routeProduct(response http.ResponseWriter, request *http.Request) (*entity.Product, bool) {
+	product, err := handler.products(request).FindByID(chi.URLParam(request, "id"))
+	if err != nil {
+		response.WriteHeader(http.StatusNotFound)
+		return nil, false
+	}
+	return product, true
+}
+
+// Create Variant godoc
+// @Summary     Create a product variant
+// @Description Create a variant with one value for each option axis of the product. Each combination of values must be unique within the product.
+// @Tags        variants
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Product ID"		Format(uuid)
+// @Param       request     body    dto.CreateVariantInput     true    "Variant request"
+// @Param       Idempotency-Key    header    string     false    "Key to safely retry the request"
+// @Success     201		{object}    entity.ProductVariant
+// @Header      201		{string}    Location    "URL of the created variant"
+// @Failure     400		{object}    Error
+// @Failure     404
+// @Failure     409		{object}    Error
+// @Failure     413		{object}    Error
+// @Failure     415		{object}    Error
+// @Failure     422		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /products/{id}/variants    [post]
+// @Security    ApiKeyAuth
+func (handler *ProductHandler) CreateVariant(response http.ResponseWriter, request *http.Request) {
+	product, ok := handler.routeProduct(response, request)
+	if !ok {
+		return
+	}
+
+	var input dto.CreateVariantInput
+	err := decodeJSON(request, &input)
+	if err != nil {
+		writeDecodeError(response, err)
+		return
+	}
+	err = validation.Validate(input)
+	if err != nil {
+		writeValidationError(response, err)
+		return
+	}
+
+	variant, err := entity.NewProductVariant(product, input.Options, input.SKU, input.Price, input.Stock)
+	if err != nil {
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	err = handler.variants(request).Create(variant)
+	if err != nil {
+		writeRepositoryError(response, err)
+		return
+	}
+	handler.touch(request, product)
+
+	response.Header().Set("Content-Type", "application/json")
+	response.Header().Set("Location", "/products/"+product.ID.String()+"/variants/"+variant.ID.String())
+	response.WriteHeader(http.StatusCreated)
+	json.NewEncoder(response).Encode(variant)
+}
+
+// List Variants godoc
+// @Summary     List product variants
+// @Description Get all variants of a product
+// @Tags        variants
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Product ID"		Format(uuid)
+// @Success     200		{array}     entity.ProductVariant
+// @Failure     404
+// @Failure     500		{object}    Error
+// @Router      /products/{id}/variants    [get]
+// @Security    ApiKeyAuth
+func (handler *ProductHandler) GetVariants(response http.ResponseWriter, request *http.Request) {
+	product, ok := handler.routeProduct(response, request)
+	if !ok {
+		return
+	}
+
+	variants, err := handler.variants(request).FindByProduct(product.ID.String())
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if variants == nil {
+		variants = []entity.ProductVariant{}
+	}
+
+	var lastModified time.Time
+	for _, variant := range variants {
+		if variant.UpdatedAt.After(lastModified) {
+			lastModified = variant.UpdatedAt
+		}
+	}
+	writeCacheable(response, request, lastModified, false, variants)
+}
+
+// Get Variant godoc
+// @Summary     Get a product variant
+// @Description Get a variant of a product by ID
+// @Tags        variants
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Product ID"		Format(uuid)
+// @Param       variantID   path    string     true    "Variant ID"		Format(uuid)
+// @Success     200		{object}    entity.ProductVariant
+// @Success     304
+// @Failure     404
+// @Router      /products/{id}/variants/{variantID}    [get]
+// @Security    ApiKeyAuth
+func (handler *ProductHandler) GetVariant(response http.ResponseWriter, request *http.Request) {
+	product, ok := handler.routeProduct(response, request)
+	if !ok {
+		return
+	}
+
+	variant, err := handler.variants(request).FindByID(product.ID.String(), chi.URLParam(request, "variantID"))
+	if err != nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writeCacheable(response, request, variant.UpdatedAt, true, variant)
+}
+
+// Update Variant godoc
+// @Summary     Update a product variant
+// @Description Replace the options, SKU, price and stock of a variant
+// @Tags        variants
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Product ID"		Format(uuid)
+// @Param       variantID   path    string     true    "Variant ID"		Format(uuid)
+// @Param       request     body    dto.CreateVariantInput     true    "Variant request"
+// @Success     200		{object}    entity.ProductVariant
+// @Failure     400		{object}    Error
+// @Failure     404
+// @Failure     409		{object}    Error
+// @Failure     413		{object}    Error
+// @Failure     415		{object}    Error
+// @Failure     422		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /products/{id}/variants/{variantID}    [put]
+// @Security    ApiKeyAuth
+func (handler *ProductHandler) UpdateVariant(response http.ResponseWriter, request *http.Request) {
+	product, ok := handler.routeProduct(response, request)
+	if !ok {
+		return
+	}
+
+	var input dto.CreateVariantInput
+	err := decodeJSON(request, &input)
+	if err != nil {
+		writeDecodeError(response, err)
+		return
+	}
+	err = validation.Validate(input)
+	if err != nil {
+		writeValidationError(response, err)
+		return
+	}
+
+	variants := handler.variants(request)
+	variant, err := variants.FindByID(product.ID.String(), chi.URLParam(request, "variantID"))
+	if err != nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	variant.Options = input.Options
+	variant.SKU = nil
+	if input.SKU != "" {
+		variant.SKU = &input.SKU
+	}
+	variant.Price = input.Price
+	variant.Stock = input.Stock
+	variant.UpdatedAt = time.Now()
+	if err := variant.Validate(product); err != nil {
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	err = variants.Update(variant)
+	if err != nil {
+		writeRepositoryError(response, err)
+		return
+	}
+	handler.touch(request, product)
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+	json.NewEncoder(response).Encode(variant)
+}
+
+// Delete Variant godoc
+// @Summary     Delete a product variant
+// @Description Delete a variant of a product by ID
+// @Tags        variants
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Product ID"		Format(uuid)
+// @Param       variantID   path    string     true    "Variant ID"		Format(uuid)
+// @Success     204
+// @Failure     404
+// @Failure     500		{object}    Error
+// @Router      /products/{id}/variants/{variantID}    [delete]
+// @Security    ApiKeyAuth
+func (handler *ProductHandler) DeleteVariant(response http.ResponseWriter, request *http.Request) {
+	product, ok := handler.routeProduct(response, request)
+	if !ok {
+		return
+	}
+
+	variants := handler.variants(request)
+	variantID := chi.URLParam(request, "variantID")
+	if _, err := variants.FindByID(product.ID.String(), variantID); err != nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if err := variants.Delete(product.ID.String(), variantID); err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	handler.touch(request, product)
+	response.WriteHeader(http.StatusNoContent)
+}
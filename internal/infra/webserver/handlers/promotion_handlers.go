@@ -0,0 +1,414 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/otthonleao/go-products.git/internal/dto"
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/database"
+	"github.com/otthonleao/go-products.git/internal/infra/webserver/middlewares"
+	entityPkg "github.com/otthonleao/go-products.git/pkg/entity"
+	"github.com/otthonleao/go-products.git/pkg/money"
+	"github.com/otthonleao/go-products.git/pkg/validation"
+	"gorm.io/gorm"
+)
+
+// PromotionHandler atende o cadastro de promoções da organização e a cotação
+// pública de preços em /pricing/quote.
+type PromotionHandler struct {
+	promotionDB    database.PromotionInterface
+	productDB      database.ProductInterface
+	variantDB      database.ProductVariantInterface
+	categoryDB     database.CategoryInterface
+	organizationDB database.OrganizationInterface
+}
+
+func NewPromotionHandler(promotionDB database.PromotionInterface, productDB database.ProductInterface, variantDB database.ProductVariantInterface, categoryDB database.CategoryInterface, organizationDB database.OrganizationInterface) *PromotionHandler {
+	return &PromotionHandler{
+		promotionDB:    promotionDB,
+		productDB:      productDB,
+		variantDB:      variantDB,
+		categoryDB:     categoryDB,
+		organizationDB: organizationDB,
+	}
+}
+
+// promotions retorna o repositório restrito à organização do token.
+func (handler *PromotionHandler) promotions(request *http.Request) database.PromotionInterface {
+	identity, _ := middlewares.IdentityFromContext(request.Context())
+	return handler.promotionDB.WithContext(request.Context()).ForTenant(identity.OrganizationID)
+}
+
+// priceLines cota as linhas com as promoções automáticas vigentes da
+// organização e o cupom informado. Cupons inexistentes, fora da vigência,
+// esgotados ou que não descontam nenhuma linha são o mesmo erro de validação
+// em coupon_code, para que a cotação pública não sirva para descobrir códigos.
+// Outras falhas ao buscar o cupom voltam como err.
+func priceLines(request *http.Request, promotionDB database.PromotionInterface, organizationID string, lines []entity.PricingLine, couponCode string) (*entity.Quote, *entity.Promotion, validation.Errors, error) {
+	promotions := promotionDB.WithContext(request.Context()).ForTenant(organizationID)
+	now := time.Now()
+
+	var coupon *entity.Promotion
+	if couponCode != "" {
+		found, err := promotions.FindByCode(couponCode)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, unavailableCoupon(), nil
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if found.CheckCoupon(now) != nil {
+			return nil, nil, unavailableCoupon(), nil
+		}
+		coupon = found
+	}
+
+	automatic, err := promotions.FindAutomatic(now)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	quote := entity.PriceLines(lines, automatic, coupon)
+	// Cupom que não desconta nenhuma linha é recusado, para não ser gravado
+	// no pedido nem consumir um uso.
+	if coupon != nil && !quote.HasPromotion(coupon.ID) {
+		return nil, nil, unavailableCoupon(), nil
+	}
+	return quote, coupon, nil, nil
+}
+
+// unavailableCoupon é a mesma resposta para cupom inexistente, fora da
+// validade, esgotado ou sem efeito, sem revelar qual foi o motivo.
+func unavailableCoupon() validation.Errors {
+	return validation.Errors{"coupon_code": {entity.ErrCouponUnavailable.Error()}}
+}
+
+// scopeIDs converte os IDs do escopo da promoção, conferindo com exists que
+// cada um pertence à organização.
+func scopeIDs(field string, ids []string, exists func(id string) error) ([]entityPkg.ID, validation.Errors) {
+	parsed := make([]entityPkg.ID, 0, len(ids))
+	for i, id := range ids {
+		key := fmt.Sprintf("%s[%d]", field, i)
+		value, err := entityPkg.ParseID(id)
+		if err != nil {
+			return nil, validation.Errors{key: {"must be a valid UUID"}}
+		}
+		if err := exists(id); err != nil {
+			return nil, validation.Errors{key: {"not found"}}
+		}
+		parsed = append(parsed, value)
+	}
+	return parsed, nil
+}
+
+// applyInput preenche a promoção com os dados recebidos e confere que os
+// produtos e as categorias do escopo são da organização.
+func (handler *PromotionHandler) applyInput(request *http.Request, promotion *entity.Promotion, input dto.PromotionInput) (validation.Errors, error) {
+	identity, _ := middlewares.IdentityFromContext(request.Context())
+	products := handler.productDB.WithContext(request.Context()).ForTenant(identity.OrganizationID)
+	categories := handler.categoryDB.WithContext(request.Context()).ForTenant(identity.OrganizationID)
+
+	productIDs, invalid := scopeIDs("product_ids", input.ProductIDs, func(id string) error {
+		_, err := products.FindByID(id)
+		return err
+	})
+	if invalid != nil {
+		return invalid, nil
+	}
+	categoryIDs, invalid := scopeIDs("category_ids", input.CategoryIDs, func(id string) error {
+		_, err := categories.FindByID(id)
+		return err
+	})
+	if invalid != nil {
+		return invalid, nil
+	}
+
+	promotion.Name = input.Name
+	promotion.Kind = input.Kind
+	promotion.Percentage = input.Percentage
+	promotion.Amount = money.Cents(input.Amount)
+	promotion.BuyQuantity = input.BuyQuantity
+	promotion.FreeQuantity = input.FreeQuantity
+	promotion.ProductIDs = productIDs
+	promotion.CategoryIDs = categoryIDs
+	promotion.Code = nil
+	if input.Code != "" {
+		promotion.Code = &input.Code
+	}
+	promotion.UsageLimit = input.UsageLimit
+	promotion.StartsAt = input.StartsAt
+	promotion.EndsAt = input.EndsAt
+	promotion.Active = input.Active == nil || *input.Active
+	return nil, promotion.Validate()
+}
+
+// writePromotionInput responde aos erros de applyInput: escopo inválido dá 422
+// e regras da promoção inválidas, 400.
+func writePromotionInput(response http.ResponseWriter, invalid validation.Errors, err error) bool {
+	if invalid != nil {
+		writeValidationError(response, invalid)
+		return false
+	}
+	if err != nil {
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return false
+	}
+	return true
+}
+
+// Create Promotion godoc
+// @Summary     Create a promotion
+// @Description Create a percentage, fixed (per unit, in cents) or buy X get Y promotion, optionally limited to products and categories and to a validity window. Buy X get Y counts the units of a product across all its variants and gives away the cheapest ones. With code the promotion is a coupon, optionally with a usage limit; without code it is applied automatically. Only owners and admins.
+// @Tags        promotions
+// @Accept      json
+// @Produce     json
+// @Param       request     body    dto.PromotionInput     true    "Promotion request"
+// @Param       Idempotency-Key    header    string     false    "Key to safely retry the request"
+// @Success     201		{object}    entity.Promotion
+// @Header      201		{string}    Location    "URL of the created promotion"
+// @Failure     400		{object}    Error
+// @Failure     403		{object}    Error
+// @Failure     409		{object}    Error
+// @Failure     415		{object}    Error
+// @Failure     422		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /promotions    [post]
+// @Security    ApiKeyAuth
+func (handler *PromotionHandler) Create(response http.ResponseWriter, request *http.Request) {
+	var input dto.PromotionInput
+	err := decodeJSON(request, &input)
+	if err != nil {
+		writeDecodeError(response, err)
+		return
+	}
+	err = validation.Validate(input)
+	if err != nil {
+		writeValidationError(response, err)
+		return
+	}
+
+	promotion := entity.NewPromotion(input.Name, input.Kind)
+	invalid, err := handler.applyInput(request, promotion, input)
+	if !writePromotionInput(response, invalid, err) {
+		return
+	}
+
+	err = handler.promotions(request).Create(promotion)
+	if err != nil {
+		writeRepositoryError(response, err)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.Header().Set("Location", "/promotions/"+promotion.ID.String())
+	response.WriteHeader(http.StatusCreated)
+	json.NewEncoder(response).Encode(promotion)
+}
+
+// List Promotions godoc
+// @Summary     List promotions
+// @Description List the promotions and coupons of the organization, newest first. Only owners and admins.
+// @Tags        promotions
+// @Accept      json
+// @Produce     json
+// @Param       page        query    int        false    "Page number"
+// @Param       limit       query    int        false    "Number of items per page (default 20, at most 100)"
+// @Success     200		{array}     entity.Promotion
+// @Success     304
+// @Failure     403		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /promotions    [get]
+// @Security    ApiKeyAuth
+func (handler *PromotionHandler) GetPromotions(response http.ResponseWriter, request *http.Request) {
+	page, limit := pagination(request.URL.Query())
+	promotions, err := handler.promotions(request).FindAll(page, limit)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if promotions == nil {
+		promotions = []entity.Promotion{}
+	}
+
+	var lastModified time.Time
+	for _, promotion := range promotions {
+		if promotion.UpdatedAt.After(lastModified) {
+			lastModified = promotion.UpdatedAt
+		}
+	}
+	writeCacheable(response, request, lastModified, false, promotions)
+}
+
+// Get Promotion godoc
+// @Summary     Get a promotion
+// @Tags        promotions
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Promotion ID"		Format(uuid)
+// @Success     200		{object}    entity.Promotion
+// @Success     304
+// @Failure     403		{object}    Error
+// @Failure     404
+// @Router      /promotions/{id}    [get]
+// @Security    ApiKeyAuth
+func (handler *PromotionHandler) GetPromotion(response http.ResponseWriter, request *http.Request) {
+	promotion, err := handler.promotions(request).FindByID(chi.URLParam(request, "id"))
+	if err != nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+	writeCacheable(response, request, promotion.UpdatedAt, true, promotion)
+}
+
+// Update Promotion godoc
+// @Summary     Update a promotion
+// @Description Replace a promotion. The number of times a coupon was used is kept.
+// @Tags        promotions
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Promotion ID"		Format(uuid)
+// @Param       request     body    dto.PromotionInput     true    "Promotion request"
+// @Success     200		{object}    entity.Promotion
+// @Failure     400		{object}    Error
+// @Failure     403		{object}    Error
+// @Failure     404
+// @Failure     409		{object}    Error
+// @Failure     415		{object}    Error
+// @Failure     422		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /promotions/{id}    [put]
+// @Security    ApiKeyAuth
+func (handler *PromotionHandler) UpdatePromotion(response http.ResponseWriter, request *http.Request) {
+	var input dto.PromotionInput
+	err := decodeJSON(request, &input)
+	if err != nil {
+		writeDecodeError(response, err)
+		return
+	}
+	err = validation.Validate(input)
+	if err != nil {
+		writeValidationError(response, err)
+		return
+	}
+
+	promotions := handler.promotions(request)
+	promotion, err := promotions.FindByID(chi.URLParam(request, "id"))
+	if err != nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	invalid, err := handler.applyInput(request, promotion, input)
+	if !writePromotionInput(response, invalid, err) {
+		return
+	}
+	promotion.UpdatedAt = time.Now()
+
+	err = promotions.Update(promotion)
+	if err != nil {
+		writeRepositoryError(response, err)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+	json.NewEncoder(response).Encode(promotion)
+}
+
+// Delete Promotion godoc
+// @Summary     Delete a promotion
+// @Description Delete a promotion by ID. Orders keep the discounts already applied.
+// @Tags        promotions
+// @Accept      json
+// @Produce     json
+// @Param       id          path    string     true    "Promotion ID"		Format(uuid)
+// @Success     204
+// @Failure     403		{object}    Error
+// @Failure     404
+// @Failure     500		{object}    Error
+// @Router      /promotions/{id}    [delete]
+// @Security    ApiKeyAuth
+func (handler *PromotionHandler) DeletePromotion(response http.ResponseWriter, request *http.Request) {
+	id := chi.URLParam(request, "id")
+
+	promotions := handler.promotions(request)
+	if _, err := promotions.FindByID(id); err != nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err := promotions.Delete(id); err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+// Create Quote godoc
+// @Summary     Quote prices with promotions
+// @Description Price published products of an organization with its current automatic promotions and an optional coupon. Each line gets the automatic promotion with the largest discount; the coupon applies on top of it. The response shows the discount of every applied rule per line and in total. Amounts are in cents. A coupon that does not exist, is out of its validity window, is exhausted or discounts no line gets the same 422 error.
+// @Tags        pricing
+// @Accept      json
+// @Produce     json
+// @Param       request     body    dto.QuoteInput     true    "Quote request"
+// @Success     200		{object}    entity.Quote
+// @Failure     400		{object}    Error
+// @Failure     415		{object}    Error
+// @Failure     422		{object}    Error
+// @Failure     500		{object}    Error
+// @Router      /pricing/quote    [post]
+func (handler *PromotionHandler) CreateQuote(response http.ResponseWriter, request *http.Request) {
+	var input dto.QuoteInput
+	err := decodeJSON(request, &input)
+	if err != nil {
+		writeDecodeError(response, err)
+		return
+	}
+	err = validation.Validate(input)
+	if err != nil {
+		writeValidationError(response, err)
+		return
+	}
+
+	if _, err := handler.organizationDB.WithContext(request.Context()).FindByID(input.OrganizationID); err != nil {
+		writeValidationError(response, validation.Errors{"organization_id": {"organization not found"}})
+		return
+	}
+	lines := make([]entity.PricingLine, 0, len(input.Items))
+	for i, line := range input.Items {
+		product, variant, invalid, err := availableProduct(request, handler.productDB, handler.variantDB, input.OrganizationID, line.ProductID, line.VariantID)
+		if err != nil {
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if invalid != nil {
+			writeValidationError(response, prefixErrors(invalid, fmt.Sprintf("items[%d].", i)))
+			return
+		}
+		item, err := entity.NewOrderItem(product, variant, line.Quantity)
+		if err != nil {
+			writeValidationError(response, validation.Errors{fmt.Sprintf("items[%d].quantity", i): {err.Error()}})
+			return
+		}
+		lines = append(lines, item.PricingLine(product.CategoryID))
+	}
+
+	quote, _, invalid, err := priceLines(request, handler.promotionDB, input.OrganizationID, lines, input.CouponCode)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if invalid != nil {
+		writeValidationError(response, invalid)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(response).Encode(quote)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/database"
+	"github.com/otthonleao/go-products.git/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromotionHandler(t *testing.T) {
+	router, productDB := newProductRouter(t)
+	organization, _ := entity.NewOrganization("Acme")
+	assert.NoError(t, database.NewOrganization(productDB.DB).Create(organization))
+	admin := tokenWithRole(t, organization.ID.String(), entity.RoleAdmin)
+	member := tokenWithRole(t, organization.ID.String(), entity.RoleMember)
+	buyer := tokenFor(t, "")
+
+	recorder := doRequest(router, http.MethodPost, "/categories", admin, `{"name": "Clothing"}`)
+	var clothing entity.Category
+	json.Unmarshal(recorder.Body.Bytes(), &clothing)
+	create := func(body string) entity.Product {
+		recorder := doRequest(router, http.MethodPost, "/products", admin, body)
+		var product entity.Product
+		json.Unmarshal(recorder.Body.Bytes(), &product)
+		for _, status := range []string{entity.StatusInReview, entity.StatusPublished} {
+			doRequest(router, http.MethodPost, "/products/"+product.ID.String()+"/transitions", admin, `{"to": "`+status+`"}`)
+		}
+		return product
+	}
+	pen := create(`{"name": "Pen", "price": 2.5}`)
+	shirt := create(`{"name": "Shirt", "price": 40, "category_id": "` + clothing.ID.String() + `"}`)
+
+	// Cadastro
+	recorder = doRequest(router, http.MethodPost, "/promotions", admin, `{"name": "Buy 2 get 1", "kind": "buy_x_get_y", "buy_quantity": 2, "free_quantity": 1, "product_ids": ["`+pen.ID.String()+`"]}`)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	var bundle entity.Promotion
+	json.Unmarshal(recorder.Body.Bytes(), &bundle)
+	assert.True(t, bundle.Active)
+	assert.Equal(t, "/promotions/"+bundle.ID.String(), recorder.Header().Get("Location"))
+
+	recorder = doRequest(router, http.MethodPost, "/promotions", admin, `{"name": "Clothes", "kind": "percentage", "percentage": 10, "category_ids": ["`+clothing.ID.String()+`"], "code": "clothes10", "usage_limit": 1}`)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	var coupon entity.Promotion
+	json.Unmarshal(recorder.Body.Bytes(), &coupon)
+	assert.Equal(t, "CLOTHES10", *coupon.Code)
+
+	recorder = doRequest(router, http.MethodPost, "/promotions", admin, `{"name": "Again", "kind": "fixed", "amount": 100, "code": "CLOTHES10"}`)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	recorder = doRequest(router, http.MethodPost, "/promotions", admin, `{"name": "Empty", "kind": "percentage"}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), entity.ErrInvalidPercentage.Error())
+	recorder = doRequest(router, http.MethodPost, "/promotions", admin, `{"name": "Other", "kind": "fixed", "amount": 100, "category_ids": ["`+pen.ID.String()+`"]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "category_ids[0]")
+	recorder = doRequest(router, http.MethodPost, "/promotions", admin, `{"name": "Other", "kind": "half_off"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Equal(t, http.StatusForbidden, doRequest(router, http.MethodGet, "/promotions", member, "").Code)
+
+	recorder = doRequest(router, http.MethodGet, "/promotions", admin, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var promotions []entity.Promotion
+	json.Unmarshal(recorder.Body.Bytes(), &promotions)
+	assert.Len(t, promotions, 2)
+
+	// Cotação pública
+	quoteBody := `{"organization_id": "` + organization.ID.String() + `", "items": [{"product_id": "` + pen.ID.String() + `", "quantity": 3}, {"product_id": "` + shirt.ID.String() + `", "quantity": 1}], "coupon_code": "clothes10"}`
+	recorder = doRequest(router, http.MethodPost, "/pricing/quote", "", quoteBody)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var quote entity.Quote
+	json.Unmarshal(recorder.Body.Bytes(), &quote)
+	assert.Equal(t, money.Cents(4750), quote.Subtotal)
+	assert.Equal(t, money.Cents(650), quote.Discount)
+	assert.Equal(t, money.Cents(4100), quote.Total)
+	assert.Len(t, quote.Promotions, 2)
+	assert.Equal(t, money.Cents(250), quote.Lines[0].Discount)
+	assert.Equal(t, "CLOTHES10", quote.Lines[1].Promotions[0].Code)
+
+	recorder = doRequest(router, http.MethodPost, "/pricing/quote", "", `{"organization_id": "`+organization.ID.String()+`", "items": [{"product_id": "`+pen.ID.String()+`", "quantity": 1}], "coupon_code": "NOPE"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.JSONEq(t, `{"message": "validation failed", "errors": {"coupon_code": ["`+entity.ErrCouponUnavailable.Error()+`"]}}`, recorder.Body.String())
+
+	// Cupom que não desconta nenhuma linha é recusado e não consome uso
+	penOnly := `{"organization_id": "` + organization.ID.String() + `", "items": [{"product_id": "` + pen.ID.String() + `", "quantity": 1}], "coupon_code": "clothes10"}`
+	recorder = doRequest(router, http.MethodPost, "/pricing/quote", "", penOnly)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), entity.ErrCouponUnavailable.Error())
+	recorder = doRequest(router, http.MethodPost, "/users/me/orders", buyer, penOnly)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+	// O pedido grava os descontos e consome o cupom
+	recorder = doRequest(router, http.MethodPost, "/users/me/orders", buyer, quoteBody)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	var order entity.Order
+	json.Unmarshal(recorder.Body.Bytes(), &order)
+	assert.Equal(t, money.Cents(4750), order.Subtotal)
+	assert.Equal(t, money.Cents(4100), order.Total)
+	assert.Equal(t, "CLOTHES10", *order.CouponCode)
+	assert.Equal(t, money.Cents(3600), order.Items[1].Total)
+	assert.Len(t, order.Promotions, 2)
+
+	recorder = doRequest(router, http.MethodPost, "/users/me/orders", buyer, quoteBody)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	// O cupom esgotado tem a mesma resposta de um cupom inexistente
+	assert.Contains(t, recorder.Body.String(), entity.ErrCouponUnavailable.Error())
+	assert.NotContains(t, recorder.Body.String(), entity.ErrCouponExhausted.Error())
+
+	// Cancelar o pedido devolve o uso do cupom
+	assert.Equal(t, http.StatusOK, doRequest(router, http.MethodPost, "/users/me/orders/"+order.ID.String()+"/cancel", buyer, "").Code)
+	recorder = doRequest(router, http.MethodGet, "/promotions/"+coupon.ID.String(), admin, "")
+	json.Unmarshal(recorder.Body.Bytes(), &coupon)
+	assert.Equal(t, 0, coupon.UsageCount)
+
+	// Promoções desativadas deixam de valer
+	recorder = doRequest(router, http.MethodPut, "/promotions/"+bundle.ID.String(), admin, `{"name": "Buy 2 get 1", "kind": "buy_x_get_y", "buy_quantity": 2, "free_quantity": 1, "active": false}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = doRequest(router, http.MethodPost, "/pricing/quote", "", `{"organization_id": "`+organization.ID.String()+`", "items": [{"product_id": "`+pen.ID.String()+`", "quantity": 3}]}`)
+	json.Unmarshal(recorder.Body.Bytes(), &quote)
+	assert.Equal(t, money.Cents(0), quote.Discount)
+
+	assert.Equal(t, http.StatusNoContent, doRequest(router, http.MethodDelete, "/promotions/"+bundle.ID.String(), admin, "").Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(router, http.MethodGet, "/promotions/"+bundle.ID.String(), admin, "").Code)
+	assert.Equal(t, http.StatusNotFound, doRequest(router, http.MethodDelete, "/promotions/"+bundle.ID.String(), admin, "").Code)
+}
@@ -1,42 +1,61 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/jwtauth"
 	"github.com/otthonleao/go-products.git/internal/dto"
 	"github.com/otthonleao/go-products.git/internal/entity"
 	"github.com/otthonleao/go-products.git/internal/infra/database"
+	"github.com/otthonleao/go-products.git/internal/infra/metrics"
+	"github.com/otthonleao/go-products.git/internal/infra/webserver/middlewares"
+	"github.com/otthonleao/go-products.git/pkg/validation"
 )
 
+var ErrNotAMember = errors.New("user is not a member of this organization")
+
 type Error struct {
 	Message string `json:"message"`
+	// Field e Offset indicam o campo e a posição no corpo quando o erro é de decodificação
+	Field  string `json:"field,omitempty"`
+	Offset int64  `json:"offset,omitempty"`
+	// Errors lista as mensagens de validação por campo
+	Errors map[string][]string `json:"errors,omitempty"`
 }
 
 type UserHandler struct {
-	UserDB       database.UserInterface
-	Jwt          *jwtauth.JWTAuth
-	JwtExpiresIn int
+	UserDB         database.UserInterface
+	OrganizationDB database.OrganizationInterface
+	Jwt            *jwtauth.JWTAuth
+	JwtExpiresIn   int
 }
 
-func NewUserHandler(userDB database.UserInterface) *UserHandler {
+func NewUserHandler(userDB database.UserInterface, organizationDB database.OrganizationInterface) *UserHandler {
 	return &UserHandler{
-		UserDB:       userDB,
+		UserDB:         userDB,
+		OrganizationDB: organizationDB,
 	}
 }
 
 // GetJWT godoc
 // @Summary     Get a user JWT
-// @Description Get a user with token JWT with 300 seconds of expiration
+// @Description Get a user with token JWT with 300 seconds of expiration. The token is bound to the given organization or, when omitted, to the first organization the user belongs to.
 // @Tags        users
 // @Accept      json
 // @Produce     json
 // @Param       request     body    dto.GetJWTInput     true    "User credentials"
 // @Success     200     {object}    dto.GetJWTOutput
 // @Failure     401     {object}    Error
+// @Failure     403     {object}    Error
 // @Failure     404		{object}	Error
+// @Failure     413		{object}    Error
+// @Failure     415		{object}    Error
+// @Failure     422		{object}    Error
 // @Failure     500     {object}    Error
 // @Router      /users/login    [post]
 func (handler *UserHandler) GetJWT(response http.ResponseWriter, request *http.Request) {
@@ -46,16 +65,20 @@ func (handler *UserHandler) GetJWT(response http.ResponseWriter, request *http.R
 
 	var user dto.GetJWTInput
 
-	err := json.NewDecoder(request.Body).Decode(&user)
+	err := decodeJSON(request, &user)
 	if err != nil {
-		response.WriteHeader(http.StatusNotFound)
-		err := Error{Message: err.Error()}
-		json.NewEncoder(response).Encode(err)
+		writeDecodeError(response, err)
+		return
+	}
+	err = validation.Validate(user)
+	if err != nil {
+		writeValidationError(response, err)
 		return
 	}
 
-	userRequest, err := handler.UserDB.FindByEmail(user.Email)
+	userRequest, err := handler.UserDB.WithContext(request.Context()).FindByEmail(user.Email)
 	if err != nil {
+		metrics.RecordLogin(false)
 		response.WriteHeader(http.StatusBadRequest)
 		err := Error{Message: err.Error()}
 		json.NewEncoder(response).Encode(err)
@@ -63,17 +86,33 @@ func (handler *UserHandler) GetJWT(response http.ResponseWriter, request *http.R
 	}
 
 	if !userRequest.CheckPassword(user.Password) {
+		metrics.RecordLogin(false)
 		response.WriteHeader(http.StatusUnauthorized)
 		err := Error{Message: "Invalid credentials - password does not match"}
 		json.NewEncoder(response).Encode(err)
 		return
 	}
 
-	_, tokenString, _ := jwt.Encode(map[string]interface{}{
+	claims := map[string]interface{}{
 		"sub": userRequest.ID.String(),
 		"exp": time.Now().Add(time.Hour * time.Duration(jwtExpiresIn)).Unix(),
-	})
+	}
+
+	membership, err := handler.findMembership(request.Context(), userRequest.ID.String(), user.OrganizationID)
+	if err != nil {
+		response.WriteHeader(http.StatusForbidden)
+		err := Error{Message: err.Error()}
+		json.NewEncoder(response).Encode(err)
+		return
+	}
+	if membership != nil {
+		claims["org"] = membership.OrganizationID.String()
+		claims["role"] = membership.Role
+	}
 
+	_, tokenString, _ := jwt.Encode(claims)
+
+	metrics.RecordLogin(true)
 	accessToken := dto.GetJWTOutput{AccessToken: tokenString}
 	response.Header().Set("Content-Type", "application/json")
 	response.WriteHeader(http.StatusOK)
@@ -87,15 +126,27 @@ func (handler *UserHandler) GetJWT(response http.ResponseWriter, request *http.R
 // @Accept		json
 // @Produce		json
 // @Param		request		body	dto.CreateUserInput	true	"User request"
-// @Success		201
+// @Param		Idempotency-Key	header	string	false	"Key to safely retry the request"
+// @Success		201		{object}	entity.User
+// @Header		201		{string}	Location	"URL of the created user"
+// @Failure		400		{object}	Error
+// @Failure		409		{object}	Error
+// @Failure		413		{object}	Error
+// @Failure		415		{object}	Error
+// @Failure		422		{object}	Error
 // @Failure		500		{object}	Error
 // @Router		/users	[post]
 func (handler *UserHandler) Create(response http.ResponseWriter, request *http.Request) {
 	var user dto.CreateUserInput
 
-	err := json.NewDecoder(request.Body).Decode(&user)
+	err := decodeJSON(request, &user)
 	if err != nil {
-		response.WriteHeader(http.StatusBadRequest)
+		writeDecodeError(response, err)
+		return
+	}
+	err = validation.Validate(user)
+	if err != nil {
+		writeValidationError(response, err)
 		return
 	}
 
@@ -107,12 +158,67 @@ func (handler *UserHandler) Create(response http.ResponseWriter, request *http.R
 		return
 	}
 
-	err = handler.UserDB.Create(userRequest)
+	err = handler.UserDB.WithContext(request.Context()).Create(userRequest)
 	if err != nil {
-		response.WriteHeader(http.StatusInternalServerError)
-		error := Error{Message: err.Error()}
-		json.NewEncoder(response).Encode(error)
+		writeRepositoryError(response, err)
 		return
 	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.Header().Set("Location", "/users/"+userRequest.ID.String())
 	response.WriteHeader(http.StatusCreated)
+	json.NewEncoder(response).Encode(userRequest)
+}
+
+// Get user godoc
+// @Summary		Get a user
+// @Description	Get the authenticated user by ID. Other users are not visible and respond 404.
+// @Tags		users
+// @Accept		json
+// @Produce		json
+// @Param		id		path	string	true	"User ID"	Format(uuid)
+// @Success		200		{object}	entity.User
+// @Failure		401		{object}	Error
+// @Failure		404
+// @Router		/users/{id}	[get]
+// @Security	ApiKeyAuth
+func (handler *UserHandler) GetUser(response http.ResponseWriter, request *http.Request) {
+	identity, _ := middlewares.IdentityFromContext(request.Context())
+	id := chi.URLParam(request, "id")
+	if id != identity.UserID {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	user, err := handler.UserDB.WithContext(request.Context()).FindByID(id)
+	if err != nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+	json.NewEncoder(response).Encode(user)
+}
+
+
+// findMembership escolhe a organização que será gravada no token. Sem organização
+// informada, usa a primeira da qual o usuário participa (ou nenhuma).
+func (handler *UserHandler) findMembership(ctx context.Context, userID, organizationID string) (*entity.Membership, error) {
+	if organizationID != "" {
+		membership, err := handler.OrganizationDB.WithContext(ctx).FindMembership(userID, organizationID)
+		if err != nil {
+			return nil, ErrNotAMember
+		}
+		return membership, nil
+	}
+
+	memberships, err := handler.OrganizationDB.WithContext(ctx).FindMembershipsByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(memberships) == 0 {
+		return nil, nil
+	}
+	return &memberships[0], nil
 }
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/jwtauth"
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/database"
+	"github.com/otthonleao/go-products.git/internal/infra/webserver/middlewares"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newUserRouter(t *testing.T) (http.Handler, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	db.AutoMigrate(&entity.User{}, &entity.Organization{}, &entity.Membership{})
+
+	userHandler := NewUserHandler(database.NewUser(db), database.NewOrganization(db))
+
+	route := chi.NewRouter()
+	route.Post("/users", userHandler.Create)
+	route.With(jwtauth.Verifier(testTokenAuth), middlewares.Identify).Get("/users/{id}", userHandler.GetUser)
+	return route, db
+}
+
+func TestUserHandler_Create(t *testing.T) {
+	router, db := newUserRouter(t)
+
+	recorder := doRequest(router, http.MethodPost, "/users", "", `{"name": "John", "email": "j@j.com", "password": "123456"}`)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.NotContains(t, recorder.Body.String(), "password")
+
+	var user entity.User
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &user))
+	assert.Equal(t, "John", user.Name)
+	assert.Equal(t, "j@j.com", user.Email)
+	assert.Equal(t, "/users/"+user.ID.String(), recorder.Header().Get("Location"))
+
+	userFound, err := database.NewUser(db).FindByEmail("j@j.com")
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, userFound.ID)
+
+	// O Location leva ao próprio usuário, que só é visível para ele mesmo
+	_, token, _ := testTokenAuth.Encode(map[string]interface{}{"sub": user.ID.String()})
+	location := recorder.Header().Get("Location")
+	recorder = doRequest(router, http.MethodGet, location, token, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"id": "`+user.ID.String()+`", "name": "John", "email": "j@j.com"}`, recorder.Body.String())
+	assert.Equal(t, http.StatusNotFound, doRequest(router, http.MethodGet, location, tokenFor(t, ""), "").Code)
+	assert.Equal(t, http.StatusUnauthorized, doRequest(router, http.MethodGet, location, "", "").Code)
+}
+
+func TestUserHandler_CreateErrors(t *testing.T) {
+	router, db := newUserRouter(t)
+
+	recorder := doRequest(router, http.MethodPost, "/users", "", `{"name": `)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// O e-mail é único
+	doRequest(router, http.MethodPost, "/users", "", `{"name": "John", "email": "j@j.com", "password": "123456"}`)
+	recorder = doRequest(router, http.MethodPost, "/users", "", `{"name": "Johnny", "email": "j@j.com", "password": "654321"}`)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "email is already in use")
+
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+	recorder = doRequest(router, http.MethodPost, "/users", "", `{"name": "John", "email": "j@j.com", "password": "123456"}`)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
+
+func TestUserHandler_CreateValidation(t *testing.T) {
+	router, _ := newUserRouter(t)
+
+	recorder := doRequest(router, http.MethodPost, "/users", "", `{"name": "J", "email": "not-an-email", "password": ""}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.JSONEq(t, `{
+		"message": "validation failed",
+		"errors": {
+			"name": ["must be at least 2 characters long"],
+			"email": ["must be a valid email address"],
+			"password": ["is required"]
+		}
+	}`, recorder.Body.String())
+}
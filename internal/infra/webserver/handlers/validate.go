@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/otthonleao/go-products.git/internal/infra/database"
+	"github.com/otthonleao/go-products.git/pkg/validation"
+)
+
+// writeValidationError responde 422 com todas as violações por campo.
+func writeValidationError(response http.ResponseWriter, err error) {
+	var validationErrors validation.Errors
+	if !errors.As(err, &validationErrors) {
+		response.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(response).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(response).Encode(Error{
+		Message: "validation failed",
+		Errors:  validationErrors,
+	})
+}
+
+// writeRepositoryError responde 409 para violações de unicidade e 500 para o resto.
+func writeRepositoryError(response http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, database.ErrConflict) {
+		status = http.StatusConflict
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(status)
+	json.NewEncoder(response).Encode(Error{Message: err.Error()})
+}
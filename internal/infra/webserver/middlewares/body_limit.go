@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"net/http"
+	"path"
+)
+
+// BodySizeOverride troca o limite do corpo para as requisições cujo caminho
+// casa com Pattern (sintaxe de path.Match, como "/products/*/images"). Com
+// Method vazio vale para qualquer método.
+type BodySizeOverride struct {
+	Method   string
+	Pattern  string
+	MaxBytes int64
+}
+
+// MaxBodySize limita o corpo das requisições a maxBytes, ou ao limite do primeiro
+// override que casar com a requisição. Ao passar do limite a leitura do corpo
+// falha com *http.MaxBytesError, que os handlers respondem com 413.
+func MaxBodySize(maxBytes int64, overrides ...BodySizeOverride) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			limit := maxBytes
+			for _, override := range overrides {
+				if override.matches(request) {
+					limit = override.MaxBytes
+					break
+				}
+			}
+
+			if request.ContentLength > limit {
+				writeError(response, http.StatusRequestEntityTooLarge, "request body is too large")
+				return
+			}
+			request.Body = http.MaxBytesReader(response, request.Body, limit)
+			next.ServeHTTP(response, request)
+		})
+	}
+}
+
+func (o BodySizeOverride) matches(request *http.Request) bool {
+	if o.Method != "" && o.Method != request.Method {
+		return false
+	}
+	matched, err := path.Match(o.Pattern, request.URL.Path)
+	return err == nil && matched
+}
@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxBodySize_Override(t *testing.T) {
+	handler := MaxBodySize(8, BodySizeOverride{Method: http.MethodPost, Pattern: "/products/*/images", MaxBytes: 64})(
+		http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			if _, err := io.ReadAll(request.Body); err != nil {
+				response.WriteHeader(http.StatusRequestEntityTooLarge)
+			}
+		}))
+
+	send := func(method, target string, size int) int {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(method, target, strings.NewReader(strings.Repeat("a", size))))
+		return recorder.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "/products/1/images", 32))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, send(http.MethodPost, "/products/1/images", 65))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, send(http.MethodPost, "/products", 32))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, send(http.MethodPut, "/products/1/images", 32))
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "/products", 8))
+}
@@ -0,0 +1,235 @@
+package middlewares
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+type CompressOptions struct {
+	// MinSize é o tamanho mínimo do corpo, em bytes, para valer a pena comprimir.
+	MinSize int
+	// ContentTypes lista os tipos de mídia comprimidos (ex.: "application/json").
+	ContentTypes []string
+}
+
+// Codificações suportadas, na ordem de preferência em caso de empate no q-value.
+var supportedEncodings = []string{"br", "gzip"}
+
+// Compress comprime as respostas com brotli ou gzip conforme o Accept-Encoding
+// do cliente. Respostas menores que MinSize, de tipos fora da lista ou que já
+// vêm codificadas seguem sem compressão.
+func Compress(options CompressOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			encoding := negotiateEncoding(request.Header.Get("Accept-Encoding"))
+			if encoding == "" || request.Method == http.MethodHead {
+				response.Header().Add("Vary", "Accept-Encoding")
+				next.ServeHTTP(response, request)
+				return
+			}
+
+			writer := &compressWriter{ResponseWriter: response, encoding: encoding, options: options}
+			next.ServeHTTP(writer, request)
+			// Sem defer: num panic o Recoverer precisa responder 500 sozinho
+			writer.Close()
+		})
+	}
+}
+
+// negotiateEncoding escolhe a codificação de maior q-value aceita pelo cliente.
+// O curinga "*" só vale para as codificações que não foram listadas explicitamente.
+func negotiateEncoding(acceptEncoding string) string {
+	qualities := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		qualities[name] = quality
+	}
+
+	best, bestQuality := "", 0.0
+	for _, encoding := range supportedEncodings {
+		quality, ok := qualities[encoding]
+		if !ok {
+			quality = qualities["*"]
+		}
+		if quality > bestQuality {
+			best, bestQuality = encoding, quality
+		}
+	}
+	return best
+}
+
+// compressWriter guarda os primeiros bytes até saber se a resposta deve ser
+// comprimida: só decide ao atingir MinSize ou no fim do handler.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	options  CompressOptions
+
+	status      int
+	wroteHeader bool
+	decided     bool
+	buffer      []byte
+	encoder     io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+
+	// Sem corpo não há o que comprimir
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.decided {
+		if w.encoder != nil {
+			return w.encoder.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buffer = append(w.buffer, data...)
+	if len(w.buffer) >= w.options.MinSize {
+		if err := w.flushBuffer(w.eligible()); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// eligible verifica se o tipo da resposta está na lista e se ela ainda não foi
+// codificada pelo handler.
+func (w *compressWriter) eligible() bool {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(w.buffer)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(w.options.ContentTypes, mediaType)
+}
+
+func (w *compressWriter) decide(compress bool) {
+	if w.decided {
+		return
+	}
+	w.decided = true
+
+	header := w.Header()
+	header.Add("Vary", "Accept-Encoding")
+	if compress {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		// A representação comprimida não é idêntica byte a byte à original
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+
+		switch w.encoding {
+		case "br":
+			w.encoder = brotli.NewWriterLevel(w.ResponseWriter, brotli.DefaultCompression)
+		default:
+			w.encoder = gzip.NewWriter(w.ResponseWriter)
+		}
+	}
+
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *compressWriter) flushBuffer(compress bool) error {
+	w.decide(compress)
+	buffer := w.buffer
+	w.buffer = nil
+	if len(buffer) == 0 {
+		return nil
+	}
+	if w.encoder != nil {
+		_, err := w.encoder.Write(buffer)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buffer)
+	return err
+}
+
+// Close envia o que ficou no buffer e finaliza o encoder.
+func (w *compressWriter) Close() error {
+	if !w.wroteHeader {
+		// O handler não escreveu nada: mantém o status implícito 200
+		w.decide(false)
+		return nil
+	}
+	if !w.decided {
+		if err := w.flushBuffer(len(w.buffer) >= w.options.MinSize && w.eligible()); err != nil {
+			return err
+		}
+	}
+	if w.encoder != nil {
+		return w.encoder.Close()
+	}
+	return nil
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.flushBuffer(w.eligible())
+	}
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("response does not support hijacking")
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
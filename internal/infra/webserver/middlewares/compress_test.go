@@ -0,0 +1,152 @@
+package middlewares
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+)
+
+var compressOptions = CompressOptions{MinSize: 256, ContentTypes: []string{"application/json", "text/plain"}}
+
+func compressRequest(handler http.Handler, acceptEncoding string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, "/products", nil)
+	if acceptEncoding != "" {
+		request.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	recorder := httptest.NewRecorder()
+	Compress(compressOptions)(handler).ServeHTTP(recorder, request)
+	return recorder
+}
+
+func jsonHandler(body string) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		response.Header().Set("Content-Type", "application/json; charset=utf-8")
+		response.Header().Set("ETag", `"abc"`)
+		response.Write([]byte(body))
+	})
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	assert.Equal(t, "br", negotiateEncoding("gzip, deflate, br"))
+	assert.Equal(t, "gzip", negotiateEncoding("gzip;q=1.0, br;q=0.5"))
+	assert.Equal(t, "gzip", negotiateEncoding("gzip"))
+	assert.Equal(t, "br", negotiateEncoding("*"))
+	assert.Equal(t, "gzip", negotiateEncoding("br;q=0, *"))
+	assert.Equal(t, "", negotiateEncoding("deflate, identity"))
+	assert.Equal(t, "", negotiateEncoding("gzip;q=0"))
+	assert.Equal(t, "", negotiateEncoding(""))
+}
+
+func TestCompress_Gzip(t *testing.T) {
+	body := strings.Repeat(`{"name":"Product"},`, 100)
+	recorder := compressRequest(jsonHandler(body), "gzip")
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "gzip", recorder.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", recorder.Header().Get("Vary"))
+	assert.Equal(t, `W/"abc"`, recorder.Header().Get("ETag"))
+
+	reader, err := gzip.NewReader(recorder.Body)
+	assert.NoError(t, err)
+	decompressed, _ := io.ReadAll(reader)
+	assert.Equal(t, body, string(decompressed))
+}
+
+func TestCompress_Brotli(t *testing.T) {
+	body := strings.Repeat(`{"name":"Product"},`, 100)
+	recorder := compressRequest(jsonHandler(body), "gzip, br")
+
+	assert.Equal(t, "br", recorder.Header().Get("Content-Encoding"))
+	decompressed, _ := io.ReadAll(brotli.NewReader(recorder.Body))
+	assert.Equal(t, body, string(decompressed))
+}
+
+func TestCompress_SkipsSmallBodies(t *testing.T) {
+	recorder := compressRequest(jsonHandler(`{"name":"Product"}`), "gzip")
+
+	assert.Empty(t, recorder.Header().Get("Content-Encoding"))
+	assert.Equal(t, `"abc"`, recorder.Header().Get("ETag"))
+	assert.Equal(t, `{"name":"Product"}`, recorder.Body.String())
+}
+
+func TestCompress_SkipsUnlistedContentTypes(t *testing.T) {
+	body := bytes.Repeat([]byte{0x89, 'P', 'N', 'G'}, 100)
+	handler := http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		response.Header().Set("Content-Type", "image/png")
+		response.Write(body)
+	})
+	recorder := compressRequest(handler, "gzip")
+
+	assert.Empty(t, recorder.Header().Get("Content-Encoding"))
+	assert.Equal(t, body, recorder.Body.Bytes())
+}
+
+func TestCompress_WithoutAcceptEncoding(t *testing.T) {
+	body := strings.Repeat(`{"name":"Product"},`, 100)
+	recorder := compressRequest(jsonHandler(body), "")
+
+	assert.Empty(t, recorder.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", recorder.Header().Get("Vary"))
+	assert.Equal(t, body, recorder.Body.String())
+}
+
+func TestCompress_KeepsStatusWithoutBody(t *testing.T) {
+	handler := http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		response.WriteHeader(http.StatusNotModified)
+	})
+	recorder := compressRequest(handler, "gzip")
+
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("Content-Encoding"))
+	assert.Empty(t, recorder.Body.String())
+}
+
+func TestDecompressRequest(t *testing.T) {
+	var received string
+	handler := DecompressRequest(64)(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		body, err := io.ReadAll(request.Body)
+		if err != nil {
+			response.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		received = string(body)
+	}))
+
+	send := func(body []byte, encoding string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/products/bulk", bytes.NewReader(body))
+		request.Header.Set("Content-Encoding", encoding)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+	gzipped := func(body string) []byte {
+		var compressed bytes.Buffer
+		writer := gzip.NewWriter(&compressed)
+		writer.Write([]byte(body))
+		writer.Close()
+		return compressed.Bytes()
+	}
+
+	recorder := send(gzipped(`[{"name":"Product"}]`), "gzip")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `[{"name":"Product"}]`, received)
+
+	recorder = send([]byte(`[{"name":"Product"}]`), "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = send(gzipped(strings.Repeat("a", 1<<20)), "gzip")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+
+	recorder = send([]byte("not gzip"), "gzip")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = send([]byte("{}"), "deflate")
+	assert.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+}
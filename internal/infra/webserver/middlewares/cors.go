@@ -0,0 +1,129 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CORSOptions struct {
+	// AllowedOrigins aceita origens exatas, "*" e curingas de subdomínio como
+	// "https://*.example.com".
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// CORS responde os preflights (OPTIONS) diretamente, antes de qualquer
+// autenticação, e adiciona os cabeçalhos CORS às demais respostas de origens permitidas.
+func CORS(options CORSOptions) func(http.Handler) http.Handler {
+	allowedMethods := strings.Join(upper(options.AllowedMethods), ", ")
+	allowedHeaders := strings.Join(options.AllowedHeaders, ", ")
+	exposedHeaders := strings.Join(options.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(options.MaxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			origin := request.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(response, request)
+				return
+			}
+
+			header := response.Header()
+			header.Add("Vary", "Origin")
+
+			preflight := request.Method == http.MethodOptions && request.Header.Get("Access-Control-Request-Method") != ""
+			if preflight {
+				header.Add("Vary", "Access-Control-Request-Method")
+				header.Add("Vary", "Access-Control-Request-Headers")
+			}
+
+			if !originAllowed(options.AllowedOrigins, origin) {
+				if preflight {
+					response.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(response, request)
+				return
+			}
+
+			// Com credenciais o navegador não aceita "*": a origem é sempre ecoada
+			if slices.Contains(options.AllowedOrigins, "*") && !options.AllowCredentials {
+				header.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				header.Set("Access-Control-Allow-Origin", origin)
+			}
+			if options.AllowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if exposedHeaders != "" {
+					header.Set("Access-Control-Expose-Headers", exposedHeaders)
+				}
+				next.ServeHTTP(response, request)
+				return
+			}
+
+			requestedMethod := strings.ToUpper(request.Header.Get("Access-Control-Request-Method"))
+			if !slices.Contains(upper(options.AllowedMethods), requestedMethod) {
+				response.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			header.Set("Access-Control-Allow-Methods", allowedMethods)
+			if allowedHeaders != "" {
+				header.Set("Access-Control-Allow-Headers", allowedHeaders)
+			}
+			if options.MaxAge > 0 {
+				header.Set("Access-Control-Max-Age", maxAge)
+			}
+			response.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+func originAllowed(allowed []string, origin string) bool {
+	for _, pattern := range allowed {
+		if pattern == "*" || strings.EqualFold(pattern, origin) {
+			return true
+		}
+		if wildcardOriginMatches(pattern, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// wildcardOriginMatches casa "https://*.example.com" com "https://app.example.com",
+// mas não com "https://example.com" nem com "https://evil-example.com".
+func wildcardOriginMatches(pattern, origin string) bool {
+	scheme, host, ok := strings.Cut(pattern, "://*.")
+	if !ok {
+		return false
+	}
+
+	parsed, err := url.Parse(origin)
+	if err != nil || !strings.EqualFold(parsed.Scheme, scheme) {
+		return false
+	}
+
+	suffix := "." + strings.ToLower(host)
+	originHost := strings.ToLower(parsed.Host)
+	return strings.HasSuffix(originHost, suffix) && len(originHost) > len(suffix)
+}
+
+func upper(values []string) []string {
+	result := make([]string, len(values))
+	for i, value := range values {
+		result[i] = strings.ToUpper(strings.TrimSpace(value))
+	}
+	return result
+}
@@ -0,0 +1,88 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/jwtauth"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCORSRouter(options CORSOptions) http.Handler {
+	route := chi.NewRouter()
+	route.Use(CORS(options))
+	route.Route("/products", func(chiRoute chi.Router) {
+		chiRoute.Use(jwtauth.Verifier(jwtauth.New("HS256", []byte("secret"), nil)))
+		chiRoute.Use(Identify)
+		chiRoute.Get("/", func(response http.ResponseWriter, request *http.Request) {})
+	})
+	return route
+}
+
+func corsRequest(handler http.Handler, method, origin string, headers map[string]string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, "/products", nil)
+	request.Header.Set("Origin", origin)
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestCORS_PreflightBypassesAuthentication(t *testing.T) {
+	router := newCORSRouter(CORSOptions{
+		AllowedOrigins:   []string{"https://*.example.com"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+
+	recorder := corsRequest(router, http.MethodOptions, "https://app.example.com", map[string]string{
+		"Access-Control-Request-Method":  "POST",
+		"Access-Control-Request-Headers": "authorization",
+	})
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "https://app.example.com", recorder.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", recorder.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "GET, POST", recorder.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Authorization, Content-Type", recorder.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", recorder.Header().Get("Access-Control-Max-Age"))
+
+	// A requisição real continua exigindo o JWT
+	recorder = corsRequest(router, http.MethodGet, "https://app.example.com", nil)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Equal(t, "https://app.example.com", recorder.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORS_RejectsUnknownOrigins(t *testing.T) {
+	router := newCORSRouter(CORSOptions{
+		AllowedOrigins: []string{"https://*.example.com", "http://localhost:3000"},
+		AllowedMethods: []string{"GET"},
+	})
+
+	for _, origin := range []string{"https://example.com", "https://evil-example.com", "http://app.example.com", "http://localhost:4000"} {
+		recorder := corsRequest(router, http.MethodOptions, origin, map[string]string{"Access-Control-Request-Method": "GET"})
+		assert.Equal(t, http.StatusNoContent, recorder.Code, origin)
+		assert.Empty(t, recorder.Header().Get("Access-Control-Allow-Origin"), origin)
+	}
+
+	recorder := corsRequest(router, http.MethodOptions, "http://localhost:3000", map[string]string{"Access-Control-Request-Method": "DELETE"})
+	assert.Empty(t, recorder.Header().Get("Access-Control-Allow-Methods"))
+}
+
+func TestCORS_WildcardWithoutCredentials(t *testing.T) {
+	router := newCORSRouter(CORSOptions{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"GET"},
+		ExposedHeaders: []string{"X-Request-ID"},
+	})
+
+	recorder := corsRequest(router, http.MethodGet, "https://anything.io", nil)
+	assert.Equal(t, "*", recorder.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "X-Request-ID", recorder.Header().Get("Access-Control-Expose-Headers"))
+}
@@ -0,0 +1,52 @@
+package middlewares
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DecompressRequest aceita corpos com Content-Encoding gzip, limitando o tamanho
+// descomprimido a maxBytes para barrar zip bombs. Ao passar do limite a leitura
+// do corpo falha com *http.MaxBytesError. Outras codificações respondem 415.
+func DecompressRequest(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			encoding := strings.ToLower(strings.TrimSpace(request.Header.Get("Content-Encoding")))
+			switch encoding {
+			case "", "identity":
+				request.Body = http.MaxBytesReader(response, request.Body, maxBytes)
+				next.ServeHTTP(response, request)
+				return
+			case "gzip", "x-gzip":
+			default:
+				writeError(response, http.StatusUnsupportedMediaType, "unsupported content encoding: "+encoding)
+				return
+			}
+
+			reader, err := gzip.NewReader(request.Body)
+			if err != nil {
+				writeError(response, http.StatusBadRequest, "invalid gzip body")
+				return
+			}
+
+			request.Body = http.MaxBytesReader(response, &gzipBody{Reader: reader, compressed: request.Body}, maxBytes)
+			request.Header.Del("Content-Encoding")
+			request.Header.Del("Content-Length")
+			request.ContentLength = -1
+			next.ServeHTTP(response, request)
+		})
+	}
+}
+
+// gzipBody fecha tanto o leitor gzip quanto o corpo original.
+type gzipBody struct {
+	*gzip.Reader
+	compressed io.Closer
+}
+
+func (b *gzipBody) Close() error {
+	b.Reader.Close()
+	return b.compressed.Close()
+}
@@ -0,0 +1,184 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/database"
+	"gorm.io/gorm"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// Cabeçalhos da resposta original que são reenviados nas retentativas.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag", "Last-Modified"}
+
+// Idempotency grava a resposta das requisições que trazem o cabeçalho
+// Idempotency-Key e a reenvia quando o cliente repete a mesma chave. A chave é
+// única por identidade (usuário ou serviço e organização, ou o IP nas rotas
+// anônimas); reutilizá-la com outro corpo ou em outra rota responde 409.
+// Respostas 5xx não são gravadas para que o cliente possa tentar de novo.
+func Idempotency(keys database.IdempotencyKeyInterface, ttl time.Duration, log *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			key := request.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(response, request)
+				return
+			}
+
+			body, err := io.ReadAll(request.Body)
+			if err != nil {
+				var maxBytesError *http.MaxBytesError
+				if errors.As(err, &maxBytesError) {
+					writeError(response, http.StatusRequestEntityTooLarge, "request body is too large")
+					return
+				}
+				writeError(response, http.StatusBadRequest, "could not read request body")
+				return
+			}
+			request.Body = io.NopCloser(bytes.NewReader(body))
+
+			record, err := entity.NewIdempotencyKey(idempotencyScope(request), key, fingerprint(request, body), ttl)
+			if err != nil {
+				writeError(response, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			repository := keys.WithContext(request.Context())
+			existing, err := reserve(repository, record)
+			if err != nil {
+				writeError(response, http.StatusInternalServerError, "could not store idempotency key")
+				return
+			}
+			if existing != nil {
+				replay(response, existing, record.Fingerprint)
+				return
+			}
+
+			var captured bytes.Buffer
+			wrapped := middleware.NewWrapResponseWriter(response, request.ProtoMajor)
+			wrapped.Tee(&captured)
+
+			next.ServeHTTP(wrapped, request)
+
+			// O cliente pode ter desistido da requisição: a chave precisa ser
+			// finalizada mesmo assim, ou as retentativas ficariam bloqueadas
+			ctx := context.WithoutCancel(request.Context())
+			repository = keys.WithContext(ctx)
+
+			status := wrapped.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusInternalServerError {
+				if err := repository.Delete(record); err != nil {
+					log.ErrorContext(ctx, "could not release idempotency key", slog.Any("error", err))
+				}
+				return
+			}
+
+			header := http.Header{}
+			for _, name := range replayedHeaders {
+				if value := response.Header().Get(name); value != "" {
+					header.Set(name, value)
+				}
+			}
+			record.Complete(status, header, captured.Bytes())
+			if err := repository.Update(record); err != nil {
+				log.ErrorContext(ctx, "could not store idempotent response", slog.Any("error", err))
+			}
+		})
+	}
+}
+
+// reserve grava a chave ainda sem resposta. Se ela já existir, retorna o registro
+// gravado para que a requisição seja reenviada ou rejeitada. Chaves vencidas e
+// reservas abandonadas além de IdempotencyKeyLease são substituídas.
+func reserve(repository database.IdempotencyKeyInterface, record *entity.IdempotencyKey) (*entity.IdempotencyKey, error) {
+	existing, err := repository.Find(record.Scope, record.Key)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if existing != nil {
+		now := time.Now()
+		if !existing.IsExpired(now) && !existing.IsStale(now) {
+			return existing, nil
+		}
+		// Chave vencida que o worker de limpeza ainda não apagou, ou reserva de
+		// uma requisição que não terminou. Delete só apaga a reserva vista aqui,
+		// então entre retentativas concorrentes apenas uma a assume.
+		if err := repository.Delete(existing); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := repository.Create(record); err != nil {
+		// Outra requisição com a mesma chave reservou primeiro
+		existing, findErr := repository.Find(record.Scope, record.Key)
+		if findErr != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+	return nil, nil
+}
+
+func replay(response http.ResponseWriter, record *entity.IdempotencyKey, fingerprint string) {
+	if record.Fingerprint != fingerprint {
+		writeError(response, http.StatusConflict, "idempotency key was already used with a different request")
+		return
+	}
+	if !record.Completed {
+		writeError(response, http.StatusConflict, "a request with this idempotency key is still being processed")
+		return
+	}
+
+	for name, values := range record.Header {
+		for _, value := range values {
+			response.Header().Add(name, value)
+		}
+	}
+	response.Header().Set(IdempotentReplayedHeader, "true")
+	response.WriteHeader(record.StatusCode)
+	response.Write(record.Body)
+}
+
+// idempotencyScope separa as chaves por identidade. Sem identidade as chaves
+// ficam restritas ao IP, para que um cliente anônimo não use nem receba a
+// resposta gravada por outro.
+func idempotencyScope(request *http.Request) string {
+	identity, ok := IdentityFromContext(request.Context())
+	if !ok {
+		host, _, err := net.SplitHostPort(request.RemoteAddr)
+		if err != nil {
+			host = request.RemoteAddr
+		}
+		return "ip:" + host
+	}
+	if identity.ServiceName != "" {
+		return "service:" + identity.ServiceName + "@" + identity.OrganizationID
+	}
+	return "user:" + identity.UserID + "@" + identity.OrganizationID
+}
+
+// fingerprint identifica a requisição pelo método, caminho e corpo.
+func fingerprint(request *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, request.Method+" "+request.URL.Path+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
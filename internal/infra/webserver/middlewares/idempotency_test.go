@@ -0,0 +1,196 @@
+package middlewares
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/database"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newIdempotencyHandler(t *testing.T, status int) (http.Handler, *int32) {
+	handler, calls, _ := newIdempotencyHandlerWithDB(t, status)
+	return handler, calls
+}
+
+func newIdempotencyHandlerWithDB(t *testing.T, status int) (http.Handler, *int32, *database.IdempotencyKey) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	db.AutoMigrate(&entity.IdempotencyKey{})
+
+	var calls int32
+	keys := database.NewIdempotencyKey(db)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := Idempotency(keys, time.Hour, log)(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		response.Header().Set("Content-Type", "application/json")
+		response.Header().Set("Location", "/products/"+string(rune('0'+n)))
+		response.WriteHeader(status)
+		response.Write([]byte(`{"call":` + string(rune('0'+n)) + `}`))
+	}))
+	return handler, &calls, keys
+}
+
+func idempotentRequest(handler http.Handler, key, userID, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(body))
+	if key != "" {
+		request.Header.Set(IdempotencyKeyHeader, key)
+	}
+	if userID != "" {
+		request = request.WithContext(WithIdentity(context.Background(), Identity{UserID: userID, OrganizationID: "org-1"}))
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestIdempotency_ReplaysStoredResponse(t *testing.T) {
+	handler, calls := newIdempotencyHandler(t, http.StatusCreated)
+
+	first := idempotentRequest(handler, "key-1", "user-1", `{"name":"Product"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+
+	retry := idempotentRequest(handler, "key-1", "user-1", `{"name":"Product"}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, first.Header().Get("Location"), retry.Header().Get("Location"))
+	assert.Equal(t, "application/json", retry.Header().Get("Content-Type"))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
+
+func TestIdempotency_DifferentBodyConflicts(t *testing.T) {
+	handler, calls := newIdempotencyHandler(t, http.StatusCreated)
+
+	idempotentRequest(handler, "key-1", "user-1", `{"name":"Product"}`)
+	conflict := idempotentRequest(handler, "key-1", "user-1", `{"name":"Other"}`)
+
+	assert.Equal(t, http.StatusConflict, conflict.Code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
+
+func TestIdempotency_KeysAreScopedByIdentity(t *testing.T) {
+	handler, calls := newIdempotencyHandler(t, http.StatusCreated)
+
+	idempotentRequest(handler, "key-1", "user-1", `{"name":"Product"}`)
+	other := idempotentRequest(handler, "key-1", "user-2", `{"name":"Product"}`)
+
+	assert.Equal(t, http.StatusCreated, other.Code)
+	assert.Empty(t, other.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+}
+
+func TestIdempotency_WithoutKeyAlwaysExecutes(t *testing.T) {
+	handler, calls := newIdempotencyHandler(t, http.StatusCreated)
+
+	idempotentRequest(handler, "", "user-1", `{"name":"Product"}`)
+	idempotentRequest(handler, "", "user-1", `{"name":"Product"}`)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+}
+
+func TestIdempotency_ServerErrorsAreNotStored(t *testing.T) {
+	handler, calls := newIdempotencyHandler(t, http.StatusInternalServerError)
+
+	idempotentRequest(handler, "key-1", "user-1", `{"name":"Product"}`)
+	retry := idempotentRequest(handler, "key-1", "user-1", `{"name":"Product"}`)
+
+	assert.Empty(t, retry.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+}
+
+func TestIdempotency_RejectsLongKeys(t *testing.T) {
+	handler, calls := newIdempotencyHandler(t, http.StatusCreated)
+
+	response := idempotentRequest(handler, strings.Repeat("k", entity.MaxIdempotencyKeyLength+1), "user-1", `{}`)
+
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Equal(t, int32(0), atomic.LoadInt32(calls))
+}
+
+func TestIdempotency_AnonymousKeysAreScopedByIP(t *testing.T) {
+	handler, calls := newIdempotencyHandler(t, http.StatusCreated)
+
+	do := func(remoteAddr string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"User"}`))
+		request.RemoteAddr = remoteAddr
+		request.Header.Set(IdempotencyKeyHeader, "key-1")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	do("10.0.0.1:1234")
+	other := do("10.0.0.2:1234")
+	assert.Empty(t, other.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+
+	retry := do("10.0.0.1:4321")
+	assert.Equal(t, "true", retry.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+}
+
+func TestIdempotency_CompletesWhenClientDisconnects(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	db.AutoMigrate(&entity.IdempotencyKey{})
+
+	ctx, cancel := context.WithCancel(WithIdentity(context.Background(), Identity{UserID: "user-1", OrganizationID: "org-1"}))
+	handler := Idempotency(database.NewIdempotencyKey(db), time.Hour, slog.New(slog.NewTextHandler(io.Discard, nil)))(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		// O cliente desiste enquanto a requisição ainda é processada
+		cancel()
+		response.WriteHeader(http.StatusCreated)
+	}))
+
+	request := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(`{}`)).WithContext(ctx)
+	request.Header.Set(IdempotencyKeyHeader, "key-1")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	record, err := database.NewIdempotencyKey(db).Find("user:user-1@org-1", "key-1")
+	assert.NoError(t, err)
+	assert.True(t, record.Completed)
+	assert.Equal(t, http.StatusCreated, record.StatusCode)
+}
+
+func TestIdempotency_TakesOverStaleReservations(t *testing.T) {
+	handler, calls, keys := newIdempotencyHandlerWithDB(t, http.StatusCreated)
+
+	body := `{"name":"Product"}`
+	request := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(body))
+	reservation, _ := entity.NewIdempotencyKey("user:user-1@org-1", "key-1", fingerprint(request, []byte(body)), time.Hour)
+	assert.NoError(t, keys.Create(reservation))
+
+	// Enquanto a reserva está no prazo, a retentativa é rejeitada
+	busy := idempotentRequest(handler, "key-1", "user-1", body)
+	assert.Equal(t, http.StatusConflict, busy.Code)
+	assert.Equal(t, int32(0), atomic.LoadInt32(calls))
+
+	// Depois do prazo a retentativa assume a chave
+	reservation.LockedUntil = time.Now().Add(-time.Second)
+	assert.NoError(t, keys.Update(reservation))
+	retry := idempotentRequest(handler, "key-1", "user-1", body)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+
+	// A requisição original, se terminar depois, não sobrescreve a nova resposta
+	reservation.Complete(http.StatusAccepted, nil, nil)
+	assert.NoError(t, keys.Update(reservation))
+	replayed := idempotentRequest(handler, "key-1", "user-1", body)
+	assert.Equal(t, http.StatusCreated, replayed.Code)
+	assert.Equal(t, "true", replayed.Header().Get(IdempotentReplayedHeader))
+}
@@ -0,0 +1,152 @@
+package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/jwtauth"
+	"github.com/lestrrat-go/jwx/jwt"
+	"github.com/otthonleao/go-products.git/internal/entity"
+	"github.com/otthonleao/go-products.git/internal/infra/logger"
+)
+
+type identityContextKey struct{}
+
+// Identity representa quem está fazendo a requisição autenticada: um usuário
+// (via JWT) ou um serviço (via certificado de cliente).
+type Identity struct {
+	UserID         string
+	ServiceName    string
+	OrganizationID string
+	Role           string
+}
+
+// Identify lê as claims do JWT verificado por jwtauth.Verifier e coloca a
+// identidade no contexto, respondendo 401 para tokens ausentes ou inválidos.
+func Identify(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		token, claims, err := jwtauth.FromContext(request.Context())
+		if err != nil {
+			writeError(response, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		if token == nil || jwt.Validate(token) != nil {
+			writeError(response, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+			return
+		}
+
+		identity := Identity{
+			UserID:         claimString(claims, "sub"),
+			OrganizationID: claimString(claims, "org"),
+			Role:           claimString(claims, "role"),
+		}
+		if identity.UserID == "" {
+			writeError(response, http.StatusUnauthorized, "token without subject")
+			return
+		}
+		logger.SetUserID(request.Context(), identity.UserID)
+
+		next.ServeHTTP(response, request.WithContext(WithIdentity(request.Context(), identity)))
+	})
+}
+
+// OptionalIdentify é Identify para rotas que também atendem anônimos: sem
+// token a requisição segue sem identidade, mas tokens inválidos ainda
+// respondem 401.
+func OptionalIdentify(next http.Handler) http.Handler {
+	identify := Identify(next)
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		if _, _, err := jwtauth.FromContext(request.Context()); errors.Is(err, jwtauth.ErrNoTokenFound) {
+			next.ServeHTTP(response, request)
+			return
+		}
+		identify.ServeHTTP(response, request)
+	})
+}
+
+// Authenticate aceita um certificado de cliente verificado (mTLS) como identidade
+// de serviço e, na ausência dele, exige o JWT como Identify. O certificado deve
+// trazer o nome do serviço no CN e o ID da organização no campo OU.
+func Authenticate(next http.Handler) http.Handler {
+	identify := Identify(next)
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		identity, ok := identityFromClientCertificate(request)
+		if !ok {
+			identify.ServeHTTP(response, request)
+			return
+		}
+		logger.SetUserID(request.Context(), "service:"+identity.ServiceName)
+		next.ServeHTTP(response, request.WithContext(WithIdentity(request.Context(), identity)))
+	})
+}
+
+func identityFromClientCertificate(request *http.Request) (Identity, bool) {
+	if request.TLS == nil || len(request.TLS.VerifiedChains) == 0 || len(request.TLS.VerifiedChains[0]) == 0 {
+		return Identity{}, false
+	}
+
+	leaf := request.TLS.VerifiedChains[0][0]
+	if leaf.Subject.CommonName == "" {
+		return Identity{}, false
+	}
+
+	identity := Identity{
+		ServiceName: leaf.Subject.CommonName,
+		Role:        entity.RoleMember,
+	}
+	if len(leaf.Subject.OrganizationalUnit) > 0 {
+		identity.OrganizationID = leaf.Subject.OrganizationalUnit[0]
+	}
+	return identity, true
+}
+
+// RequireTenant bloqueia requisições cujo token não carrega uma organização.
+func RequireTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		identity, ok := IdentityFromContext(request.Context())
+		if !ok || identity.OrganizationID == "" {
+			writeError(response, http.StatusForbidden, "token is not bound to an organization")
+			return
+		}
+		next.ServeHTTP(response, request)
+	})
+}
+
+// RequireRole bloqueia requisições cujo papel na organização não está entre os
+// informados.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			identity, _ := IdentityFromContext(request.Context())
+			if !slices.Contains(roles, identity.Role) {
+				writeError(response, http.StatusForbidden, "role is not allowed to access this resource")
+				return
+			}
+			next.ServeHTTP(response, request)
+		})
+	}
+}
+
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityContextKey{}, identity)
+}
+
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityContextKey{}).(Identity)
+	return identity, ok
+}
+
+func claimString(claims map[string]interface{}, key string) string {
+	value, _ := claims[key].(string)
+	return value
+}
+
+func writeError(response http.ResponseWriter, status int, message string) {
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(status)
+	json.NewEncoder(response).Encode(map[string]string{"message": message})
+}
@@ -0,0 +1,77 @@
+package middlewares
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// RequestLogger registra uma linha estruturada por requisição. Deve ficar depois
+// de RequestID para que a linha carregue request_id e user_id.
+func RequestLogger(log *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			start := time.Now()
+			wrapped := middleware.NewWrapResponseWriter(response, request.ProtoMajor)
+
+			next.ServeHTTP(wrapped, request)
+
+			status := wrapped.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			level := slog.LevelInfo
+			if status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			} else if status >= http.StatusBadRequest {
+				level = slog.LevelWarn
+			}
+
+			attributes := []slog.Attr{
+				slog.String("method", request.Method),
+				slog.String("path", request.URL.Path),
+				slog.Int("status", status),
+				slog.Int("bytes", wrapped.BytesWritten()),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("remote_addr", request.RemoteAddr),
+				slog.String("user_agent", request.UserAgent()),
+			}
+			if routeContext := chi.RouteContext(request.Context()); routeContext != nil && routeContext.RoutePattern() != "" {
+				attributes = append(attributes, slog.String("route", routeContext.RoutePattern()))
+			}
+
+			log.LogAttrs(request.Context(), level, "http request", attributes...)
+		})
+	}
+}
+
+// Recoverer transforma panics em 500 e registra a pilha no log estruturado.
+func Recoverer(log *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				// ErrAbortHandler é a forma do net/http de abortar a resposta e não deve ser engolido
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+
+				log.ErrorContext(request.Context(), "panic recovered",
+					slog.Any("panic", recovered),
+					slog.String("stack", string(debug.Stack())),
+				)
+				writeError(response, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			}()
+
+			next.ServeHTTP(response, request)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/jwtauth"
+	"github.com/otthonleao/go-products.git/internal/infra/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID_EchoesOrGenerates(t *testing.T) {
+	handler := RequestID(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		response.Write([]byte(logger.RequestID(request.Context())))
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(RequestIDHeader, "abc-123")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, "abc-123", recorder.Header().Get(RequestIDHeader))
+	assert.Equal(t, "abc-123", recorder.Body.String())
+
+	request = httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(RequestIDHeader, "invalid id with spaces")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.NotEqual(t, "invalid id with spaces", recorder.Header().Get(RequestIDHeader))
+	assert.Len(t, recorder.Header().Get(RequestIDHeader), 36)
+}
+
+func TestRequestLogger_IncludesRequestAndUser(t *testing.T) {
+	var buffer bytes.Buffer
+	log, err := logger.New(&buffer, "info", "json")
+	assert.NoError(t, err)
+
+	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	_, token, _ := tokenAuth.Encode(map[string]interface{}{"sub": "user-42"})
+
+	route := chi.NewRouter()
+	route.Use(RequestID)
+	route.Use(RequestLogger(log))
+	route.Use(Recoverer(log))
+	route.With(jwtauth.Verifier(tokenAuth), Identify).Get("/products/{id}", func(response http.ResponseWriter, request *http.Request) {
+		panic("boom")
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/products/1?token=secret-value", nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set(RequestIDHeader, "req-7")
+	recorder := httptest.NewRecorder()
+	route.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+	lines := bytes.Split(bytes.TrimSpace(buffer.Bytes()), []byte("\n"))
+	assert.Len(t, lines, 2)
+
+	var panicLine, accessLine map[string]interface{}
+	assert.NoError(t, json.Unmarshal(lines[0], &panicLine))
+	assert.NoError(t, json.Unmarshal(lines[1], &accessLine))
+
+	assert.Equal(t, "panic recovered", panicLine["msg"])
+	assert.Equal(t, "user-42", panicLine["user_id"])
+
+	assert.Equal(t, "http request", accessLine["msg"])
+	assert.Equal(t, "ERROR", accessLine["level"])
+	assert.Equal(t, "req-7", accessLine["request_id"])
+	assert.Equal(t, "user-42", accessLine["user_id"])
+	assert.Equal(t, "/products/{id}", accessLine["route"])
+	assert.Equal(t, float64(500), accessLine["status"])
+	assert.NotContains(t, buffer.String(), "secret-value")
+}
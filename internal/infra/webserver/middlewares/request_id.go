@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/otthonleao/go-products.git/internal/infra/logger"
+	"github.com/otthonleao/go-products.git/pkg/entity"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID evita aceitar IDs enormes ou com caracteres que poluam os logs.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// RequestID reaproveita o X-Request-ID recebido (ou gera um novo), devolve-o na
+// resposta e o coloca no contexto para correlação dos logs.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		requestID := request.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = entity.NewID().String()
+		}
+
+		response.Header().Set(RequestIDHeader, requestID)
+		ctx := logger.WithRequestID(request.Context(), requestID)
+		next.ServeHTTP(response, request.WithContext(ctx))
+	})
+}
@@ -0,0 +1,42 @@
+package webserver
+
+import (
+	"crypto/tls"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+)
+
+type ServerOptions struct {
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	// TLSConfig habilita HTTPS quando informado (ver NewTLSConfig).
+	TLSConfig *tls.Config
+	ErrorLog  *log.Logger
+}
+
+// NewServer monta o http.Server com os limites de tempo e de cabeçalho configurados.
+func NewServer(address string, handler http.Handler, options ServerOptions) *http.Server {
+	server := &http.Server{
+		Addr:              address,
+		Handler:           handler,
+		ReadTimeout:       options.ReadTimeout,
+		ReadHeaderTimeout: options.ReadHeaderTimeout,
+		WriteTimeout:      options.WriteTimeout,
+		IdleTimeout:       options.IdleTimeout,
+		MaxHeaderBytes:    options.MaxHeaderBytes,
+		TLSConfig:         options.TLSConfig,
+		ErrorLog:          options.ErrorLog,
+	}
+
+	// Um mapa vazio (não nil) impede o net/http de ativar o HTTP/2 automaticamente
+	if options.TLSConfig != nil && !slices.Contains(options.TLSConfig.NextProtos, "h2") {
+		server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	}
+
+	return server
+}
@@ -0,0 +1,40 @@
+package webserver
+
+import "sync/atomic"
+
+const (
+	StateStarting int32 = iota
+	StateReady
+	StateDraining
+)
+
+// State acompanha o ciclo de vida do servidor para as sondas de prontidão.
+type State struct {
+	value atomic.Int32
+}
+
+func NewState() *State {
+	return &State{}
+}
+
+func (s *State) SetReady() {
+	s.value.Store(StateReady)
+}
+
+func (s *State) SetDraining() {
+	s.value.Store(StateDraining)
+}
+
+func (s *State) IsReady() bool {
+	return s.value.Load() == StateReady
+}
+
+func (s *State) String() string {
+	switch s.value.Load() {
+	case StateReady:
+		return "ready"
+	case StateDraining:
+		return "draining"
+	}
+	return "starting"
+}
@@ -0,0 +1,149 @@
+package webserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrInvalidClientCA = errors.New("no certificates found in client CA bundle")
+
+type TLSOptions struct {
+	// MinVersion aceita "1.2" ou "1.3".
+	MinVersion string
+	HTTP2      bool
+	// ClientCAFile habilita a verificação de certificados de cliente (mTLS).
+	ClientCAFile string
+	// RequireClientCert recusa o handshake de clientes sem certificado válido.
+	RequireClientCert bool
+}
+
+// CertificateReloader mantém o par certificado/chave em memória e o recarrega
+// quando os arquivos mudam em disco, sem precisar reiniciar o servidor.
+type CertificateReloader struct {
+	certFile string
+	keyFile  string
+
+	mu          sync.RWMutex
+	certificate *tls.Certificate
+	modTime     time.Time
+}
+
+func NewCertificateReloader(certFile, keyFile string) (*CertificateReloader, error) {
+	reloader := &CertificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	if err := reloader.Reload(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+// Reload lê novamente o certificado e a chave do disco.
+func (r *CertificateReloader) Reload() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	certificate, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.certificate = &certificate
+	r.modTime = modTime
+	r.mu.Unlock()
+	return nil
+}
+
+// ReloadIfChanged recarrega o certificado somente se algum dos arquivos foi alterado.
+func (r *CertificateReloader) ReloadIfChanged() (bool, error) {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	changed := modTime.After(r.modTime)
+	r.mu.RUnlock()
+
+	if !changed {
+		return false, nil
+	}
+	return true, r.Reload()
+}
+
+func (r *CertificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.certificate, nil
+}
+
+func (r *CertificateReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// NewTLSConfig monta a configuração TLS do servidor a partir das opções.
+func NewTLSConfig(reloader *CertificateReloader, options TLSOptions) (*tls.Config, error) {
+	minVersion, err := ParseTLSVersion(options.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		MinVersion:     minVersion,
+		GetCertificate: reloader.GetCertificate,
+		NextProtos:     []string{"http/1.1"},
+	}
+
+	if options.HTTP2 {
+		config.NextProtos = []string{"h2", "http/1.1"}
+	}
+
+	if options.ClientCAFile != "" {
+		bundle, err := os.ReadFile(options.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, ErrInvalidClientCA
+		}
+
+		config.ClientCAs = pool
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+		if options.RequireClientCert {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	return config, nil
+}
+
+func ParseTLSVersion(version string) (uint16, error) {
+	switch version {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("unsupported TLS version %q", version)
+}
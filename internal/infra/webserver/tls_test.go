@@ -0,0 +1,168 @@
+package webserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-chi/jwtauth"
+	"github.com/otthonleao/go-products.git/internal/infra/webserver/middlewares"
+	"github.com/stretchr/testify/assert"
+)
+
+type testCertificate struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	certPEM     []byte
+	keyPEM      []byte
+}
+
+func newTestCertificate(t *testing.T, subject pkix.Name, parent *testCertificate, isCA bool) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               subject,
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:              []string{"localhost"},
+	}
+
+	parentCert, parentKey := template, key
+	if parent != nil {
+		parentCert, parentKey = parent.certificate, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	assert.NoError(t, err)
+	certificate, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	return &testCertificate{
+		certificate: certificate,
+		key:         key,
+		certPEM:     pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:      pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func writeCertificate(t *testing.T, dir string, cert *testCertificate) (string, string) {
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	assert.NoError(t, os.WriteFile(certFile, cert.certPEM, 0600))
+	assert.NoError(t, os.WriteFile(keyFile, cert.keyPEM, 0600))
+	return certFile, keyFile
+}
+
+func TestCertificateReloader_ReloadIfChanged(t *testing.T) {
+	dir := t.TempDir()
+	first := newTestCertificate(t, pkix.Name{CommonName: "first"}, nil, false)
+	certFile, keyFile := writeCertificate(t, dir, first)
+
+	reloader, err := NewCertificateReloader(certFile, keyFile)
+	assert.NoError(t, err)
+
+	reloaded, err := reloader.ReloadIfChanged()
+	assert.NoError(t, err)
+	assert.False(t, reloaded)
+
+	second := newTestCertificate(t, pkix.Name{CommonName: "second"}, nil, false)
+	writeCertificate(t, dir, second)
+	later := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(certFile, later, later))
+
+	reloaded, err = reloader.ReloadIfChanged()
+	assert.NoError(t, err)
+	assert.True(t, reloaded)
+
+	certificate, _ := reloader.GetCertificate(nil)
+	leaf, _ := x509.ParseCertificate(certificate.Certificate[0])
+	assert.Equal(t, "second", leaf.Subject.CommonName)
+}
+
+func TestParseTLSVersion(t *testing.T) {
+	version, err := ParseTLSVersion("1.3")
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), version)
+
+	_, err = ParseTLSVersion("1.0")
+	assert.Error(t, err)
+}
+
+func TestMutualTLS_ClientCertificateAuthenticatesService(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCertificate(t, pkix.Name{CommonName: "test-ca"}, nil, true)
+	server := newTestCertificate(t, pkix.Name{CommonName: "localhost"}, ca, false)
+	client := newTestCertificate(t, pkix.Name{CommonName: "billing", OrganizationalUnit: []string{"org-123"}}, ca, false)
+
+	certFile, keyFile := writeCertificate(t, dir, server)
+	caFile := filepath.Join(dir, "ca.crt")
+	assert.NoError(t, os.WriteFile(caFile, ca.certPEM, 0600))
+
+	reloader, err := NewCertificateReloader(certFile, keyFile)
+	assert.NoError(t, err)
+	tlsConfig, err := NewTLSConfig(reloader, TLSOptions{MinVersion: "1.2", HTTP2: true, ClientCAFile: caFile})
+	assert.NoError(t, err)
+
+	handler := jwtauth.Verifier(jwtauth.New("HS256", []byte("secret"), nil))(middlewares.Authenticate(
+		http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			identity, _ := middlewares.IdentityFromContext(request.Context())
+			io.WriteString(response, identity.ServiceName+"@"+identity.OrganizationID)
+		}),
+	))
+
+	testServer := httptest.NewUnstartedServer(handler)
+	testServer.TLS = tlsConfig
+	testServer.StartTLS()
+	defer testServer.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.certificate)
+	clientCertificate, err := tls.X509KeyPair(client.certPEM, client.keyPEM)
+	assert.NoError(t, err)
+
+	withCertificate := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      roots,
+		ServerName:   "localhost",
+		Certificates: []tls.Certificate{clientCertificate},
+	}}}
+	response, err := withCertificate.Get(testServer.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	body, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "billing@org-123", string(body))
+
+	// Sem certificado de cliente o JWT continua sendo exigido
+	withoutCertificate := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, ServerName: "localhost"}}}
+	response, err = withoutCertificate.Get(testServer.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	response.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
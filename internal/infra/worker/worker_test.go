@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup_StopWaitsForWorkers(t *testing.T) {
+	group := NewGroup()
+
+	var finished atomic.Bool
+	group.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		finished.Store(true)
+	})
+
+	err := group.Stop(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, finished.Load())
+}
+
+func TestGroup_Every(t *testing.T) {
+	group := NewGroup()
+
+	var runs atomic.Int32
+	group.Every(time.Millisecond, func(ctx context.Context) {
+		runs.Add(1)
+	})
+
+	assert.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, time.Millisecond)
+	assert.NoError(t, group.Stop(context.Background()))
+}
+
+func TestGroup_EveryDisabled(t *testing.T) {
+	group := NewGroup()
+
+	var runs atomic.Int32
+	for _, interval := range []time.Duration{0, -time.Second} {
+		group.Every(interval, func(ctx context.Context) {
+			runs.Add(1)
+		})
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int32(0), runs.Load())
+	assert.NoError(t, group.Stop(context.Background()))
+}
+
+func TestGroup_StopTimeout(t *testing.T) {
+	group := NewGroup()
+
+	release := make(chan struct{})
+	defer close(release)
+	group.Go(func(ctx context.Context) {
+		<-release
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, group.Stop(ctx), context.DeadlineExceeded)
+}
@@ -0,0 +1,150 @@
+// Package imaging identifica, valida e redimensiona imagens enviadas pelos
+// usuários. O tipo é detectado pelo conteúdo, nunca pelo nome do arquivo ou pelo
+// Content-Type declarado.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrTooManyPixels   = errors.New("image dimensions are too large")
+)
+
+// MaxPixels limita largura × altura para que uma imagem pequena em bytes não
+// consuma memória demais ao ser decodificada.
+const MaxPixels = 40_000_000
+
+// ContentTypes são os formatos aceitos.
+var ContentTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+// Size é um tamanho de miniatura: o maior lado da imagem gerada.
+type Size struct {
+	Name      string
+	MaxLength int
+}
+
+// ThumbnailSizes são as miniaturas geradas para cada imagem.
+var ThumbnailSizes = []Size{
+	{Name: "small", MaxLength: 160},
+	{Name: "medium", MaxLength: 480},
+	{Name: "large", MaxLength: 1024},
+}
+
+// Image é uma imagem decodificada e validada.
+type Image struct {
+	ContentType string
+	Width       int
+	Height      int
+	image       image.Image
+}
+
+// Thumbnail é uma miniatura já codificada.
+type Thumbnail struct {
+	Size        Size
+	ContentType string
+	Data        []byte
+}
+
+// Sniff detecta o tipo da imagem pelos primeiros bytes.
+func Sniff(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	for _, supported := range ContentTypes {
+		if contentType == supported {
+			return contentType, nil
+		}
+	}
+	return contentType, ErrUnsupportedType
+}
+
+// Decode valida o tipo e as dimensões antes de decodificar a imagem inteira.
+func Decode(data []byte) (*Image, error) {
+	contentType, err := Sniff(data)
+	if err != nil {
+		return nil, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return &Image{
+		ContentType: contentType,
+		Width:       config.Width,
+		Height:      config.Height,
+		image:       decoded,
+	}, nil
+}
+
+// Thumbnails gera uma miniatura para cada tamanho, mantendo a proporção e sem
+// ampliar imagens menores que o tamanho pedido. Imagens PNG e GIF geram PNG, para
+// preservar a transparência; as demais geram JPEG.
+func (i *Image) Thumbnails(sizes []Size) ([]Thumbnail, error) {
+	thumbnails := make([]Thumbnail, 0, len(sizes))
+	for _, size := range sizes {
+		width, height := fit(i.Width, i.Height, size.MaxLength)
+		resized := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(resized, resized.Bounds(), i.image, i.image.Bounds(), draw.Src, nil)
+
+		contentType, data, err := encode(resized, i.ContentType)
+		if err != nil {
+			return nil, err
+		}
+		thumbnails = append(thumbnails, Thumbnail{Size: size, ContentType: contentType, Data: data})
+	}
+	return thumbnails, nil
+}
+
+func fit(width, height, maxLength int) (int, int) {
+	if width <= maxLength && height <= maxLength {
+		return width, height
+	}
+	if width >= height {
+		return maxLength, max(1, height*maxLength/width)
+	}
+	return max(1, width*maxLength/height), maxLength
+}
+
+func encode(img image.Image, sourceType string) (string, []byte, error) {
+	var buffer bytes.Buffer
+	if sourceType == "image/png" || sourceType == "image/gif" {
+		err := png.Encode(&buffer, img)
+		return "image/png", buffer.Bytes(), err
+	}
+	err := jpeg.Encode(&buffer, img, &jpeg.Options{Quality: 85})
+	return "image/jpeg", buffer.Bytes(), err
+}
+
+// Extension retorna a extensão de arquivo usada para o tipo de imagem.
+func Extension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	default:
+		return ""
+	}
+}
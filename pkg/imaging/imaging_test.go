@@ -0,0 +1,67 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func pngImage(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	var buffer bytes.Buffer
+	assert.NoError(t, png.Encode(&buffer, img))
+	return buffer.Bytes()
+}
+
+func TestSniff(t *testing.T) {
+	contentType, err := Sniff(pngImage(t, 2, 2))
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+
+	contentType, err = Sniff([]byte("<html><body>not an image</body></html>"))
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+	assert.Equal(t, "text/html; charset=utf-8", contentType)
+}
+
+func TestDecodeAndThumbnails(t *testing.T) {
+	img, err := Decode(pngImage(t, 2000, 1000))
+	assert.NoError(t, err)
+	assert.Equal(t, 2000, img.Width)
+	assert.Equal(t, 1000, img.Height)
+
+	thumbnails, err := img.Thumbnails(ThumbnailSizes)
+	assert.NoError(t, err)
+	assert.Len(t, thumbnails, 3)
+
+	expected := map[string][2]int{"small": {160, 80}, "medium": {480, 240}, "large": {1024, 512}}
+	for _, thumbnail := range thumbnails {
+		assert.Equal(t, "image/png", thumbnail.ContentType)
+		config, err := png.DecodeConfig(bytes.NewReader(thumbnail.Data))
+		assert.NoError(t, err)
+		assert.Equal(t, expected[thumbnail.Size.Name], [2]int{config.Width, config.Height}, thumbnail.Size.Name)
+	}
+}
+
+func TestThumbnails_DoNotUpscale(t *testing.T) {
+	img, err := Decode(pngImage(t, 100, 300))
+	assert.NoError(t, err)
+
+	thumbnails, err := img.Thumbnails([]Size{{Name: "small", MaxLength: 150}, {Name: "large", MaxLength: 1024}})
+	assert.NoError(t, err)
+
+	config, _ := png.DecodeConfig(bytes.NewReader(thumbnails[0].Data))
+	assert.Equal(t, [2]int{50, 150}, [2]int{config.Width, config.Height})
+	config, _ = png.DecodeConfig(bytes.NewReader(thumbnails[1].Data))
+	assert.Equal(t, [2]int{100, 300}, [2]int{config.Width, config.Height})
+}
+
+func TestDecode_TooManyPixels(t *testing.T) {
+	// Só o cabeçalho é lido: a imagem não chega a ser decodificada
+	_, err := Decode(pngImage(t, 8000, 6000))
+	assert.ErrorIs(t, err, ErrTooManyPixels)
+}
@@ -0,0 +1,39 @@
+// Package money representa valores monetários em centavos, para que somas e
+// multiplicações sejam exatas.
+package money
+
+import (
+	"fmt"
+	"math"
+)
+
+// Cents é um valor monetário em centavos.
+type Cents int64
+
+// FromFloat converte um preço decimal, como os de entity.Product, arredondando
+// para o centavo mais próximo. Os preços da API têm no máximo duas casas
+// decimais, então o arredondamento só corrige a imprecisão do float64.
+func FromFloat(value float64) Cents {
+	return Cents(math.Round(value * 100))
+}
+
+// Times multiplica o valor pela quantidade.
+func (c Cents) Times(quantity int) Cents {
+	return c * Cents(quantity)
+}
+
+// Float retorna o valor decimal, para exibição ou comparação com preços em float64.
+func (c Cents) Float() float64 {
+	return float64(c) / 100
+}
+
+// String formata o valor com duas casas decimais, como "12.30".
+func (c Cents) String() string {
+	sign := ""
+	value := int64(c)
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, value/100, value%100)
+}
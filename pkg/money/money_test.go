@@ -0,0 +1,24 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromFloat(t *testing.T) {
+	assert.Equal(t, Cents(1999), FromFloat(19.99))
+	// 0.1 + 0.2 em float64 é 0.30000000000000004
+	assert.Equal(t, Cents(30), FromFloat(0.1+0.2))
+	assert.Equal(t, Cents(123456), FromFloat(1234.56))
+	assert.Equal(t, Cents(0), FromFloat(0))
+}
+
+func TestCents(t *testing.T) {
+	price := FromFloat(19.99)
+	assert.Equal(t, Cents(5997), price.Times(3))
+	assert.Equal(t, "59.97", price.Times(3).String())
+	assert.Equal(t, "0.05", Cents(5).String())
+	assert.Equal(t, "-1.50", Cents(-150).String())
+	assert.Equal(t, 19.99, price.Float())
+}
@@ -0,0 +1,344 @@
+// Package validation valida structs a partir da tag `validate`, acumulando todas
+// as violações por campo em vez de parar na primeira.
+//
+// Regras disponíveis, separadas por vírgula:
+//
+//	required       o valor não pode ser vazio (string vazia, zero, nil)
+//	min=N, max=N   tamanho de strings (em caracteres) e slices, ou valor de números
+//	gt=N           números estritamente maiores que N
+//	email          endereço de e-mail válido
+//	uuid           UUID válido
+//	oneof=a b c    um dos valores listados
+//	maxdecimals=N  no máximo N casas decimais
+//	identifier     apenas letras, dígitos, ".", "_" e "-"
+//	gtin           código de barras EAN-13, UPC-A ou GTIN-14 com dígito verificador válido
+//
+// Campos vazios sem a regra required não são validados. O nome do campo nas
+// mensagens é o da tag json.
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Errors associa cada campo inválido às mensagens das regras que falharam.
+type Errors map[string][]string
+
+func (e Errors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	messages := make([]string, 0, len(fields))
+	for _, field := range fields {
+		messages = append(messages, field+": "+strings.Join(e[field], ", "))
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e Errors) add(field, message string) {
+	e[field] = append(e[field], message)
+}
+
+// Validate valida uma struct (ou ponteiro para struct) ou um slice delas. Em
+// slices os campos aparecem com o índice, como "[2].name". Retorna Errors ou nil.
+func Validate(value interface{}) error {
+	errors := Errors{}
+	validateValue(reflect.ValueOf(value), "", errors)
+	if len(errors) == 0 {
+		return nil
+	}
+	return errors
+}
+
+func validateValue(value reflect.Value, prefix string, errors Errors) {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			validateValue(value.Index(i), fmt.Sprintf("%s[%d].", prefix, i), errors)
+		}
+	case reflect.Struct:
+		validateStruct(value, prefix, errors)
+	}
+}
+
+func validateStruct(value reflect.Value, prefix string, errors Errors) {
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := fieldName(field)
+		if name == "-" {
+			continue
+		}
+		name = prefix + name
+
+		fieldValue := value.Field(i)
+		if tag, ok := field.Tag.Lookup("validate"); ok {
+			validateField(fieldValue, name, tag, errors)
+		}
+
+		// Structs e slices aninhados são validados com o nome do campo como prefixo
+		nested := fieldValue
+		for nested.Kind() == reflect.Pointer && !nested.IsNil() {
+			nested = nested.Elem()
+		}
+		if nested.Kind() == reflect.Struct || (nested.Kind() == reflect.Slice && nested.Type().Elem().Kind() == reflect.Struct) {
+			validateValue(nested, name+nestedSeparator(nested), errors)
+		}
+	}
+}
+
+func nestedSeparator(value reflect.Value) string {
+	if value.Kind() == reflect.Struct {
+		return "."
+	}
+	return ""
+}
+
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func validateField(value reflect.Value, name, tag string, errors Errors) {
+	rules := strings.Split(tag, ",")
+	if isEmpty(value) {
+		for _, rule := range rules {
+			if strings.TrimSpace(rule) == "required" {
+				errors.add(name, "is required")
+			}
+		}
+		return
+	}
+
+	for value.Kind() == reflect.Pointer {
+		value = value.Elem()
+	}
+
+	for _, rule := range rules {
+		rule, parameter, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		check, ok := rulesByName[rule]
+		if !ok {
+			if rule == "required" || rule == "" {
+				continue
+			}
+			panic(fmt.Sprintf("validation: unknown rule %q on field %q", rule, name))
+		}
+		if message := check(value, parameter); message != "" {
+			errors.add(name, message)
+		}
+	}
+}
+
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return value.IsNil()
+	case reflect.Map, reflect.Slice:
+		return value.Len() == 0
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	default:
+		return value.IsZero()
+	}
+}
+
+type rule func(value reflect.Value, parameter string) string
+
+var rulesByName = map[string]rule{
+	"min":         minRule,
+	"max":         maxRule,
+	"gt":          gtRule,
+	"email":       emailRule,
+	"uuid":        uuidRule,
+	"oneof":       oneOfRule,
+	"maxdecimals": maxDecimalsRule,
+	"identifier":  identifierRule,
+	"gtin":        gtinRule,
+}
+
+func minRule(value reflect.Value, parameter string) string {
+	limit := mustParseFloat(parameter)
+	switch value.Kind() {
+	case reflect.String:
+		if float64(utf8.RuneCountInString(value.String())) < limit {
+			return fmt.Sprintf("must be at least %s characters long", parameter)
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if float64(value.Len()) < limit {
+			return fmt.Sprintf("must have at least %s items", parameter)
+		}
+	default:
+		if number, ok := toFloat(value); ok && number < limit {
+			return fmt.Sprintf("must be at least %s", parameter)
+		}
+	}
+	return ""
+}
+
+func maxRule(value reflect.Value, parameter string) string {
+	limit := mustParseFloat(parameter)
+	switch value.Kind() {
+	case reflect.String:
+		if float64(utf8.RuneCountInString(value.String())) > limit {
+			return fmt.Sprintf("must be at most %s characters long", parameter)
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if float64(value.Len()) > limit {
+			return fmt.Sprintf("must have at most %s items", parameter)
+		}
+	default:
+		if number, ok := toFloat(value); ok && number > limit {
+			return fmt.Sprintf("must be at most %s", parameter)
+		}
+	}
+	return ""
+}
+
+func gtRule(value reflect.Value, parameter string) string {
+	if number, ok := toFloat(value); ok && number <= mustParseFloat(parameter) {
+		return fmt.Sprintf("must be greater than %s", parameter)
+	}
+	return ""
+}
+
+func emailRule(value reflect.Value, _ string) string {
+	address, err := mail.ParseAddress(value.String())
+	// Rejeita formatos com nome ("Fulano <a@b.com>") e domínios sem ponto
+	if err != nil || address.Address != value.String() || !strings.Contains(address.Address[strings.LastIndex(address.Address, "@"):], ".") {
+		return "must be a valid email address"
+	}
+	return ""
+}
+
+func uuidRule(value reflect.Value, _ string) string {
+	if _, err := uuid.Parse(value.String()); err != nil {
+		return "must be a valid UUID"
+	}
+	return ""
+}
+
+func oneOfRule(value reflect.Value, parameter string) string {
+	options := strings.Fields(parameter)
+	current := fmt.Sprint(value.Interface())
+	for _, option := range options {
+		if current == option {
+			return ""
+		}
+	}
+	return "must be one of: " + strings.Join(options, ", ")
+}
+
+func maxDecimalsRule(value reflect.Value, parameter string) string {
+	number, ok := toFloat(value)
+	if !ok {
+		return ""
+	}
+	formatted := strconv.FormatFloat(number, 'f', -1, 64)
+	_, decimals, _ := strings.Cut(formatted, ".")
+	if len(decimals) > int(mustParseFloat(parameter)) {
+		return fmt.Sprintf("must have at most %s decimal places", parameter)
+	}
+	return ""
+}
+
+func identifierRule(value reflect.Value, _ string) string {
+	if !IsIdentifier(value.String()) {
+		return "must contain only letters, digits, '.', '_' and '-'"
+	}
+	return ""
+}
+
+func gtinRule(value reflect.Value, _ string) string {
+	if !IsGTIN(value.String()) {
+		return "must be a valid EAN-13, UPC-A or GTIN-14 barcode"
+	}
+	return ""
+}
+
+// IsIdentifier indica se s é um identificador de negócio (como um SKU) formado
+// apenas por letras, dígitos, ".", "_" e "-".
+func IsIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		isLetter := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		isDigit := r >= '0' && r <= '9'
+		if !isLetter && !isDigit && r != '.' && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// IsGTIN valida códigos UPC-A (12 dígitos), EAN-13 e GTIN-14 pelo dígito verificador.
+func IsGTIN(code string) bool {
+	if len(code) != 12 && len(code) != 13 && len(code) != 14 {
+		return false
+	}
+
+	sum := 0
+	for i := len(code) - 1; i >= 0; i-- {
+		digit := code[i]
+		if digit < '0' || digit > '9' {
+			return false
+		}
+		if i == len(code)-1 {
+			continue
+		}
+		// Da direita para a esquerda, sem o verificador, os pesos alternam 3 e 1
+		weight := 1
+		if (len(code)-1-i)%2 == 1 {
+			weight = 3
+		}
+		sum += int(digit-'0') * weight
+	}
+
+	check := (10 - sum%10) % 10
+	return int(code[len(code)-1]-'0') == check
+}
+
+func toFloat(value reflect.Value) (float64, bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	}
+	return 0, false
+}
+
+func mustParseFloat(parameter string) float64 {
+	number, err := strconv.ParseFloat(parameter, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validation: invalid rule parameter %q", parameter))
+	}
+	return number
+}
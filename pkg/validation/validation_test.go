@@ -0,0 +1,116 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type product struct {
+	Name     string   `json:"name" validate:"required,min=3,max=10"`
+	Price    float64  `json:"price" validate:"required,gt=0,max=1000,maxdecimals=2"`
+	Quantity int      `json:"quantity" validate:"min=1"`
+	Email    string   `json:"email" validate:"email"`
+	Role     string   `json:"role" validate:"oneof=owner member"`
+	Owner    string   `json:"owner_id" validate:"uuid"`
+	Tags     []string `json:"tags" validate:"max=2"`
+	Note     *string  `json:"note" validate:"required"`
+	ignored  string   `validate:"required"`
+	Items    []item   `json:"items"`
+}
+
+type item struct {
+	SKU string `json:"sku" validate:"required"`
+}
+
+func TestValidate_Valid(t *testing.T) {
+	note := "ok"
+	err := Validate(product{
+		Name:     "Product",
+		Price:    10.5,
+		Quantity: 2,
+		Email:    "john@example.com",
+		Role:     "owner",
+		Owner:    "0b5a4f4e-4a1e-4c8a-9f5e-1b2c3d4e5f60",
+		Tags:     []string{"a"},
+		Note:     &note,
+		Items:    []item{{SKU: "A-1"}},
+	})
+	assert.Nil(t, err)
+}
+
+func TestValidate_AggregatesAllViolations(t *testing.T) {
+	err := Validate(&product{
+		Name:     "ab",
+		Price:    -1.999,
+		Quantity: -1,
+		Email:    "not-an-email",
+		Role:     "admin",
+		Owner:    "123",
+		Tags:     []string{"a", "b", "c"},
+		Items:    []item{{SKU: "A-1"}, {}},
+	})
+
+	errors, ok := err.(Errors)
+	assert.True(t, ok)
+	assert.Equal(t, Errors{
+		"name":         {"must be at least 3 characters long"},
+		"price":        {"must be greater than 0", "must have at most 2 decimal places"},
+		"quantity":     {"must be at least 1"},
+		"email":        {"must be a valid email address"},
+		"role":         {"must be one of: owner, member"},
+		"owner_id":     {"must be a valid UUID"},
+		"tags":         {"must have at most 2 items"},
+		"note":         {"is required"},
+		"items[1].sku": {"is required"},
+	}, errors)
+}
+
+func TestValidate_RequiredStopsOtherRules(t *testing.T) {
+	err := Validate(product{Name: "   "})
+
+	errors := err.(Errors)
+	assert.Equal(t, []string{"is required"}, errors["name"])
+	assert.Equal(t, []string{"is required"}, errors["price"])
+	// Campos opcionais vazios não são validados
+	assert.NotContains(t, errors, "email")
+	assert.NotContains(t, errors, "quantity")
+}
+
+func TestValidate_Slice(t *testing.T) {
+	err := Validate([]item{{SKU: "A-1"}, {}, {}})
+	assert.Equal(t, Errors{"[1].sku": {"is required"}, "[2].sku": {"is required"}}, err)
+}
+
+func TestValidate_Email(t *testing.T) {
+	type input struct {
+		Email string `json:"email" validate:"email"`
+	}
+	assert.Nil(t, Validate(input{Email: "john.doe+tag@mail.example.com"}))
+	assert.NotNil(t, Validate(input{Email: "John <john@example.com>"}))
+	assert.NotNil(t, Validate(input{Email: "john@localhost"}))
+	assert.NotNil(t, Validate(input{Email: "john"}))
+}
+
+func TestErrors_Error(t *testing.T) {
+	errors := Errors{"price": {"must be greater than 0"}, "name": {"is required"}}
+	assert.Equal(t, "name: is required; price: must be greater than 0", errors.Error())
+}
+
+func TestIsGTIN(t *testing.T) {
+	assert.True(t, IsGTIN("4006381333931"))  // EAN-13
+	assert.True(t, IsGTIN("036000291452"))   // UPC-A
+	assert.True(t, IsGTIN("10012345678902")) // GTIN-14
+	assert.False(t, IsGTIN("4006381333932"))
+	assert.False(t, IsGTIN("036000291453"))
+	assert.False(t, IsGTIN("40063813339"))
+	assert.False(t, IsGTIN("400638133393A"))
+	assert.False(t, IsGTIN(""))
+}
+
+func TestIsIdentifier(t *testing.T) {
+	assert.True(t, IsIdentifier("ABC-123_x.1"))
+	assert.False(t, IsIdentifier("ABC 123"))
+	assert.False(t, IsIdentifier("ABC/123"))
+	assert.False(t, IsIdentifier(""))
+}